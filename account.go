@@ -3,7 +3,6 @@ package valr
 import (
 	"context"
 	"fmt"
-	"net/url"
 	"time"
 )
 
@@ -17,16 +16,12 @@ type Balance struct {
 
 // Balances satisfies the PrivateClient interface.
 func (c *client) Balances(ctx context.Context) ([]Balance, error) {
-	res, err := c.httpClient.Get(ctx, "/account/balances", nil)
+	res, err := c.get(ctx, Call{Endpoint: "Balances"}, "/account/balances",
+		nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch account balances: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch account balances: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	var balances []Balance
 	if err = res.JSON(&balances); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal account balances: %w", err)
@@ -41,7 +36,7 @@ type Trade struct {
 	ID           int64     `json:"tradeId"`
 	Price        string    `json:"price"`
 	Quantity     string    `json:"quantity"`
-	Side         string    `json:"side"`
+	Side         Side      `json:"side"`
 	TradedAt     time.Time `json:"tradedAt"`
 }
 
@@ -49,17 +44,12 @@ type Trade struct {
 // your account.
 func (c *client) TradeHistory(ctx context.Context, pair string) ([]Trade,
 	error) {
-	res, err := c.httpClient.Get(ctx, fmt.Sprintf("/account/%s/tradehistory",
-		pair), nil)
+	res, err := c.request(ctx, Call{Endpoint: "TradeHistory", Pair: pair},
+		&TradeHistoryRequest{Pair: pair})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch transaction history: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch transaction history: %d "+
-			"status code recevied", res.StatusCode)
-	}
-
 	var trades []Trade
 	if err = res.JSON(&trades); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal trades: %w", err)
@@ -79,6 +69,7 @@ type Transaction struct {
 	FeeCurrency    string               `json:"feeCurrency,omitempty"`
 	FeeValue       string               `json:"feeValue,omitempty"`
 	EventAt        time.Time            `json:"eventAt,omitempty"`
+	ID             string               `json:"id,omitempty"`
 	TypeInfo       *TransactionTypeInfo `json:"transactionType,omitempty"`
 }
 
@@ -101,24 +92,165 @@ type TransactionTypeInfo struct {
 func (c *client) TransactionHistory(ctx context.Context,
 	req *TransactionHistoryRequest) ([]Transaction, error) {
 
-	params := make(url.Values)
-	if err := c.encoder.Encode(req, params); err != nil {
-		return nil, fmt.Errorf("failed to encode request params: %w", err)
+	res, err := c.request(ctx, Call{Endpoint: "TransactionHistory",
+		Currency: req.Currency}, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction history: %w", err)
+	}
+
+	var transactions []Transaction
+	if err = res.JSON(&transactions); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// MaxTransactionHistoryLimit is the largest page size supported by the
+// TransactionHistory endpoint.
+const MaxTransactionHistoryLimit = 200
+
+// TransactionIterator walks the full transaction history of an account from
+// the most recent transaction backwards, fetching pages as required. Pages are
+// requested using the BeforeID of the last transaction received, falling back
+// to skipping over the transactions already received when IDs are not
+// available.
+//
+// Example:
+//
+//	it := valr.NewTransactionIterator(client, &valr.TransactionHistoryRequest{
+//	    StartTime: start,
+//	    EndTime:   end,
+//	})
+//
+//	for it.Next(ctx) {
+//	    fmt.Println(it.Transaction())
+//	}
+//
+//	if err := it.Err(); err != nil {
+//	    /* Handle the error. */
+//	}
+type TransactionIterator struct {
+	client PrivateClient
+	req    TransactionHistoryRequest
+	types  map[TransactionType]bool
+
+	current Transaction
+	done    bool
+	err     error
+	page    []Transaction
+}
+
+// NewTransactionIterator returns a TransactionIterator which fetches pages
+// using the filters in req. A zero Limit requests the largest page size
+// supported. Transactions outside of StartTime and EndTime, or not matching
+// Types, are skipped even if returned by VALR.
+func NewTransactionIterator(c PrivateClient,
+	req *TransactionHistoryRequest) *TransactionIterator {
+	it := TransactionIterator{client: c}
+	if req != nil {
+		it.req = *req
 	}
 
-	res, err := c.httpClient.Get(ctx, "/account/transactionhistory", params)
+	if it.req.Limit <= 0 || it.req.Limit > MaxTransactionHistoryLimit {
+		it.req.Limit = MaxTransactionHistoryLimit
+	}
+
+	if len(it.req.Types) > 0 {
+		it.types = make(map[TransactionType]bool)
+		for _, typ := range it.req.Types {
+			it.types[typ] = true
+		}
+	}
+
+	return &it
+}
+
+// Next advances the iterator to the next transaction, returning false once the
+// history has been exhausted or an error occurs.
+func (it *TransactionIterator) Next(ctx context.Context) bool {
+	for {
+		if len(it.page) == 0 {
+			if it.done || it.err != nil {
+				return false
+			}
+
+			it.fetch(ctx)
+			continue
+		}
+
+		it.current, it.page = it.page[0], it.page[1:]
+
+		// Walking backwards, everything after a transaction before the start
+		// time is outside of the requested range.
+		if !it.req.StartTime.IsZero() &&
+			it.current.EventAt.Before(it.req.StartTime) {
+			it.done, it.page = true, nil
+			return false
+		}
+
+		if !it.req.EndTime.IsZero() && !it.current.EventAt.Before(
+			it.req.EndTime) {
+			continue
+		}
+
+		if it.types != nil && (it.current.TypeInfo == nil ||
+			!it.types[it.current.TypeInfo.Type]) {
+			continue
+		}
+
+		return true
+	}
+}
+
+func (it *TransactionIterator) fetch(ctx context.Context) {
+	page, err := it.client.TransactionHistory(ctx, &it.req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch transaction history: %w", err)
+		it.err = err
+		return
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch transaction history: %d "+
-			"status code recevied", res.StatusCode)
+	// A short page is the last one.
+	if len(page) < it.req.Limit {
+		it.done = true
 	}
 
+	if len(page) > 0 {
+		last := page[len(page)-1]
+		if last.ID != "" {
+			it.req.BeforeID = last.ID
+			it.req.Offset = 0
+		} else {
+			it.req.Offset += len(page)
+		}
+	}
+
+	it.page = page
+}
+
+// Transaction returns the current transaction.
+func (it *TransactionIterator) Transaction() Transaction {
+	return it.current
+}
+
+// Err returns the error which stopped iteration, if any.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// AllTransactions returns the full transaction history matching req, walking
+// every page using a TransactionIterator.
+func AllTransactions(ctx context.Context, c PrivateClient,
+	req *TransactionHistoryRequest) ([]Transaction, error) {
 	var transactions []Transaction
-	if err = res.JSON(&transactions); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal transactions: %w", err)
+
+	it := NewTransactionIterator(c, req)
+	for it.Next(ctx) {
+		transactions = append(transactions, it.Transaction())
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction history: %w", err)
 	}
 
 	return transactions, nil
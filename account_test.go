@@ -2,6 +2,11 @@ package valr_test
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
@@ -57,7 +62,7 @@ func (suite *accountTestSuite) TestPrivateClient_TradeHistory() {
 			ID:           10634,
 			Price:        "87000",
 			Quantity:     "0.0001",
-			Side:         "buy",
+			Side:         valr.SideBuy,
 			TradedAt: time.Date(2019, 5, 13, 15, 14, 48, 422000000,
 				time.UTC),
 		},
@@ -87,3 +92,154 @@ func (suite *accountTestSuite) TestPrivateClient_TransactionHistory() {
 
 	suite.Require().Contains(history, transactions[0])
 }
+
+func TestTransactionIteratorTestSuite(t *testing.T) {
+	suite.Run(t, new(transactionIteratorTestSuite))
+}
+
+type transactionIteratorTestSuite struct {
+	suite.Suite
+	client       valr.Client
+	queries      []url.Values
+	server       *httptest.Server
+	start        time.Time
+	transactions []valr.Transaction
+	withIDs      bool
+}
+
+func (suite *transactionIteratorTestSuite) SetupTest() {
+	suite.queries = nil
+	suite.withIDs = true
+	suite.start = time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	// 450 transactions, one per hour, most recent first.
+	suite.transactions = make([]valr.Transaction, 450)
+	for i := range suite.transactions {
+		typ := valr.TransactionTypeLimitBuy
+		if i%3 == 0 {
+			typ = valr.TransactionTypeMakerReward
+		}
+
+		suite.transactions[i] = valr.Transaction{
+			EventAt:  suite.start.Add(-time.Duration(i) * time.Hour),
+			ID:       strconv.Itoa(1000 - i),
+			TypeInfo: &valr.TransactionTypeInfo{Type: typ},
+		}
+	}
+
+	suite.server = httptest.NewServer(http.HandlerFunc(suite.handle))
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+}
+
+func (suite *transactionIteratorTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *transactionIteratorTestSuite) handle(w http.ResponseWriter,
+	r *http.Request) {
+	query := r.URL.Query()
+	suite.queries = append(suite.queries, query)
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	skip, _ := strconv.Atoi(query.Get("skip"))
+
+	from := skip
+	if beforeID := query.Get("beforeId"); beforeID != "" {
+		for i, tx := range suite.transactions {
+			if tx.ID == beforeID {
+				from = i + 1
+			}
+		}
+	}
+
+	to := from + limit
+	if to > len(suite.transactions) {
+		to = len(suite.transactions)
+	}
+
+	page := append([]valr.Transaction(nil), suite.transactions[from:to]...)
+	if !suite.withIDs {
+		for i := range page {
+			page[i].ID = ""
+		}
+	}
+
+	json.NewEncoder(w).Encode(page)
+}
+
+func (suite *transactionIteratorTestSuite) TestBeforeID() {
+	transactions, err := valr.AllTransactions(context.TODO(), suite.client,
+		nil)
+	suite.Require().NoError(err)
+	suite.Require().Len(transactions, 450)
+	suite.Require().Equal("1000", transactions[0].ID)
+	suite.Require().Equal("551", transactions[449].ID)
+
+	suite.Require().Len(suite.queries, 3)
+	suite.Require().Equal("200", suite.queries[0].Get("limit"))
+	suite.Require().Equal("", suite.queries[0].Get("beforeId"))
+	suite.Require().Equal("801", suite.queries[1].Get("beforeId"))
+	suite.Require().Equal("601", suite.queries[2].Get("beforeId"))
+	for _, query := range suite.queries {
+		suite.Require().Equal("", query.Get("skip"))
+	}
+}
+
+func (suite *transactionIteratorTestSuite) TestSkip() {
+	suite.withIDs = false
+
+	transactions, err := valr.AllTransactions(context.TODO(), suite.client,
+		&valr.TransactionHistoryRequest{Limit: 100})
+	suite.Require().NoError(err)
+	suite.Require().Len(transactions, 450)
+
+	suite.Require().Len(suite.queries, 5)
+	for i, query := range suite.queries {
+		suite.Require().Equal("100", query.Get("limit"))
+		if i > 0 {
+			suite.Require().Equal(strconv.Itoa(i*100), query.Get("skip"))
+		}
+	}
+}
+
+func (suite *transactionIteratorTestSuite) TestTimeRange() {
+	transactions, err := valr.AllTransactions(context.TODO(), suite.client,
+		&valr.TransactionHistoryRequest{
+			StartTime: suite.start.Add(-300 * time.Hour),
+			EndTime:   suite.start.Add(-10 * time.Hour),
+		})
+	suite.Require().NoError(err)
+	suite.Require().Len(transactions, 290)
+	suite.Require().Equal(suite.start.Add(-11*time.Hour),
+		transactions[0].EventAt)
+	suite.Require().Equal(suite.start.Add(-300*time.Hour),
+		transactions[289].EventAt)
+
+	// Iteration stops once the start time has been passed.
+	suite.Require().Len(suite.queries, 2)
+}
+
+func (suite *transactionIteratorTestSuite) TestTypes() {
+	it := valr.NewTransactionIterator(suite.client,
+		&valr.TransactionHistoryRequest{
+			Types: []valr.TransactionType{valr.TransactionTypeMakerReward},
+		})
+
+	var count int
+	for it.Next(context.TODO()) {
+		suite.Require().Equal(valr.TransactionTypeMakerReward,
+			it.Transaction().TypeInfo.Type)
+		count++
+	}
+	suite.Require().NoError(it.Err())
+	suite.Require().Equal(150, count)
+}
+
+func (suite *transactionIteratorTestSuite) TestError() {
+	suite.server.Close()
+
+	it := valr.NewTransactionIterator(suite.client, nil)
+	suite.Require().False(it.Next(context.TODO()))
+	suite.Require().Error(it.Err())
+	suite.Require().False(it.Next(context.TODO()))
+}
@@ -0,0 +1,361 @@
+// Package alerts watches VALR markets and sends notifications when rules are
+// triggered.
+//
+// An Engine polls the market summaries and order books of the watched pairs,
+// keeping a history of observations for rules which look back over a window.
+// Each rule fires once when its condition starts to hold for a pair, and not
+// again until the condition has cleared, optionally subject to a cooldown.
+// Alerts are delivered to every Notifier, such as a Webhook, an Email or a Go
+// callback.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+)
+
+// DefaultInterval is the default interval at which markets are checked by
+// Run.
+const DefaultInterval = 30 * time.Second
+
+// Alert is sent to notifiers when a rule is triggered.
+type Alert struct {
+	Message string    `json:"message"`
+	Name    string    `json:"name"`
+	Pair    string    `json:"pair"`
+	Time    time.Time `json:"time"`
+}
+
+// Observation is the state of a market at a point in time. Book is nil if the
+// order book could not be fetched.
+type Observation struct {
+	Book    *valr.OrderBook
+	Pair    string
+	Summary valr.MarketSummary
+	Time    time.Time
+}
+
+// Rule is a condition on a market.
+type Rule interface {
+	// Check returns a description of the condition if it holds for the
+	// latest of a pair's observations. Observations are sorted oldest first
+	// and cover at least Lookback when enough history is available.
+	Check(history []Observation) (string, bool)
+
+	// Lookback returns how much history the rule requires.
+	Lookback() time.Duration
+}
+
+// Watch applies a rule to a set of pairs.
+type Watch struct {
+	// Name identifies the watch in alerts. It must be unique.
+	Name  string
+	Pairs []string
+	Rule  Rule
+
+	// Cooldown is the minimum time between alerts for the same pair.
+	Cooldown time.Duration
+}
+
+// Notifier delivers alerts.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc is a Notifier which calls a function.
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+// Notify satisfies the Notifier interface.
+func (fn NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return fn(ctx, alert)
+}
+
+// Engine evaluates watches against market data and sends alerts. An Engine is
+// safe for concurrent use.
+type Engine struct {
+	client    valr.PublicClient
+	errors    func(error)
+	interval  time.Duration
+	notifiers []Notifier
+	now       func() time.Time
+
+	mu      sync.Mutex
+	history map[string][]Observation
+	states  map[string]*state
+	watches []Watch
+}
+
+// state tracks whether a watch's condition holds for a pair.
+type state struct {
+	active bool
+	fired  time.Time
+}
+
+// Option configures an Engine.
+type Option func(*Engine)
+
+// WithClock sets the function used to timestamp observations and alerts.
+func WithClock(now func() time.Time) Option {
+	return func(e *Engine) {
+		e.now = now
+	}
+}
+
+// WithErrorHandler sets a function which is called with the errors which
+// occur while Run checks markets. Errors are ignored by default.
+func WithErrorHandler(fn func(error)) Option {
+	return func(e *Engine) {
+		e.errors = fn
+	}
+}
+
+// WithInterval sets the interval at which Run checks markets, which defaults
+// to DefaultInterval.
+func WithInterval(d time.Duration) Option {
+	return func(e *Engine) {
+		e.interval = d
+	}
+}
+
+// WithNotifier adds a Notifier to which alerts are sent.
+func WithNotifier(n Notifier) Option {
+	return func(e *Engine) {
+		e.notifiers = append(e.notifiers, n)
+	}
+}
+
+// New returns an Engine which fetches market data using client.
+func New(client valr.PublicClient, opts ...Option) *Engine {
+	e := Engine{
+		client:   client,
+		errors:   func(error) {},
+		history:  make(map[string][]Observation),
+		interval: DefaultInterval,
+		now:      time.Now,
+		states:   make(map[string]*state),
+	}
+
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	return &e
+}
+
+// Add adds a watch to the engine. Rules with a Validate method are validated.
+func (e *Engine) Add(w Watch) error {
+	if w.Name == "" || w.Rule == nil || len(w.Pairs) == 0 {
+		return errors.New("alerts: watch requires a name, rule and pairs")
+	}
+
+	if v, ok := w.Rule.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return fmt.Errorf("alerts: invalid rule for %q: %w", w.Name, err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, existing := range e.watches {
+		if existing.Name == w.Name {
+			return fmt.Errorf("alerts: duplicate watch %q", w.Name)
+		}
+	}
+
+	pairs := make([]string, len(w.Pairs))
+	for i, pair := range w.Pairs {
+		pairs[i] = strings.ToUpper(pair)
+	}
+	w.Pairs = pairs
+
+	e.watches = append(e.watches, w)
+	return nil
+}
+
+// Run checks the markets at the engine's interval until ctx is done, which is
+// returned.
+func (e *Engine) Run(ctx context.Context) error {
+	t := time.NewTicker(e.interval)
+	defer t.Stop()
+
+	for {
+		if _, err := e.Check(ctx); err != nil && ctx.Err() == nil {
+			e.errors(err)
+		}
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Check observes the watched markets once, evaluates every watch and sends the
+// alerts triggered to every notifier. The alerts are returned along with the
+// first error which occurred, if any. Alerts are sent even if the data of some
+// pairs could not be fetched.
+func (e *Engine) Check(ctx context.Context) ([]Alert, error) {
+	e.mu.Lock()
+	watches := append([]Watch(nil), e.watches...)
+	e.mu.Unlock()
+
+	observations, err := e.observe(ctx, watches)
+
+	e.mu.Lock()
+	var alerts []Alert
+	for _, w := range watches {
+		for _, pair := range w.Pairs {
+			if _, ok := observations[pair]; !ok {
+				continue
+			}
+
+			if alert, ok := e.evaluate(w, pair); ok {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		for _, n := range e.notifiers {
+			nerr := n.Notify(ctx, alert)
+			if nerr != nil && err == nil {
+				err = fmt.Errorf("failed to notify %s alert for %s: %w",
+					alert.Name, alert.Pair, nerr)
+			}
+		}
+	}
+
+	return alerts, err
+}
+
+// observe fetches the market data of every watched pair, adding it to their
+// history. The observations made are returned by pair.
+func (e *Engine) observe(ctx context.Context, watches []Watch) (
+	map[string]Observation, error) {
+
+	lookback := make(map[string]time.Duration)
+	for _, w := range watches {
+		for _, pair := range w.Pairs {
+			d := w.Rule.Lookback()
+			if current, ok := lookback[pair]; !ok || d > current {
+				lookback[pair] = d
+			}
+		}
+	}
+
+	if len(lookback) == 0 {
+		return nil, nil
+	}
+
+	summaries, err := e.client.MarketSummary(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch market summaries: %w", err)
+	}
+
+	bySymbol := make(map[string]valr.MarketSummary)
+	for _, summary := range summaries {
+		bySymbol[strings.ToUpper(summary.CurrencyPair)] = summary
+	}
+
+	pairs := make([]string, 0, len(lookback))
+	for pair := range lookback {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+
+	now := e.now()
+	observations := make(map[string]Observation)
+
+	var firstErr error
+	for _, pair := range pairs {
+		summary, ok := bySymbol[pair]
+		if !ok {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("no market summary for %s", pair)
+			}
+			continue
+		}
+
+		book, err := e.client.OrderBook(ctx, pair)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to fetch order book: %w", err)
+		}
+
+		observations[pair] = Observation{
+			Book:    book,
+			Pair:    pair,
+			Summary: summary,
+			Time:    now,
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for pair, o := range observations {
+		e.history[pair] = trim(append(e.history[pair], o),
+			now.Add(-lookback[pair]))
+	}
+
+	return observations, firstErr
+}
+
+// trim removes the observations which are no longer needed to look back to
+// cutoff, keeping the latest observation made at or before it and at least
+// the observation before the latest.
+func trim(history []Observation, cutoff time.Time) []Observation {
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].Time.After(cutoff)
+	})
+	if i > 0 {
+		i--
+	}
+	if i > len(history)-2 && i > 0 {
+		i = len(history) - 2
+	}
+
+	return append([]Observation(nil), history[i:]...)
+}
+
+// evaluate checks a watch against a pair's history, returning an alert if the
+// condition has started to hold and the watch is not cooling down.
+func (e *Engine) evaluate(w Watch, pair string) (Alert, bool) {
+	key := w.Name + "/" + pair
+	s, ok := e.states[key]
+	if !ok {
+		s = &state{}
+		e.states[key] = s
+	}
+
+	history := e.history[pair]
+	message, ok := w.Rule.Check(history)
+	if !ok {
+		s.active = false
+		return Alert{}, false
+	}
+
+	// Alerts are only sent when the condition starts to hold.
+	if s.active {
+		return Alert{}, false
+	}
+	s.active = true
+
+	now := history[len(history)-1].Time
+	if !s.fired.IsZero() && now.Sub(s.fired) < w.Cooldown {
+		return Alert{}, false
+	}
+	s.fired = now
+
+	return Alert{Message: message, Name: w.Name, Pair: pair, Time: now},
+		true
+}
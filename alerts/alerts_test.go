@@ -0,0 +1,457 @@
+package alerts_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/alerts"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAlertsTestSuite(t *testing.T) {
+	suite.Run(t, new(alertsTestSuite))
+}
+
+// market is a PublicClient serving market data set by tests.
+type market struct {
+	valr.PublicClient
+	mu        sync.Mutex
+	books     map[string]*valr.OrderBook
+	summaries map[string]valr.MarketSummary
+}
+
+func (m *market) MarketSummary(ctx context.Context) ([]valr.MarketSummary,
+	error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var summaries []valr.MarketSummary
+	for _, summary := range m.summaries {
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+func (m *market) OrderBook(ctx context.Context, pair string) (*valr.OrderBook,
+	error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	book, ok := m.books[pair]
+	if !ok {
+		return nil, &valr.Error{StatusCode: http.StatusBadRequest,
+			Message: "Unsupported currency pair"}
+	}
+
+	return book, nil
+}
+
+type alertsTestSuite struct {
+	suite.Suite
+	alerts []alerts.Alert
+	engine *alerts.Engine
+	market *market
+	now    time.Time
+}
+
+func (suite *alertsTestSuite) SetupTest() {
+	suite.alerts = nil
+	suite.now = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	suite.market = &market{
+		books: map[string]*valr.OrderBook{
+			"BTCZAR": {
+				Asks: []valr.OrderBookEntry{{Price: "101", Quantity: "1"}},
+				Bids: []valr.OrderBookEntry{{Price: "99", Quantity: "1"}},
+			},
+		},
+		summaries: make(map[string]valr.MarketSummary),
+	}
+	suite.set("BTCZAR", "100", "0")
+
+	suite.engine = alerts.New(suite.market,
+		alerts.WithClock(func() time.Time { return suite.now }),
+		alerts.WithNotifier(alerts.NotifierFunc(
+			func(ctx context.Context, alert alerts.Alert) error {
+				suite.alerts = append(suite.alerts, alert)
+				return nil
+			})))
+}
+
+// set sets the last traded price and 24 hour volume of a pair.
+func (suite *alertsTestSuite) set(pair, price, volume string) {
+	suite.market.mu.Lock()
+	defer suite.market.mu.Unlock()
+
+	suite.market.summaries[pair] = valr.MarketSummary{
+		AskPrice:        "101",
+		BaseVolume:      volume,
+		BidPrice:        "99",
+		CurrencyPair:    pair,
+		LastTradedPrice: price,
+	}
+}
+
+// check advances the clock by a minute and checks the markets, returning the
+// messages of the alerts sent.
+func (suite *alertsTestSuite) check() []string {
+	suite.now = suite.now.Add(time.Minute)
+
+	sent := len(suite.alerts)
+	returned, err := suite.engine.Check(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(suite.alerts, sent+len(returned))
+
+	var messages []string
+	for _, alert := range returned {
+		messages = append(messages, alert.Message)
+	}
+
+	return messages
+}
+
+func (suite *alertsTestSuite) TestPriceCross() {
+	suite.Require().NoError(suite.engine.Add(alerts.Watch{
+		Name:  "cross",
+		Pairs: []string{"btczar"},
+		Rule:  alerts.PriceCross{Level: "105"},
+	}))
+
+	suite.Require().Empty(suite.check())
+
+	suite.set("BTCZAR", "106", "0")
+	suite.Require().Equal([]string{"BTCZAR crossed above 105 at 106"},
+		suite.check())
+	suite.Require().Empty(suite.check())
+
+	suite.set("BTCZAR", "104", "0")
+	suite.Require().Equal([]string{"BTCZAR crossed below 105 at 104"},
+		suite.check())
+
+	alert := suite.alerts[1]
+	suite.Require().Equal("cross", alert.Name)
+	suite.Require().Equal("BTCZAR", alert.Pair)
+	suite.Require().Equal(suite.now, alert.Time)
+}
+
+func (suite *alertsTestSuite) TestPercentChange() {
+	suite.Require().NoError(suite.engine.Add(alerts.Watch{
+		Name:  "change",
+		Pairs: []string{"BTCZAR"},
+		Rule: alerts.PercentChange{
+			Percent: "10",
+			Window:  2 * time.Minute,
+		},
+	}))
+
+	// The rule waits for a full window of history.
+	suite.Require().Empty(suite.check())
+	suite.set("BTCZAR", "120", "0")
+	suite.Require().Empty(suite.check())
+
+	suite.set("BTCZAR", "111", "0")
+	suite.Require().Equal([]string{
+		"BTCZAR changed 11.00% in 2m0s from 100 to 111"}, suite.check())
+
+	// The change is measured from 120 two minutes ago.
+	suite.set("BTCZAR", "125", "0")
+	suite.Require().Empty(suite.check())
+}
+
+func (suite *alertsTestSuite) TestSpreadWider() {
+	suite.Require().NoError(suite.engine.Add(alerts.Watch{
+		Name:  "spread",
+		Pairs: []string{"BTCZAR"},
+		Rule:  alerts.SpreadWider{Percent: "2"},
+	}))
+
+	suite.Require().Empty(suite.check())
+
+	suite.market.mu.Lock()
+	suite.market.books["BTCZAR"].Asks[0].Price = "103"
+	suite.market.mu.Unlock()
+
+	suite.Require().Equal([]string{
+		"BTCZAR spread is 3.96% between 99 and 103"}, suite.check())
+}
+
+func (suite *alertsTestSuite) TestVolumeSpike() {
+	suite.Require().NoError(suite.engine.Add(alerts.Watch{
+		Name:  "volume",
+		Pairs: []string{"BTCZAR"},
+		Rule: alerts.VolumeSpike{
+			Multiple: "3",
+			Window:   5 * time.Minute,
+		},
+	}))
+
+	for _, volume := range []string{"1", "2", "3", "4"} {
+		suite.set("BTCZAR", "100", volume)
+		suite.Require().Empty(suite.check())
+	}
+
+	suite.set("BTCZAR", "100", "8")
+	suite.Require().Equal([]string{"BTCZAR traded 4 since the last check, " +
+		"4.0x the average of 1"}, suite.check())
+}
+
+func (suite *alertsTestSuite) TestCooldown() {
+	suite.Require().NoError(suite.engine.Add(alerts.Watch{
+		Cooldown: 5 * time.Minute,
+		Name:     "spread",
+		Pairs:    []string{"BTCZAR"},
+		Rule:     alerts.SpreadWider{Percent: "1"},
+	}))
+
+	// The alert is sent once while the condition holds.
+	suite.Require().Len(suite.check(), 1)
+	suite.Require().Empty(suite.check())
+
+	toggle := func(ask string) {
+		suite.market.mu.Lock()
+		defer suite.market.mu.Unlock()
+		suite.market.books["BTCZAR"].Asks[0].Price = ask
+	}
+
+	// The condition holds again within the cooldown, so no alert is sent
+	// until it clears and holds again afterwards.
+	toggle("99.5")
+	suite.Require().Empty(suite.check())
+	toggle("101")
+	suite.Require().Empty(suite.check())
+
+	toggle("99.5")
+	suite.Require().Empty(suite.check())
+	suite.Require().Empty(suite.check())
+	toggle("101")
+	suite.Require().Len(suite.check(), 1)
+}
+
+func (suite *alertsTestSuite) TestErrors() {
+	suite.Require().Error(suite.engine.Add(alerts.Watch{
+		Name:  "invalid",
+		Pairs: []string{"BTCZAR"},
+		Rule:  alerts.PercentChange{Percent: "10"},
+	}))
+	suite.Require().Error(suite.engine.Add(alerts.Watch{Name: "empty"}))
+
+	watch := alerts.Watch{
+		Name:  "cross",
+		Pairs: []string{"BTCZAR", "ETHZAR"},
+		Rule:  alerts.PriceCross{Level: "105"},
+	}
+	suite.Require().NoError(suite.engine.Add(watch))
+	suite.Require().Error(suite.engine.Add(watch))
+
+	// Pairs with data are still evaluated when others fail.
+	_, err := suite.engine.Check(context.TODO())
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "ETHZAR")
+
+	suite.set("BTCZAR", "110", "0")
+	returned, err := suite.engine.Check(context.TODO())
+	suite.Require().Error(err)
+	suite.Require().Len(returned, 1)
+}
+
+func (suite *alertsTestSuite) TestRun() {
+	failure := errors.New("failure")
+	sent := make(chan alerts.Alert, 1)
+	errs := make(chan error, 1)
+
+	engine := alerts.New(suite.market,
+		alerts.WithInterval(time.Millisecond),
+		alerts.WithErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}),
+		alerts.WithNotifier(alerts.NotifierFunc(
+			func(ctx context.Context, alert alerts.Alert) error {
+				select {
+				case sent <- alert:
+				default:
+				}
+				return failure
+			})))
+	suite.Require().NoError(engine.Add(alerts.Watch{
+		Name:  "spread",
+		Pairs: []string{"BTCZAR"},
+		Rule:  alerts.SpreadWider{Percent: "1"},
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- engine.Run(ctx)
+	}()
+
+	alert := <-sent
+	suite.Require().Equal("spread", alert.Name)
+	suite.Require().True(errors.Is(<-errs, failure))
+
+	cancel()
+	suite.Require().True(errors.Is(<-done, context.Canceled))
+}
+
+func (suite *alertsTestSuite) TestWebhook() {
+	var (
+		body      alerts.Alert
+		decodeErr error
+		header    http.Header
+		method    string
+	)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			header, method = r.Header, r.Method
+			decodeErr = json.NewDecoder(r.Body).Decode(&body)
+
+			if body.Pair != "BTCZAR" {
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	defer server.Close()
+
+	webhook := alerts.Webhook{
+		Header: http.Header{"Authorization": {"Bearer token"}},
+		URL:    server.URL,
+	}
+
+	alert := alerts.Alert{
+		Message: "BTCZAR crossed above 105 at 106",
+		Name:    "cross",
+		Pair:    "BTCZAR",
+		Time:    suite.now,
+	}
+	suite.Require().NoError(webhook.Notify(context.TODO(), alert))
+	suite.Require().Equal(http.MethodPost, method)
+	suite.Require().NoError(decodeErr)
+	suite.Require().Equal(alert, body)
+	suite.Require().Equal("Bearer token", header.Get("Authorization"))
+	suite.Require().Equal("application/json", header.Get("Content-Type"))
+
+	alert.Pair = "ETHZAR"
+	err := webhook.Notify(context.TODO(), alert)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "500")
+}
+
+func (suite *alertsTestSuite) TestEmail() {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(l, received)
+
+	email := alerts.Email{
+		Addr: l.Addr().String(),
+		From: "alerts@example.com",
+		To:   []string{"trader@example.com"},
+	}
+	suite.Require().NoError(email.Notify(context.TODO(), alerts.Alert{
+		Message: "BTCZAR crossed above 105 at 106",
+		Name:    "cross",
+		Pair:    "BTCZAR",
+		Time:    suite.now,
+	}))
+
+	msg := <-received
+	suite.Require().Contains(msg, "MAIL FROM:<alerts@example.com>")
+	suite.Require().Contains(msg, "RCPT TO:<trader@example.com>")
+	suite.Require().Contains(msg, "Subject: VALR alert: cross BTCZAR\r\n")
+	suite.Require().Contains(msg, "\r\n\r\nBTCZAR crossed above 105 at 106\r\n")
+
+	suite.Require().Error(alerts.Email{Addr: email.Addr}.Notify(
+		context.TODO(), alerts.Alert{}))
+}
+
+func (suite *alertsTestSuite) TestEmailHeaderInjection() {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	defer l.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(l, received)
+
+	email := alerts.Email{
+		Addr: l.Addr().String(),
+		From: "alerts@example.com",
+		To:   []string{"trader@example.com"},
+	}
+	suite.Require().NoError(email.Notify(context.TODO(), alerts.Alert{
+		Message: "BTCZAR crossed above 105 at 106",
+		Name:    "cross\r\nBcc: attacker@example.com\n",
+		Pair:    "BTCZAR",
+		Time:    suite.now,
+	}))
+
+	// Line breaks in the name are kept within the subject.
+	msg := <-received
+	suite.Require().Contains(msg, "Subject: VALR alert: cross Bcc: "+
+		"attacker@example.com  BTCZAR\r\n")
+	suite.Require().NotContains(msg, "\r\nBcc:")
+}
+
+// serveSMTP accepts a single connection, speaking just enough SMTP to receive
+// a message, and sends the session received from the client.
+func serveSMTP(l net.Listener, received chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var session strings.Builder
+	r := bufio.NewReader(conn)
+	reply := func(s string) {
+		_, _ = conn.Write([]byte(s + "\r\n"))
+	}
+
+	reply("220 localhost ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		session.WriteString(line)
+
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			for {
+				line, err = r.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				session.WriteString(line)
+			}
+			reply("250 ok")
+		case cmd == "QUIT":
+			reply("221 bye")
+			received <- session.String()
+			_, _ = ioutil.ReadAll(r)
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+
+	received <- session.String()
+}
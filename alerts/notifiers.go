@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Webhook is a Notifier which POSTs alerts to a URL as JSON.
+type Webhook struct {
+	// Client is used to send requests, or http.DefaultClient if nil.
+	Client *http.Client
+
+	// Header is added to every request.
+	Header http.Header
+
+	URL string
+}
+
+// Notify satisfies the Notifier interface. Responses without a 2xx status are
+// treated as errors.
+func (w Webhook) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL,
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for key, values := range w.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// Email is a Notifier which sends alerts by email over SMTP.
+type Email struct {
+	// Addr is the address of the SMTP server, e.g. "smtp.example.com:587".
+	Addr string
+
+	// Auth authenticates with the server, if not nil.
+	Auth smtp.Auth
+
+	From string
+	To   []string
+}
+
+// headerValue replaces line breaks in a header value, which would otherwise
+// allow it to add headers to or start the body of an email.
+var headerValue = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Notify satisfies the Notifier interface. The context is not used as
+// net/smtp does not support cancellation.
+func (e Email) Notify(ctx context.Context, alert Alert) error {
+	if len(e.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&msg, "Subject: VALR alert: %s %s\r\n",
+		headerValue.Replace(alert.Name), headerValue.Replace(alert.Pair))
+	fmt.Fprintf(&msg, "Date: %s\r\n", alert.Time.Format(time.RFC1123Z))
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(alert.Message + "\r\n")
+
+	if err := smtp.SendMail(e.Addr, e.Auth, e.From, e.To,
+		msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
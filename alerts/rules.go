@@ -0,0 +1,284 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// PriceCross fires when the last traded price crosses Level in either
+// direction between consecutive observations.
+type PriceCross struct {
+	Level string
+}
+
+// Check satisfies the Rule interface.
+func (r PriceCross) Check(history []Observation) (string, bool) {
+	if len(history) < 2 {
+		return "", false
+	}
+
+	level, ok := new(big.Rat).SetString(r.Level)
+	if !ok {
+		return "", false
+	}
+
+	current := history[len(history)-1]
+	last, ok := lastPrice(current)
+	if !ok {
+		return "", false
+	}
+
+	previous, ok := lastPrice(history[len(history)-2])
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case previous.Cmp(level) < 0 && last.Cmp(level) >= 0:
+		return fmt.Sprintf("%s crossed above %s at %s", current.Pair, r.Level,
+			current.Summary.LastTradedPrice), true
+	case previous.Cmp(level) > 0 && last.Cmp(level) <= 0:
+		return fmt.Sprintf("%s crossed below %s at %s", current.Pair, r.Level,
+			current.Summary.LastTradedPrice), true
+	default:
+		return "", false
+	}
+}
+
+// Lookback satisfies the Rule interface. Only the previous observation is
+// required.
+func (r PriceCross) Lookback() time.Duration {
+	return 0
+}
+
+// Validate returns an error if the level is invalid.
+func (r PriceCross) Validate() error {
+	return validatePositive("level", r.Level)
+}
+
+// PercentChange fires when the last traded price has changed by at least
+// Percent, in either direction, since Window ago.
+type PercentChange struct {
+	Percent string
+	Window  time.Duration
+}
+
+// Check satisfies the Rule interface. The price Window ago is taken from the
+// latest observation made at or before then, so the rule does not fire until
+// enough history has been observed.
+func (r PercentChange) Check(history []Observation) (string, bool) {
+	threshold, ok := new(big.Rat).SetString(r.Percent)
+	if !ok || len(history) < 2 {
+		return "", false
+	}
+
+	current := history[len(history)-1]
+	cutoff := current.Time.Add(-r.Window)
+	if history[0].Time.After(cutoff) {
+		return "", false
+	}
+
+	var past Observation
+	for _, o := range history {
+		if o.Time.After(cutoff) {
+			break
+		}
+		past = o
+	}
+
+	last, ok := lastPrice(current)
+	if !ok {
+		return "", false
+	}
+
+	before, ok := lastPrice(past)
+	if !ok || before.Sign() == 0 {
+		return "", false
+	}
+
+	change := new(big.Rat).Sub(last, before)
+	change.Mul(change, big.NewRat(100, 1))
+	change.Quo(change, before)
+
+	if new(big.Rat).Abs(change).Cmp(threshold) < 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s changed %s%% in %s from %s to %s", current.Pair,
+		change.FloatString(2), r.Window, past.Summary.LastTradedPrice,
+		current.Summary.LastTradedPrice), true
+}
+
+// Lookback satisfies the Rule interface.
+func (r PercentChange) Lookback() time.Duration {
+	return r.Window
+}
+
+// Validate returns an error if the percentage or window is invalid.
+func (r PercentChange) Validate() error {
+	if r.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+
+	return validatePositive("percent", r.Percent)
+}
+
+// SpreadWider fires when the spread between the best ask and bid is wider than
+// Percent of the price in the middle of the spread. The order book is used if
+// it was fetched, or else the market summary.
+type SpreadWider struct {
+	Percent string
+}
+
+// Check satisfies the Rule interface.
+func (r SpreadWider) Check(history []Observation) (string, bool) {
+	threshold, ok := new(big.Rat).SetString(r.Percent)
+	if !ok || len(history) == 0 {
+		return "", false
+	}
+
+	current := history[len(history)-1]
+	askPrice, bidPrice := current.Summary.AskPrice, current.Summary.BidPrice
+	if book := current.Book; book != nil {
+		if len(book.Asks) == 0 || len(book.Bids) == 0 {
+			return "", false
+		}
+		askPrice, bidPrice = book.Asks[0].Price, book.Bids[0].Price
+	}
+
+	ask, ok := new(big.Rat).SetString(askPrice)
+	if !ok {
+		return "", false
+	}
+
+	bid, ok := new(big.Rat).SetString(bidPrice)
+	if !ok {
+		return "", false
+	}
+
+	mid := new(big.Rat).Add(ask, bid)
+	mid.Quo(mid, big.NewRat(2, 1))
+	if mid.Sign() <= 0 {
+		return "", false
+	}
+
+	spread := new(big.Rat).Sub(ask, bid)
+	percent := new(big.Rat).Mul(spread, big.NewRat(100, 1))
+	percent.Quo(percent, mid)
+
+	if percent.Cmp(threshold) <= 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s spread is %s%% between %s and %s", current.Pair,
+		percent.FloatString(2), bidPrice, askPrice), true
+}
+
+// Lookback satisfies the Rule interface.
+func (r SpreadWider) Lookback() time.Duration {
+	return 0
+}
+
+// Validate returns an error if the percentage is invalid.
+func (r SpreadWider) Validate() error {
+	return validatePositive("percent", r.Percent)
+}
+
+// VolumeSpike fires when the volume traded since the previous observation is
+// at least Multiple times the average volume traded between the earlier
+// observations within Window. Volumes are derived from the change in the
+// market summary's 24 hour base volume.
+type VolumeSpike struct {
+	Multiple string
+	Window   time.Duration
+}
+
+// Check satisfies the Rule interface.
+func (r VolumeSpike) Check(history []Observation) (string, bool) {
+	multiple, ok := new(big.Rat).SetString(r.Multiple)
+	if !ok || len(history) < 3 {
+		return "", false
+	}
+
+	current := history[len(history)-1]
+	cutoff := current.Time.Add(-r.Window)
+
+	// The volume traded between each pair of observations. The 24 hour
+	// volume falls as old trades leave its window, which is treated as no
+	// volume.
+	var volumes []*big.Rat
+	for i := 1; i < len(history); i++ {
+		if history[i-1].Time.Before(cutoff) {
+			continue
+		}
+
+		before, ok := new(big.Rat).SetString(history[i-1].Summary.BaseVolume)
+		if !ok {
+			return "", false
+		}
+
+		after, ok := new(big.Rat).SetString(history[i].Summary.BaseVolume)
+		if !ok {
+			return "", false
+		}
+
+		volume := after.Sub(after, before)
+		if volume.Sign() < 0 {
+			volume.SetInt64(0)
+		}
+		volumes = append(volumes, volume)
+	}
+
+	if len(volumes) < 2 {
+		return "", false
+	}
+
+	latest := volumes[len(volumes)-1]
+	average := new(big.Rat)
+	for _, volume := range volumes[:len(volumes)-1] {
+		average.Add(average, volume)
+	}
+	average.Quo(average, big.NewRat(int64(len(volumes)-1), 1))
+
+	if average.Sign() == 0 ||
+		latest.Cmp(new(big.Rat).Mul(average, multiple)) < 0 {
+		return "", false
+	}
+
+	ratio := new(big.Rat).Quo(latest, average)
+	return fmt.Sprintf("%s traded %s since the last check, %sx the average "+
+		"of %s", current.Pair, decimal.FormatPlaces(latest, 8),
+		ratio.FloatString(1), decimal.FormatPlaces(average, 8)), true
+}
+
+// Lookback satisfies the Rule interface.
+func (r VolumeSpike) Lookback() time.Duration {
+	return r.Window
+}
+
+// Validate returns an error if the multiple or window is invalid.
+func (r VolumeSpike) Validate() error {
+	if r.Window <= 0 {
+		return errors.New("window must be positive")
+	}
+
+	return validatePositive("multiple", r.Multiple)
+}
+
+// lastPrice returns the last traded price of an observation.
+func lastPrice(o Observation) (*big.Rat, bool) {
+	return new(big.Rat).SetString(o.Summary.LastTradedPrice)
+}
+
+// validatePositive returns an error if s is not a positive decimal.
+func validatePositive(name, s string) error {
+	if _, err := decimal.ParsePositive(s); err != nil {
+		return fmt.Errorf("%s %w", name, err)
+	}
+
+	return nil
+}
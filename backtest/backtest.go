@@ -0,0 +1,532 @@
+// Package backtest replays recorded trades and order books through a trading
+// strategy, simulating its orders with the paper package.
+//
+// Strategies are written against valr.Client and receive the same Trade and
+// OrderBook types as the live API, so they run unchanged in live and backtest
+// modes. During a backtest the client's public methods return the market as
+// it was at the time of the current event, and orders are filled against the
+// recorded order book at the time they reach the exchange.
+package backtest
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+	"github.com/nickcorin/valr/paper"
+)
+
+// DefaultCurrency is the currency in which equity is valued by default.
+const DefaultCurrency = "ZAR"
+
+// ErrStop may be returned by a Strategy to end the backtest early without an
+// error.
+var ErrStop = errors.New("backtest: stop")
+
+// Strategy is called with each event of the replayed market data in time
+// order. Strategies should not call the client concurrently.
+type Strategy func(ctx context.Context, client valr.Client, event Event) error
+
+// LatencyModel returns the delay between an order being placed and it
+// reaching the exchange.
+type LatencyModel func() time.Duration
+
+// FixedLatency returns a LatencyModel which always delays orders by d.
+func FixedLatency(d time.Duration) LatencyModel {
+	return func() time.Duration {
+		return d
+	}
+}
+
+// RandomLatency returns a LatencyModel which delays orders by a uniformly
+// random duration between min and max. The same seed gives the same delays.
+func RandomLatency(min, max time.Duration, seed int64) LatencyModel {
+	r := rand.New(rand.NewSource(seed))
+	return func() time.Duration {
+		if max <= min {
+			return min
+		}
+
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+type config struct {
+	currency string
+	latency  LatencyModel
+	pairs    []valr.CurrencyPair
+	paper    []paper.Option
+}
+
+// Option configures a backtest.
+type Option func(*config)
+
+// WithBalance deposits an amount of a currency into the account before the
+// backtest starts.
+func WithBalance(currency, amount string) Option {
+	return func(c *config) {
+		c.paper = append(c.paper, paper.WithBalance(currency, amount))
+	}
+}
+
+// WithCurrency sets the currency in which equity is valued, which defaults to
+// DefaultCurrency.
+func WithCurrency(currency string) Option {
+	return func(c *config) {
+		c.currency = strings.ToUpper(currency)
+	}
+}
+
+// WithCurrencyPairs sets the currency pairs which may be traded. By default
+// the pairs are inferred from the recorded data, assuming each is quoted in
+// the valuation currency.
+func WithCurrencyPairs(pairs ...valr.CurrencyPair) Option {
+	return func(c *config) {
+		c.pairs = pairs
+	}
+}
+
+// WithFees sets the maker and taker fee rates, as a fraction of the amount
+// received. See paper.WithFees.
+func WithFees(maker, taker string) Option {
+	return func(c *config) {
+		c.paper = append(c.paper, paper.WithFees(maker, taker))
+	}
+}
+
+// WithLatency sets the model used to delay orders. Orders are placed without
+// delay by default.
+func WithLatency(model LatencyModel) Option {
+	return func(c *config) {
+		c.latency = model
+	}
+}
+
+// EquityPoint is the value of the account at a point in time.
+type EquityPoint struct {
+	Time  time.Time
+	Value *big.Rat
+}
+
+// Fill is the execution of part of an order.
+type Fill struct {
+	Fee         *big.Rat
+	FeeCurrency string
+	OrderID     string
+	Pair        string
+	Price       *big.Rat
+	Quantity    *big.Rat
+	Side        valr.Side
+	Time        time.Time
+}
+
+// Stats summarises the performance of a backtest. Equity amounts are in the
+// valuation currency.
+type Stats struct {
+	// Start and End are the first and last values of the equity curve.
+	Start *big.Rat
+	End   *big.Rat
+
+	// Return is the change in equity as a fraction of Start.
+	Return *big.Rat
+
+	// MaxDrawdown is the largest fall in equity from a previous peak, as a
+	// fraction of that peak.
+	MaxDrawdown *big.Rat
+
+	// Fees is the total fees paid by currency.
+	Fees map[string]*big.Rat
+
+	Fills  int
+	Orders int
+}
+
+// Result is the outcome of a backtest.
+type Result struct {
+	// Balances are the account's balances at the end of the backtest.
+	Balances []valr.Balance
+
+	// Equity is the value of the account after each event. Events at which
+	// some holdings have no price yet are omitted.
+	Equity []EquityPoint
+
+	// Fills are the executions of the strategy's orders in time order.
+	Fills []Fill
+
+	Stats Stats
+}
+
+// client is the valr.Client given to strategies, which delays orders by the
+// latency model.
+type client struct {
+	*paper.Client
+
+	mu      sync.Mutex
+	latency LatencyModel
+	market  *market
+}
+
+// LimitOrder satisfies the valr.PrivateClient interface.
+func (c *client) LimitOrder(ctx context.Context, req *valr.LimitOrderRequest) (
+	string, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.market.arrive(c.latency())()
+
+	return c.Client.LimitOrder(ctx, req)
+}
+
+// MarketOrder satisfies the valr.PrivateClient interface.
+func (c *client) MarketOrder(ctx context.Context,
+	req *valr.MarketOrderRequest) (string, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.market.arrive(c.latency())()
+
+	return c.Client.MarketOrder(ctx, req)
+}
+
+// SimpleOrder satisfies the valr.PrivateClient interface.
+func (c *client) SimpleOrder(ctx context.Context,
+	req *valr.SimpleOrderRequest) (string, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	defer c.market.arrive(c.latency())()
+
+	return c.Client.SimpleOrder(ctx, req)
+}
+
+// Run replays data through strategy and returns the results. Resting orders
+// are filled against each new order book before the strategy sees the event,
+// and the account is valued after the strategy has handled it.
+func Run(ctx context.Context, data Data, strategy Strategy,
+	opts ...Option) (*Result, error) {
+
+	cfg := config{currency: DefaultCurrency, latency: FixedLatency(0)}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.pairs == nil {
+		pairs, err := inferPairs(data, cfg.currency)
+		if err != nil {
+			return nil, err
+		}
+		cfg.pairs = pairs
+	}
+
+	m := newMarket(data, cfg.pairs)
+	account, err := paper.New(m, append([]paper.Option{
+		paper.WithClock(m.time)}, cfg.paper...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := client{Client: account, latency: cfg.latency, market: m}
+
+	var res Result
+	for _, event := range events(data) {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		m.advance(event.Time)
+
+		if _, err = account.Balances(ctx); err != nil {
+			return nil, fmt.Errorf("failed to fill resting orders: %w", err)
+		}
+
+		err = strategy(ctx, &c, event)
+		if errors.Is(err, ErrStop) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("strategy failed at %s: %w",
+				event.Time.Format(time.RFC3339Nano), err)
+		}
+
+		balances, err := account.Balances(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to value account: %w", err)
+		}
+
+		if value, ok := equity(m, balances, cfg.currency); ok {
+			res.Equity = append(res.Equity, EquityPoint{Time: event.Time,
+				Value: value})
+		}
+		res.Balances = balances
+	}
+
+	if res.Fills, err = fills(ctx, account); err != nil {
+		return nil, err
+	}
+	res.Stats = stats(res.Equity, res.Fills)
+
+	return &res, nil
+}
+
+// inferPairs returns the pairs of the recorded data, assuming each is quoted
+// in currency.
+func inferPairs(data Data, currency string) ([]valr.CurrencyPair, error) {
+	symbols := make(map[string]bool)
+	for _, snapshot := range data.OrderBooks {
+		symbols[normalize(snapshot.Pair)] = true
+	}
+	for _, trade := range data.Trades {
+		symbols[normalize(trade.CurrencyPair)] = true
+	}
+
+	pairs := make([]valr.CurrencyPair, 0, len(symbols))
+	for symbol := range symbols {
+		base := strings.TrimSuffix(symbol, currency)
+		if base == symbol || base == "" {
+			return nil, fmt.Errorf("backtest: cannot infer currencies of "+
+				"pair %q, use WithCurrencyPairs", symbol)
+		}
+
+		pairs = append(pairs, valr.CurrencyPair{
+			Active:        true,
+			BaseCurrency:  base,
+			QuoteCurrency: currency,
+			Symbol:        symbol,
+		})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Symbol < pairs[j].Symbol
+	})
+
+	return pairs, nil
+}
+
+// equity returns the value of balances in currency at the market's current
+// prices, or false if a currency held has no price.
+func equity(m *market, balances []valr.Balance, currency string) (*big.Rat,
+	bool) {
+
+	value := new(big.Rat)
+	for _, b := range balances {
+		total, ok := new(big.Rat).SetString(b.Total)
+		if !ok {
+			return nil, false
+		}
+
+		if total.Sign() == 0 || b.Currency == currency {
+			value.Add(value, total)
+			continue
+		}
+
+		price, ok := pairPrice(m, b.Currency, currency)
+		if !ok {
+			return nil, false
+		}
+
+		value.Add(value, total.Mul(total, price))
+	}
+
+	return value, true
+}
+
+// pairPrice returns the price of base in quote.
+func pairPrice(m *market, base, quote string) (*big.Rat, bool) {
+	for _, pair := range m.pairs {
+		if pair.BaseCurrency == base && pair.QuoteCurrency == quote {
+			return m.price(pair.Symbol)
+		}
+	}
+
+	return nil, false
+}
+
+// fills returns the executions recorded in the account's transaction history
+// in time order.
+func fills(ctx context.Context, account *paper.Client) ([]Fill, error) {
+	it := valr.NewTransactionIterator(account, &valr.TransactionHistoryRequest{
+		Types: []valr.TransactionType{
+			valr.TransactionTypeLimitBuy,
+			valr.TransactionTypeLimitSell,
+			valr.TransactionTypeMarketBuy,
+			valr.TransactionTypeMarketSell,
+			valr.TransactionTypeSimpleBuy,
+			valr.TransactionTypeSimpleSell,
+		},
+	})
+
+	var fills []Fill
+	for it.Next(ctx) {
+		tx := it.Transaction()
+		debit, err := decimal.ParsePositive(tx.DebitValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction %s debit: %w",
+				tx.ID, err)
+		}
+
+		credit, err := decimal.ParsePositive(tx.CreditValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction %s credit: %w",
+				tx.ID, err)
+		}
+
+		// Transactions without a fee have an empty fee value.
+		fee, err := decimal.Parse(tx.FeeValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction %s fee: %w", tx.ID,
+				err)
+		}
+
+		f := Fill{
+			Fee:         fee,
+			FeeCurrency: tx.FeeCurrency,
+			Time:        tx.EventAt,
+		}
+
+		if info := tx.AdditionalInfo; info != nil {
+			f.OrderID, f.Pair = info.OrderID, info.CurrencyPairSymbol
+		}
+
+		// Fees are deducted from the currency received.
+		var base, quote *big.Rat
+		if strings.HasSuffix(string(tx.TypeInfo.Type), "_BUY") {
+			f.Side = valr.SideBuy
+			base, quote = credit.Add(credit, fee), debit
+		} else {
+			f.Side = valr.SideSell
+			base, quote = debit, credit.Add(credit, fee)
+		}
+
+		f.Quantity = base
+		f.Price = new(big.Rat).Quo(quote, base)
+		fills = append(fills, f)
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, fmt.Errorf("failed to fetch fills: %w", err)
+	}
+
+	for i, j := 0, len(fills)-1; i < j; i, j = i+1, j-1 {
+		fills[i], fills[j] = fills[j], fills[i]
+	}
+
+	return fills, nil
+}
+
+// stats summarises an equity curve and the fills which produced it.
+func stats(curve []EquityPoint, fills []Fill) Stats {
+	s := Stats{
+		End:         new(big.Rat),
+		Fees:        make(map[string]*big.Rat),
+		Fills:       len(fills),
+		MaxDrawdown: new(big.Rat),
+		Return:      new(big.Rat),
+		Start:       new(big.Rat),
+	}
+
+	orders := make(map[string]bool)
+	for _, f := range fills {
+		orders[f.OrderID] = true
+
+		fee, ok := s.Fees[f.FeeCurrency]
+		if !ok {
+			fee = new(big.Rat)
+			s.Fees[f.FeeCurrency] = fee
+		}
+		fee.Add(fee, f.Fee)
+	}
+	s.Orders = len(orders)
+
+	if len(curve) == 0 {
+		return s
+	}
+
+	s.Start.Set(curve[0].Value)
+	s.End.Set(curve[len(curve)-1].Value)
+	if s.Start.Sign() > 0 {
+		s.Return.Sub(s.End, s.Start)
+		s.Return.Quo(s.Return, s.Start)
+	}
+
+	peak := new(big.Rat)
+	for _, point := range curve {
+		if point.Value.Cmp(peak) > 0 {
+			peak.Set(point.Value)
+			continue
+		}
+
+		if peak.Sign() > 0 {
+			drawdown := new(big.Rat).Sub(peak, point.Value)
+			drawdown.Quo(drawdown, peak)
+			if drawdown.Cmp(s.MaxDrawdown) > 0 {
+				s.MaxDrawdown = drawdown
+			}
+		}
+	}
+
+	return s
+}
+
+// WriteEquityCSV writes the equity curve as CSV with a header row.
+func (r *Result) WriteEquityCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Time", "Equity"}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, point := range r.Equity {
+		if err := cw.Write([]string{
+			point.Time.Format(time.RFC3339Nano),
+			decimal.Format(point.Value),
+		}); err != nil {
+			return fmt.Errorf("failed to write equity: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush equity: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFillsCSV writes the trade log as CSV with a header row.
+func (r *Result) WriteFillsCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"Time", "Order ID", "Pair", "Side", "Price",
+		"Quantity", "Fee", "Fee Currency"}); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, f := range r.Fills {
+		if err := cw.Write([]string{
+			f.Time.Format(time.RFC3339Nano),
+			f.OrderID,
+			f.Pair,
+			string(f.Side),
+			decimal.Format(f.Price),
+			decimal.Format(f.Quantity),
+			decimal.Format(f.Fee),
+			f.FeeCurrency,
+		}); err != nil {
+			return fmt.Errorf("failed to write fill: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush fills: %w", err)
+	}
+
+	return nil
+}
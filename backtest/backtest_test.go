@@ -0,0 +1,293 @@
+package backtest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/backtest"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBacktestTestSuite(t *testing.T) {
+	suite.Run(t, new(backtestTestSuite))
+}
+
+type backtestTestSuite struct {
+	suite.Suite
+	data  backtest.Data
+	start time.Time
+}
+
+// snapshot returns a BTCZAR order book with a single ask and bid of 1 BTC.
+func snapshot(at time.Time, ask, bid string) backtest.Snapshot {
+	return backtest.Snapshot{
+		OrderBook: valr.OrderBook{
+			Asks: []valr.OrderBookEntry{{Price: ask, Quantity: "1"}},
+			Bids: []valr.OrderBookEntry{{Price: bid, Quantity: "1"}},
+		},
+		Pair: "BTCZAR",
+		Time: at,
+	}
+}
+
+func (suite *backtestTestSuite) SetupTest() {
+	suite.start = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	suite.data = backtest.Data{
+		// Snapshots are out of order to check that events are sorted.
+		OrderBooks: []backtest.Snapshot{
+			snapshot(suite.start.Add(2*time.Minute), "90", "80"),
+			snapshot(suite.start, "100", "90"),
+			snapshot(suite.start.Add(time.Minute), "110", "100"),
+		},
+		Trades: []valr.Trade{{
+			CurrencyPair: "BTCZAR",
+			ID:           1,
+			Price:        "105",
+			Quantity:     "0.5",
+			Side:         valr.SideBuy,
+			TradedAt:     suite.start.Add(90 * time.Second),
+		}},
+	}
+}
+
+// rat parses a decimal.
+func (suite *backtestTestSuite) rat(s string) *big.Rat {
+	value, ok := new(big.Rat).SetString(s)
+	suite.Require().True(ok)
+
+	return value
+}
+
+// buyOnce places a market order for 1 BTC on the first event.
+func buyOnce(ctx context.Context, client valr.Client,
+	event backtest.Event) error {
+
+	if event.OrderBook == nil || event.Time.Minute() != 0 {
+		return nil
+	}
+
+	_, err := client.MarketOrder(ctx, &valr.MarketOrderRequest{
+		BaseAmount: "1",
+		Pair:       "BTCZAR",
+		Side:       valr.SideBuy,
+	})
+	return err
+}
+
+func (suite *backtestTestSuite) TestRun() {
+	var events []backtest.Event
+	res, err := backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			events = append(events, event)
+			return buyOnce(ctx, client, event)
+		},
+		backtest.WithBalance("ZAR", "200"),
+		backtest.WithFees("0", "0.01"))
+	suite.Require().NoError(err)
+
+	suite.Require().Len(events, 4)
+	suite.Require().Equal(suite.start, events[0].Time)
+	suite.Require().NotNil(events[2].Trade)
+	suite.Require().Equal("80", events[3].OrderBook.Bids[0].Price)
+
+	// 1 BTC is bought at 100 with a 1% fee in BTC, and valued at the middle
+	// of the spread.
+	suite.Require().Len(res.Fills, 1)
+	fill := res.Fills[0]
+	suite.Require().Equal(valr.SideBuy, fill.Side)
+	suite.Require().Equal("BTCZAR", fill.Pair)
+	suite.Require().Equal(0, fill.Price.Cmp(suite.rat("100")))
+	suite.Require().Equal(0, fill.Quantity.Cmp(suite.rat("1")))
+	suite.Require().Equal(0, fill.Fee.Cmp(suite.rat("0.01")))
+	suite.Require().Equal(suite.start, fill.Time)
+
+	var curve []string
+	for _, point := range res.Equity {
+		curve = append(curve, point.Value.FloatString(2))
+	}
+	suite.Require().Equal([]string{"194.05", "203.95", "203.95", "184.15"},
+		curve)
+
+	suite.Require().Equal(0, res.Stats.Start.Cmp(suite.rat("194.05")))
+	suite.Require().Equal(0, res.Stats.End.Cmp(suite.rat("184.15")))
+	suite.Require().Equal("-0.0510", res.Stats.Return.FloatString(4))
+	suite.Require().Equal("0.0971", res.Stats.MaxDrawdown.FloatString(4))
+	suite.Require().Equal(1, res.Stats.Fills)
+	suite.Require().Equal(1, res.Stats.Orders)
+	suite.Require().Equal(0, res.Stats.Fees["BTC"].Cmp(suite.rat("0.01")))
+
+	var equity, fills bytes.Buffer
+	suite.Require().NoError(res.WriteEquityCSV(&equity))
+	suite.Require().True(strings.HasPrefix(equity.String(),
+		"Time,Equity\n2021-03-01T12:00:00Z,194.05\n"))
+
+	suite.Require().NoError(res.WriteFillsCSV(&fills))
+	suite.Require().Contains(fills.String(), ",BTCZAR,BUY,100,1,0.01,BTC\n")
+}
+
+func (suite *backtestTestSuite) TestLatency() {
+	res, err := backtest.Run(context.TODO(), suite.data, buyOnce,
+		backtest.WithBalance("ZAR", "200"),
+		backtest.WithFees("0", "0"),
+		backtest.WithLatency(backtest.FixedLatency(time.Minute)))
+	suite.Require().NoError(err)
+
+	// The order reaches the exchange when the second book is current.
+	suite.Require().Len(res.Fills, 1)
+	suite.Require().Equal(0, res.Fills[0].Price.Cmp(suite.rat("110")))
+	suite.Require().Equal(suite.start.Add(time.Minute), res.Fills[0].Time)
+}
+
+func (suite *backtestTestSuite) TestRestingOrders() {
+	var placed bool
+	res, err := backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			if placed {
+				return nil
+			}
+			placed = true
+
+			_, err := client.LimitOrder(ctx, &valr.LimitOrderRequest{
+				Pair:     "BTCZAR",
+				Price:    "95",
+				Quantity: "1",
+				Side:     valr.SideBuy,
+			})
+			return err
+		},
+		backtest.WithBalance("ZAR", "200"))
+	suite.Require().NoError(err)
+
+	// The order rests until the last book's ask trades through it, and is
+	// then filled at its own price without a maker fee.
+	suite.Require().Len(res.Fills, 1)
+	suite.Require().Equal(0, res.Fills[0].Price.Cmp(suite.rat("95")))
+	suite.Require().Equal(0, res.Fills[0].Fee.Sign())
+	suite.Require().Equal(suite.start.Add(2*time.Minute), res.Fills[0].Time)
+
+	balances := make(map[string]string)
+	for _, b := range res.Balances {
+		balances[b.Currency] = b.Total
+	}
+	suite.Require().Equal(map[string]string{"BTC": "1", "ZAR": "105"},
+		balances)
+}
+
+func (suite *backtestTestSuite) TestMarketData() {
+	_, err := backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+
+			now, err := client.ServerTime(ctx)
+			suite.Require().NoError(err)
+			suite.Require().Equal(event.Time, now.Time)
+
+			book, err := client.OrderBook(ctx, "BTCZAR")
+			suite.Require().NoError(err)
+
+			summary, err := client.MarketSummaryForCurrency(ctx, "BTCZAR")
+			suite.Require().NoError(err)
+			suite.Require().Equal(book.Asks[0].Price, summary.AskPrice)
+
+			if event.Trade != nil {
+				suite.Require().Equal("105", summary.LastTradedPrice)
+				suite.Require().Equal("0.5", summary.BaseVolume)
+			} else if event.Time.Equal(suite.start) {
+				suite.Require().Empty(summary.LastTradedPrice)
+			}
+
+			_, err = client.OrderBook(ctx, "ETHZAR")
+			suite.Require().Error(err)
+
+			return nil
+		})
+	suite.Require().NoError(err)
+}
+
+func (suite *backtestTestSuite) TestStop() {
+	var calls int
+	res, err := backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			calls++
+			if calls == 2 {
+				return backtest.ErrStop
+			}
+			return nil
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal(2, calls)
+	suite.Require().Len(res.Equity, 1)
+
+	failure := errors.New("failure")
+	_, err = backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			return failure
+		})
+	suite.Require().True(errors.Is(err, failure))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = backtest.Run(ctx, suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			return nil
+		})
+	suite.Require().True(errors.Is(err, context.Canceled))
+}
+
+func (suite *backtestTestSuite) TestInferPairs() {
+	suite.data.Trades[0].CurrencyPair = "ETHBTC"
+
+	_, err := backtest.Run(context.TODO(), suite.data,
+		func(ctx context.Context, client valr.Client,
+			event backtest.Event) error {
+			return nil
+		})
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "ETHBTC")
+}
+
+func (suite *backtestTestSuite) TestLoad() {
+	dir := suite.T().TempDir()
+
+	books := filepath.Join(dir, "books.jsonl")
+	f, err := os.Create(books)
+	suite.Require().NoError(err)
+	for _, snapshot := range suite.data.OrderBooks {
+		suite.Require().NoError(backtest.WriteOrderBook(f, snapshot))
+	}
+	suite.Require().NoError(f.Close())
+
+	snapshots, err := backtest.LoadOrderBooks(books)
+	suite.Require().NoError(err)
+	suite.Require().Equal(suite.data.OrderBooks, snapshots)
+
+	trades := filepath.Join(dir, "trades.jsonl")
+	suite.Require().NoError(ioutil.WriteFile(trades, []byte(
+		`{"currencyPair":"BTCZAR","tradeId":1,"price":"105",`+
+			`"quantity":"0.5","side":"buy",`+
+			`"tradedAt":"2021-03-01T12:01:30Z"}`+"\n\n"), 0o600))
+
+	loaded, err := backtest.LoadTrades(trades)
+	suite.Require().NoError(err)
+	suite.Require().Equal(suite.data.Trades, loaded)
+
+	suite.Require().NoError(ioutil.WriteFile(trades, []byte("{}\n{"), 0o600))
+	_, err = backtest.LoadTrades(trades)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "line 2")
+}
@@ -0,0 +1,146 @@
+package backtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nickcorin/valr"
+)
+
+// Snapshot is an order book recorded at a point in time. It is encoded as the
+// VALR order book response with the pair and time added.
+type Snapshot struct {
+	valr.OrderBook
+
+	Pair string    `json:"currencyPair"`
+	Time time.Time `json:"time"`
+}
+
+// Data contains the market data replayed by a backtest. Trades and snapshots
+// need not be sorted.
+type Data struct {
+	OrderBooks []Snapshot
+	Trades     []valr.Trade
+}
+
+// LoadTrades reads trades from a file containing one VALR trade per line, as
+// returned by the trade history endpoints.
+func LoadTrades(path string) ([]valr.Trade, error) {
+	var trades []valr.Trade
+	err := readLines(path, func(line []byte) error {
+		var trade valr.Trade
+		if err := json.Unmarshal(line, &trade); err != nil {
+			return err
+		}
+
+		trades = append(trades, trade)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trades: %w", err)
+	}
+
+	return trades, nil
+}
+
+// LoadOrderBooks reads order book snapshots from a file containing one
+// Snapshot per line.
+func LoadOrderBooks(path string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	err := readLines(path, func(line []byte) error {
+		var snapshot Snapshot
+		if err := json.Unmarshal(line, &snapshot); err != nil {
+			return err
+		}
+
+		snapshots = append(snapshots, snapshot)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order books: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// WriteOrderBook appends a snapshot to w in the format read by
+// LoadOrderBooks, which may be used to record order books for later replay.
+func WriteOrderBook(w io.Writer, snapshot Snapshot) error {
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal order book: %w", err)
+	}
+
+	if _, err = w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write order book: %w", err)
+	}
+
+	return nil
+}
+
+// readLines calls fn with each non-empty line of the file at path.
+func readLines(path string, fn func(line []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		if err = fn(scanner.Bytes()); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+// Event is a single update of the replayed market data. Exactly one of
+// OrderBook and Trade is set.
+type Event struct {
+	OrderBook *valr.OrderBook
+	Pair      string
+	Time      time.Time
+	Trade     *valr.Trade
+}
+
+// events returns the trades and snapshots in data as events sorted by time.
+// Snapshots are replayed before trades recorded at the same time.
+func events(data Data) []Event {
+	events := make([]Event, 0, len(data.OrderBooks)+len(data.Trades))
+	for i := range data.OrderBooks {
+		snapshot := &data.OrderBooks[i]
+		events = append(events, Event{
+			OrderBook: &snapshot.OrderBook,
+			Pair:      normalize(snapshot.Pair),
+			Time:      snapshot.Time,
+		})
+	}
+
+	for i := range data.Trades {
+		trade := &data.Trades[i]
+		events = append(events, Event{
+			Pair:  normalize(trade.CurrencyPair),
+			Time:  trade.TradedAt,
+			Trade: trade,
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events
+}
@@ -0,0 +1,345 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// market is a valr.PublicClient serving the replayed market data as it was at
+// the current time of the backtest.
+type market struct {
+	mu sync.Mutex
+
+	books  map[string][]Snapshot
+	delay  time.Duration
+	now    time.Time
+	pairs  []valr.CurrencyPair
+	trades map[string][]valr.Trade
+}
+
+var _ valr.PublicClient = (*market)(nil)
+
+func newMarket(data Data, pairs []valr.CurrencyPair) *market {
+	m := market{
+		books:  make(map[string][]Snapshot),
+		pairs:  pairs,
+		trades: make(map[string][]valr.Trade),
+	}
+
+	for _, snapshot := range data.OrderBooks {
+		pair := normalize(snapshot.Pair)
+		m.books[pair] = append(m.books[pair], snapshot)
+	}
+
+	for _, trade := range data.Trades {
+		pair := normalize(trade.CurrencyPair)
+		m.trades[pair] = append(m.trades[pair], trade)
+	}
+
+	for _, books := range m.books {
+		sort.SliceStable(books, func(i, j int) bool {
+			return books[i].Time.Before(books[j].Time)
+		})
+	}
+
+	for _, trades := range m.trades {
+		sort.SliceStable(trades, func(i, j int) bool {
+			return trades[i].TradedAt.Before(trades[j].TradedAt)
+		})
+	}
+
+	return &m
+}
+
+// normalize returns the symbol of a pair as used by VALR.
+func normalize(pair string) string {
+	return strings.ToUpper(pair)
+}
+
+// advance sets the current time of the market.
+func (m *market) advance(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.now = now
+}
+
+// arrive delays the market by latency, so that orders placed until the
+// returned function is called see the market as it is when they reach the
+// exchange.
+func (m *market) arrive(latency time.Duration) func() {
+	m.mu.Lock()
+	m.delay = latency
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		m.delay = 0
+		m.mu.Unlock()
+	}
+}
+
+// time returns the current time of the market.
+func (m *market) time() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.now.Add(m.delay)
+}
+
+// book returns the latest snapshot of a pair's order book, or false if none
+// has been recorded yet.
+func (m *market) book(pair string) (*valr.OrderBook, bool) {
+	now := m.time()
+	books := m.books[normalize(pair)]
+
+	i := sort.Search(len(books), func(i int) bool {
+		return books[i].Time.After(now)
+	})
+	if i == 0 {
+		return nil, false
+	}
+
+	book := books[i-1].OrderBook
+	return &valr.OrderBook{
+		Asks: append([]valr.OrderBookEntry(nil), book.Asks...),
+		Bids: append([]valr.OrderBookEntry(nil), book.Bids...),
+	}, true
+}
+
+// history returns a pair's trades up to the current time.
+func (m *market) history(pair string) []valr.Trade {
+	now := m.time()
+	trades := m.trades[normalize(pair)]
+
+	i := sort.Search(len(trades), func(i int) bool {
+		return trades[i].TradedAt.After(now)
+	})
+
+	return trades[:i]
+}
+
+// price returns the price of a pair, which is the middle of the spread if the
+// order book has both bids and asks, or else the last traded price.
+func (m *market) price(pair string) (*big.Rat, bool) {
+	if book, ok := m.book(pair); ok && len(book.Asks) > 0 &&
+		len(book.Bids) > 0 {
+		ask, askOK := new(big.Rat).SetString(book.Asks[0].Price)
+		bid, bidOK := new(big.Rat).SetString(book.Bids[0].Price)
+		if askOK && bidOK {
+			mid := ask.Add(ask, bid)
+			return mid.Quo(mid, big.NewRat(2, 1)), true
+		}
+	}
+
+	trades := m.history(pair)
+	if len(trades) == 0 {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(trades[len(trades)-1].Price)
+}
+
+// Currencies satisfies the valr.PublicClient interface. The currencies of the
+// backtest's pairs are returned.
+func (m *market) Currencies(ctx context.Context) ([]valr.Currency, error) {
+	seen := make(map[string]bool)
+
+	var currencies []valr.Currency
+	for _, pair := range m.pairs {
+		for _, symbol := range []string{pair.BaseCurrency, pair.QuoteCurrency} {
+			if !seen[symbol] {
+				seen[symbol] = true
+				currencies = append(currencies, valr.Currency{IsActive: true,
+					ShortName: symbol, Symbol: symbol})
+			}
+		}
+	}
+
+	return currencies, nil
+}
+
+// CurrencyPairs satisfies the valr.PublicClient interface.
+func (m *market) CurrencyPairs(ctx context.Context) ([]valr.CurrencyPair,
+	error) {
+	return append([]valr.CurrencyPair(nil), m.pairs...), nil
+}
+
+// MarketSummary satisfies the valr.PublicClient interface. Summaries are only
+// returned for pairs with recorded data.
+func (m *market) MarketSummary(ctx context.Context) ([]valr.MarketSummary,
+	error) {
+
+	var summaries []valr.MarketSummary
+	for _, pair := range m.pairs {
+		summary, err := m.MarketSummaryForCurrency(ctx, pair.Symbol)
+		if err != nil {
+			continue
+		}
+
+		summaries = append(summaries, *summary)
+	}
+
+	return summaries, nil
+}
+
+// MarketSummaryForCurrency satisfies the valr.PublicClient interface. The
+// summary is computed from the trades of the last 24 hours and the latest
+// order book.
+func (m *market) MarketSummaryForCurrency(ctx context.Context, pair string) (
+	*valr.MarketSummary, error) {
+
+	book, hasBook := m.book(pair)
+	trades := m.history(pair)
+	if !hasBook && len(trades) == 0 {
+		return nil, fmt.Errorf("failed to fetch the market summaries: %w",
+			notRecorded(pair))
+	}
+
+	now := m.time()
+	summary := valr.MarketSummary{
+		BaseVolume:   "0",
+		CreatedAt:    now,
+		CurrencyPair: normalize(pair),
+	}
+
+	if hasBook && len(book.Asks) > 0 {
+		summary.AskPrice = book.Asks[0].Price
+	}
+
+	if hasBook && len(book.Bids) > 0 {
+		summary.BidPrice = book.Bids[0].Price
+	}
+
+	if len(trades) == 0 {
+		return &summary, nil
+	}
+
+	start := now.Add(-24 * time.Hour)
+	i := sort.Search(len(trades), func(i int) bool {
+		return trades[i].TradedAt.After(start)
+	})
+
+	// The previous close is the last price before the window, or the first
+	// price within it if trading started since.
+	previous := trades[0].Price
+	if i > 0 {
+		previous = trades[i-1].Price
+	}
+
+	var high, low *big.Rat
+	volume := new(big.Rat)
+	for _, trade := range trades[i:] {
+		price, ok := new(big.Rat).SetString(trade.Price)
+		if !ok {
+			continue
+		}
+
+		if high == nil || price.Cmp(high) > 0 {
+			high = price
+		}
+		if low == nil || price.Cmp(low) < 0 {
+			low = price
+		}
+
+		if quantity, ok := new(big.Rat).SetString(trade.Quantity); ok {
+			volume.Add(volume, quantity)
+		}
+	}
+
+	summary.BaseVolume = decimal.Format(volume)
+	summary.LastTradedPrice = trades[len(trades)-1].Price
+	summary.PreviousClosePrice = previous
+
+	if high != nil {
+		summary.HighPrice = decimal.Format(high)
+		summary.LowPrice = decimal.Format(low)
+	}
+
+	last, lastOK := new(big.Rat).SetString(summary.LastTradedPrice)
+	prev, prevOK := new(big.Rat).SetString(previous)
+	if lastOK && prevOK && prev.Sign() != 0 {
+		change := new(big.Rat).Sub(last, prev)
+		change.Mul(change, big.NewRat(100, 1))
+		summary.ChangeFromPrevious = change.Quo(change, prev).FloatString(2)
+	}
+
+	return &summary, nil
+}
+
+// OrderBook satisfies the valr.PublicClient interface. An empty order book is
+// returned before the first snapshot of the pair.
+func (m *market) OrderBook(ctx context.Context, pair string) (*valr.OrderBook,
+	error) {
+
+	if book, ok := m.book(pair); ok {
+		return book, nil
+	}
+
+	if !m.recorded(pair) {
+		return nil, fmt.Errorf("failed to fetch order book: %w",
+			notRecorded(pair))
+	}
+
+	return &valr.OrderBook{}, nil
+}
+
+// OrderTypes satisfies the valr.PublicClient interface. Every order type is
+// supported for every pair.
+func (m *market) OrderTypes(ctx context.Context) (
+	map[string]map[valr.OrderType]bool, error) {
+
+	orderTypes := make(map[string]map[valr.OrderType]bool)
+	for _, pair := range m.pairs {
+		orderTypes[pair.Symbol], _ = m.OrderTypesForCurrency(ctx, pair.Symbol)
+	}
+
+	return orderTypes, nil
+}
+
+// OrderTypesForCurrency satisfies the valr.PublicClient interface. Every order
+// type is supported.
+func (m *market) OrderTypesForCurrency(ctx context.Context, pair string) (
+	map[valr.OrderType]bool, error) {
+
+	return map[valr.OrderType]bool{
+		valr.OrderTypeLimit:    true,
+		valr.OrderTypeMarket:   true,
+		valr.OrderTypePostOnly: true,
+		valr.OrderTypeSimple:   true,
+	}, nil
+}
+
+// ServerTime satisfies the valr.PublicClient interface. The current time of
+// the backtest is returned.
+func (m *market) ServerTime(ctx context.Context) (*valr.ServerTime, error) {
+	now := m.time()
+	return &valr.ServerTime{Epoch: now.Unix(), Time: now}, nil
+}
+
+// Status satisfies the valr.PublicClient interface. The market is always
+// online.
+func (m *market) Status(ctx context.Context) (valr.Status, error) {
+	return valr.StatusOnline, nil
+}
+
+// recorded returns whether any data was recorded for a pair.
+func (m *market) recorded(pair string) bool {
+	pair = normalize(pair)
+	return len(m.books[pair]) > 0 || len(m.trades[pair]) > 0
+}
+
+// notRecorded returns the error VALR responds with for an unknown pair.
+func notRecorded(pair string) error {
+	return &valr.Error{StatusCode: http.StatusBadRequest, Code: -1,
+		Message: "No data recorded for currency pair " + pair}
+}
@@ -0,0 +1,196 @@
+// Package cache provides an opt-in caching layer for the slowly changing
+// reference data served by the VALR public API.
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+)
+
+// Client wraps a valr.PublicClient, caching the results of Currencies,
+// CurrencyPairs, OrderTypes and OrderTypesForCurrency for a configurable TTL.
+// Concurrent misses for the same data share a single request. All other
+// methods are passed through to the wrapped client.
+type Client struct {
+	valr.PublicClient
+
+	ttl time.Duration
+	now func() time.Time
+
+	mu         sync.Mutex
+	entries    map[string]entry
+	generation int
+	inflight   map[string]*flight
+}
+
+type entry struct {
+	expiresAt time.Time
+	value     interface{}
+}
+
+// flight is a request shared by concurrent cache misses.
+type flight struct {
+	done  chan struct{}
+	err   error
+	value interface{}
+}
+
+// New returns a Client wrapping c, caching reference data for ttl.
+func New(c valr.PublicClient, ttl time.Duration) *Client {
+	return &Client{
+		PublicClient: c,
+		ttl:          ttl,
+		now:          time.Now,
+		entries:      make(map[string]entry),
+		inflight:     make(map[string]*flight),
+	}
+}
+
+// Invalidate removes all cached data, forcing the next call for each method to
+// fetch fresh data. Requests in flight when Invalidate is called are not
+// cached.
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]entry)
+	c.generation++
+}
+
+// load returns the cached value for key, calling fetch on a miss. Only the
+// first of concurrent misses calls fetch, using its own context, while the
+// rest wait for its result. If the first caller's context is cancelled, the
+// waiters whose contexts are not done retry rather than failing with its
+// error.
+func (c *Client) load(ctx context.Context, key string,
+	fetch func(ctx context.Context) (interface{}, error)) (interface{},
+	error) {
+
+	c.mu.Lock()
+	for {
+		if e, ok := c.entries[key]; ok && c.now().Before(e.expiresAt) {
+			c.mu.Unlock()
+			return e.value, nil
+		}
+
+		f, ok := c.inflight[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if !isContextError(f.err) || ctx.Err() != nil {
+			return f.value, f.err
+		}
+		c.mu.Lock()
+	}
+
+	f := flight{done: make(chan struct{})}
+	c.inflight[key] = &f
+	generation := c.generation
+	c.mu.Unlock()
+
+	f.value, f.err = fetch(ctx)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if f.err == nil && generation == c.generation {
+		c.entries[key] = entry{
+			expiresAt: c.now().Add(c.ttl),
+			value:     f.value,
+		}
+	}
+	c.mu.Unlock()
+	close(f.done)
+
+	return f.value, f.err
+}
+
+// isContextError returns whether err was caused by a cancelled context or an
+// exceeded deadline.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// Currencies satisfies the valr.PublicClient interface.
+func (c *Client) Currencies(ctx context.Context) ([]valr.Currency, error) {
+	v, err := c.load(ctx, "currencies", func(ctx context.Context) (
+		interface{}, error) {
+		return c.PublicClient.Currencies(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := v.([]valr.Currency)
+	return append([]valr.Currency(nil), currencies...), nil
+}
+
+// CurrencyPairs satisfies the valr.PublicClient interface.
+func (c *Client) CurrencyPairs(ctx context.Context) ([]valr.CurrencyPair,
+	error) {
+	v, err := c.load(ctx, "pairs", func(ctx context.Context) (
+		interface{}, error) {
+		return c.PublicClient.CurrencyPairs(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pairs := v.([]valr.CurrencyPair)
+	return append([]valr.CurrencyPair(nil), pairs...), nil
+}
+
+// OrderTypes satisfies the valr.PublicClient interface.
+func (c *Client) OrderTypes(ctx context.Context) (
+	map[string]map[valr.OrderType]bool, error) {
+	v, err := c.load(ctx, "ordertypes", func(ctx context.Context) (
+		interface{}, error) {
+		return c.PublicClient.OrderTypes(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	types := v.(map[string]map[valr.OrderType]bool)
+	typesCopy := make(map[string]map[valr.OrderType]bool, len(types))
+	for pair, pairTypes := range types {
+		typesCopy[pair] = copyOrderTypes(pairTypes)
+	}
+
+	return typesCopy, nil
+}
+
+// OrderTypesForCurrency satisfies the valr.PublicClient interface.
+func (c *Client) OrderTypesForCurrency(ctx context.Context, pair string) (
+	map[valr.OrderType]bool, error) {
+	v, err := c.load(ctx, "ordertypes/"+pair, func(ctx context.Context) (
+		interface{}, error) {
+		return c.PublicClient.OrderTypesForCurrency(ctx, pair)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copyOrderTypes(v.(map[valr.OrderType]bool)), nil
+}
+
+func copyOrderTypes(types map[valr.OrderType]bool) map[valr.OrderType]bool {
+	typesCopy := make(map[valr.OrderType]bool, len(types))
+	for typ, ok := range types {
+		typesCopy[typ] = ok
+	}
+
+	return typesCopy
+}
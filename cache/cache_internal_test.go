@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCacheTestSuite(t *testing.T) {
+	suite.Run(t, new(cacheTestSuite))
+}
+
+type cacheTestSuite struct {
+	suite.Suite
+	cache   *Client
+	hits    int64
+	now     time.Time
+	release chan struct{}
+	server  *httptest.Server
+}
+
+func (suite *cacheTestSuite) SetupTest() {
+	suite.hits = 0
+	suite.now = time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	suite.release = nil
+
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt64(&suite.hits, 1)
+			if suite.release != nil {
+				<-suite.release
+			}
+
+			switch r.URL.Path {
+			case "/public/currencies":
+				w.Write([]byte(`[{"shortName":"ZAR"},{"shortName":"BTC"}]`))
+			case "/public/pairs":
+				w.Write([]byte(`[{"symbol":"BTCZAR"}]`))
+			case "/public/ordertypes":
+				w.Write([]byte(`[{"currencyPair":"BTCZAR",` +
+					`"orderTypes":["limit","market"]}]`))
+			case "/public/BTCZAR/ordertypes":
+				w.Write([]byte(`["limit","market"]`))
+			case "/public/status":
+				w.Write([]byte(`{"status":"online"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+
+	suite.cache = New(valr.NewClientForTesting(suite.T(), suite.server.URL),
+		time.Minute)
+	suite.cache.now = func() time.Time { return suite.now }
+}
+
+func (suite *cacheTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *cacheTestSuite) TestTTL() {
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		currencies, err := suite.cache.Currencies(ctx)
+		suite.Require().NoError(err)
+		suite.Require().Len(currencies, 2)
+	}
+	suite.Require().EqualValues(1, suite.hits)
+
+	suite.now = suite.now.Add(59 * time.Second)
+	_, err := suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+	suite.Require().EqualValues(1, suite.hits)
+
+	suite.now = suite.now.Add(time.Second)
+	_, err = suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+	suite.Require().EqualValues(2, suite.hits)
+}
+
+func (suite *cacheTestSuite) TestReferenceData() {
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		pairs, err := suite.cache.CurrencyPairs(ctx)
+		suite.Require().NoError(err)
+		suite.Require().Equal("BTCZAR", pairs[0].Symbol)
+
+		types, err := suite.cache.OrderTypes(ctx)
+		suite.Require().NoError(err)
+		suite.Require().True(types["BTCZAR"][valr.OrderTypeMarket])
+
+		pairTypes, err := suite.cache.OrderTypesForCurrency(ctx, "BTCZAR")
+		suite.Require().NoError(err)
+		suite.Require().True(pairTypes[valr.OrderTypeLimit])
+	}
+	suite.Require().EqualValues(3, suite.hits)
+}
+
+func (suite *cacheTestSuite) TestPassThrough() {
+	for i := 0; i < 2; i++ {
+		status, err := suite.cache.Status(context.Background())
+		suite.Require().NoError(err)
+		suite.Require().Equal(valr.StatusOnline, status)
+	}
+	suite.Require().EqualValues(2, suite.hits)
+}
+
+func (suite *cacheTestSuite) TestErrorsNotCached() {
+	for i := 0; i < 2; i++ {
+		_, err := suite.cache.OrderTypesForCurrency(context.Background(),
+			"FOOBAR")
+		suite.Require().Error(err)
+	}
+	suite.Require().EqualValues(2, suite.hits)
+}
+
+func (suite *cacheTestSuite) TestInvalidate() {
+	ctx := context.Background()
+
+	_, err := suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+
+	suite.cache.Invalidate()
+
+	_, err = suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+	suite.Require().EqualValues(2, suite.hits)
+}
+
+func (suite *cacheTestSuite) TestCopies() {
+	ctx := context.Background()
+
+	currencies, err := suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+	currencies[0].ShortName = "mutated"
+
+	types, err := suite.cache.OrderTypes(ctx)
+	suite.Require().NoError(err)
+	types["BTCZAR"][valr.OrderTypeMarket] = false
+
+	currencies, err = suite.cache.Currencies(ctx)
+	suite.Require().NoError(err)
+	suite.Require().Equal("ZAR", currencies[0].ShortName)
+
+	types, err = suite.cache.OrderTypes(ctx)
+	suite.Require().NoError(err)
+	suite.Require().True(types["BTCZAR"][valr.OrderTypeMarket])
+}
+
+func (suite *cacheTestSuite) TestSingleflight() {
+	suite.release = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pairs, err := suite.cache.CurrencyPairs(context.Background())
+			suite.Require().NoError(err)
+			suite.Require().Len(pairs, 1)
+		}()
+	}
+
+	// Give the goroutines a chance to queue up behind the first request.
+	time.Sleep(50 * time.Millisecond)
+	close(suite.release)
+	wg.Wait()
+
+	suite.Require().EqualValues(1, atomic.LoadInt64(&suite.hits))
+}
+
+func (suite *cacheTestSuite) TestCancelledFetch() {
+	suite.release = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error)
+	go func() {
+		_, err := suite.cache.CurrencyPairs(ctx)
+		first <- err
+	}()
+
+	suite.Require().Eventually(func() bool {
+		return atomic.LoadInt64(&suite.hits) == 1
+	}, time.Second, time.Millisecond)
+
+	waiter := make(chan error)
+	go func() {
+		_, err := suite.cache.CurrencyPairs(context.Background())
+		waiter <- err
+	}()
+
+	// Give the waiter a chance to queue up behind the first request, whose
+	// cancellation must not fail the waiter.
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	suite.Require().ErrorIs(<-first, context.Canceled)
+
+	close(suite.release)
+	suite.Require().NoError(<-waiter)
+	suite.Require().EqualValues(2, atomic.LoadInt64(&suite.hits))
+}
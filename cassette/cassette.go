@@ -0,0 +1,256 @@
+// Package cassette provides HTTP transports which record real request and
+// response pairs to a JSON-lines cassette and replay them deterministically,
+// allowing tests to run offline against realistic VALR traffic.
+//
+// Both transports plug into the client using valr.WithHTTPClient:
+//
+//	rec, err := cassette.NewRecorder("testdata/balances.jsonl", nil)
+//	client := valr.NewClient(key, secret, valr.WithHTTPClient(
+//		&http.Client{Transport: rec}))
+package cassette
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Scrubbed replaces the values of sensitive headers in recorded requests.
+const Scrubbed = "[SCRUBBED]"
+
+// scrubbedHeaders are replaced before an interaction is written to a cassette.
+var scrubbedHeaders = []string{
+	"X-VALR-API-KEY",
+	"X-VALR-SIGNATURE",
+	"X-VALR-SUB-ACCOUNT-ID",
+	"X-VALR-TIMESTAMP",
+}
+
+// Interaction is a single recorded request and response pair. A cassette
+// contains one JSON encoded Interaction per line.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded HTTP request.
+type Request struct {
+	Body    string      `json:"body,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	Method  string      `json:"method"`
+	Path    string      `json:"path"`
+	Query   string      `json:"query,omitempty"`
+}
+
+// Response is a recorded HTTP response.
+type Response struct {
+	Body       string      `json:"body,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	StatusCode int         `json:"statusCode"`
+}
+
+// key identifies requests which are considered equal when replaying.
+func (r Request) key() string {
+	return r.Method + " " + r.Path + "?" + r.Query + "\n" + r.Body
+}
+
+// Recorder is an http.RoundTripper which performs requests using an underlying
+// transport and records every interaction to a cassette. Signing headers are
+// scrubbed before being written.
+type Recorder struct {
+	mu        sync.Mutex
+	file      *os.File
+	transport http.RoundTripper
+}
+
+// NewRecorder returns a Recorder writing to the cassette at path, truncating
+// it if it already exists. If transport is nil, http.DefaultTransport is used.
+func NewRecorder(path string, transport http.RoundTripper) (*Recorder,
+	error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+
+	return &Recorder{file: f, transport: transport}, nil
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(resBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Body:    string(reqBody),
+			Headers: scrub(req.Header),
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Query:   req.URL.Query().Encode(),
+		},
+		Response: Response{
+			Body:       string(resBody),
+			Headers:    res.Header.Clone(),
+			StatusCode: res.StatusCode,
+		},
+	}
+
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err = r.file.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write interaction: %w", err)
+	}
+
+	return res, nil
+}
+
+// Close flushes and closes the cassette.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.file.Close()
+}
+
+// Replayer is an http.RoundTripper which serves responses from a cassette
+// without making any network requests. Requests are matched on method, path,
+// query and body. Identical requests are served in the order they were
+// recorded, with the last recording repeated once exhausted.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+}
+
+// Load returns a Replayer serving the interactions in the cassette at path.
+func Load(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cassette: %w", err)
+	}
+	defer f.Close()
+
+	r := Replayer{interactions: make(map[string][]Interaction)}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var interaction Interaction
+		if err = json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal interaction on line "+
+				"%d: %w", line, err)
+		}
+
+		key := interaction.Request.key()
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	return &r, nil
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Request{
+		Body:   string(body),
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.interactions[recorded.key()]
+	if len(queue) == 0 {
+		return nil, fmt.Errorf("no recorded interaction for %s %s",
+			req.Method, req.URL.RequestURI())
+	}
+
+	interaction := queue[0]
+	if len(queue) > 1 {
+		r.interactions[recorded.key()] = queue[1:]
+	}
+
+	res := interaction.Response
+	return &http.Response{
+		Status: fmt.Sprintf("%d %s", res.StatusCode,
+			http.StatusText(res.StatusCode)),
+		StatusCode:    res.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        res.Headers.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewBufferString(res.Body)),
+		ContentLength: int64(len(res.Body)),
+		Request:       req,
+	}, nil
+}
+
+// readRequestBody returns the body of req without consuming it.
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+// scrub returns a copy of h with the values of signing headers replaced.
+func scrub(h http.Header) http.Header {
+	scrubbed := h.Clone()
+	for _, k := range scrubbedHeaders {
+		if scrubbed.Get(k) != "" {
+			scrubbed.Set(k, Scrubbed)
+		}
+	}
+
+	return scrubbed
+}
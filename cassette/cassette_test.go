@@ -0,0 +1,120 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/cassette"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCassetteTestSuite(t *testing.T) {
+	suite.Run(t, new(cassetteTestSuite))
+}
+
+type cassetteTestSuite struct {
+	suite.Suite
+}
+
+func (suite *cassetteTestSuite) client(rt http.RoundTripper,
+	baseURL string) valr.Client {
+	return valr.NewClientForTesting(suite.T(), baseURL,
+		valr.WithHTTPClient(&http.Client{Transport: rt}))
+}
+
+func (suite *cassetteTestSuite) TestReplay() {
+	replayer, err := cassette.Load("testdata/public.jsonl")
+	suite.Require().NoError(err)
+
+	c := suite.client(replayer, "https://api.valr.com/v1")
+
+	status, err := c.Status(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.StatusOnline, status)
+
+	// Identical requests are served in recorded order, repeating the last.
+	for _, price := range []string{"7005", "7010", "7010"} {
+		summary, err := c.MarketSummaryForCurrency(context.Background(),
+			"BTCZAR")
+		suite.Require().NoError(err)
+		suite.Require().Equal(price, summary.LastTradedPrice)
+	}
+
+	_, err = c.OrderBook(context.Background(), "FOOBAR")
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(-11, apiErr.Code)
+
+	_, err = c.OrderBook(context.Background(), "BTCZAR")
+	suite.Require().Error(err)
+}
+
+func (suite *cassetteTestSuite) TestRecordAndReplay() {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account/balances":
+				w.Write([]byte(`[{"currency":"BTC","available":"1"}]`))
+			case "/account/transactionhistory":
+				w.Write([]byte(`[{"creditCurrency":"` +
+					r.URL.Query().Get("currency") + `"}]`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	defer server.Close()
+
+	path := filepath.Join(suite.T().TempDir(), "private.jsonl")
+	recorder, err := cassette.NewRecorder(path, nil)
+	suite.Require().NoError(err)
+
+	c := suite.client(recorder, server.URL)
+
+	balances, err := c.Balances(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Len(balances, 1)
+
+	for _, currency := range []string{"BTC", "ETH"} {
+		_, err = c.TransactionHistory(context.Background(),
+			&valr.TransactionHistoryRequest{Currency: currency})
+		suite.Require().NoError(err)
+	}
+	suite.Require().NoError(recorder.Close())
+
+	// Signing headers must never be written to the cassette.
+	data, err := ioutil.ReadFile(path)
+	suite.Require().NoError(err)
+	suite.Require().Contains(string(data), cassette.Scrubbed)
+	suite.Require().NotContains(string(data), "test-key")
+
+	replayer, err := cassette.Load(path)
+	suite.Require().NoError(err)
+
+	// Replaying does not depend on the original server.
+	server.Close()
+	c = suite.client(replayer, server.URL)
+
+	balances, err = c.Balances(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal("BTC", balances[0].Currency)
+
+	history, err := c.TransactionHistory(context.Background(),
+		&valr.TransactionHistoryRequest{Currency: "ETH"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("ETH", history[0].CreditCurrency)
+
+	_, err = c.TransactionHistory(context.Background(),
+		&valr.TransactionHistoryRequest{Currency: "XRP"})
+	suite.Require().Error(err)
+}
+
+func (suite *cassetteTestSuite) TestLoadMissing() {
+	_, err := cassette.Load("testdata/missing.jsonl")
+	suite.Require().Error(err)
+}
@@ -1,13 +1,17 @@
 package valr
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"testing"
@@ -23,11 +27,38 @@ type PrivateClient interface {
 	// Balances returns the list of all wallets with their respective balances.
 	Balances(ctx context.Context) ([]Balance, error)
 
+	// CancelOrder cancels an open order. Cancellation is asynchronous, the
+	// order's status should be checked to confirm that it was cancelled.
+	CancelOrder(ctx context.Context, req *CancelOrderRequest) error
+
 	// DepositAddress returns the default deposit address with a specified
 	// currency.
 	DepositAddress(ctx context.Context, currency string) (*DepositAddress,
 		error)
 
+	// LimitOrder places a limit order on the exchange and returns its order
+	// ID. Orders are processed asynchronously, so an accepted order may still
+	// fail.
+	LimitOrder(ctx context.Context, req *LimitOrderRequest) (string, error)
+
+	// MarketOrder places a market order on the exchange and returns its order
+	// ID. Orders are processed asynchronously, so an accepted order may still
+	// fail.
+	MarketOrder(ctx context.Context, req *MarketOrderRequest) (string, error)
+
+	// OpenOrders returns all of your orders which have not yet been filled,
+	// cancelled or failed.
+	OpenOrders(ctx context.Context) ([]OpenOrder, error)
+
+	// OrderStatus returns the current status of an order.
+	OrderStatus(ctx context.Context, req *OrderStatusRequest) (*OrderInfo,
+		error)
+
+	// SimpleOrder places a simple buy or sell, paying the given amount in the
+	// quote currency for buys or the base currency for sells, and returns its
+	// order ID. The order is filled at the best available prices.
+	SimpleOrder(ctx context.Context, req *SimpleOrderRequest) (string, error)
+
 	// TradeHistory gets the last 100 trades for a given currency pair for your
 	// account.
 	TradeHistory(ctx context.Context, pair string) ([]Trade, error)
@@ -113,40 +144,25 @@ type Client interface {
 var defaultBaseURL = "https://api.valr.com/v1"
 
 // DefaultClient is a VALR client initialized with default values. This should
-// be sufficient for callers only using the
-var DefaultClient PublicClient = &client{
-	apiKey:    "",
-	apiSecret: "",
-	encoder:   schema.NewEncoder(),
-	httpClient: snorlax.DefaultClient.
-		SetBaseURL(defaultBaseURL).
-		SetHeader(http.CanonicalHeaderKey("Content-Type"), "application/json"),
-}
-
-// NewClient returns a Client.
-func NewClient(key, secret string) Client {
-	c := *DefaultClient.(*client)
-	c.apiKey = key
-	c.apiSecret = secret
+// be sufficient for callers only using the PublicClient.
+var DefaultClient PublicClient = newClient("", "")
 
-	// Sign requests to private endpoints.
-	c.httpClient.AddRequestHook(authenticationHook(key, secret))
-
-	return &c
+// NewClient returns a Client. Every call returns an independent Client which is
+// safe for concurrent use.
+func NewClient(key, secret string, opts ...Option) Client {
+	return newClient(key, secret, opts...)
 }
 
-// NewClientForTesting returns a Client with a custom base URL for testing
-// purposes.
-func NewClientForTesting(_ *testing.T, baseURL string) Client {
-	c := NewClient("", "").(*client)
-	c.httpClient.SetBaseURL(baseURL)
-
-	return c
+// NewClientForTesting returns a Client with a custom base URL and placeholder
+// credentials for testing purposes.
+func NewClientForTesting(_ *testing.T, baseURL string, opts ...Option) Client {
+	return newClient("test-key", "test-secret",
+		append([]Option{WithBaseURL(baseURL)}, opts...)...)
 }
 
 // NewPublicClient returns a PublicClient.
-func NewPublicClient() PublicClient {
-	return NewClient("", "")
+func NewPublicClient(opts ...Option) PublicClient {
+	return newClient("", "", opts...)
 }
 
 type client struct {
@@ -154,17 +170,159 @@ type client struct {
 	apiSecret  string
 	baseURL    string
 	encoder    *schema.Encoder
-	httpClient snorlax.Client
+	httpClient *http.Client
+	middleware []Middleware
 }
 
-func authenticationHook(key, secret string) snorlax.RequestHook {
-	return func(c snorlax.Client, r *http.Request) error {
-		// If we are requesting a public endpoint, we do not need to sign the
-		// request.
-		if strings.Contains(r.URL.Path, "public") {
-			return nil
+func newClient(key, secret string, opts ...Option) *client {
+	c := &client{
+		apiKey:     key,
+		apiSecret:  secret,
+		baseURL:    defaultBaseURL,
+		encoder:    newEncoder(),
+		httpClient: http.DefaultClient,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// clone returns a copy of the client which shares no mutable state with the
+// original.
+func (c *client) clone() *client {
+	cc := *c
+	cc.middleware = append([]Middleware(nil), c.middleware...)
+
+	return &cc
+}
+
+// requestHook is run against every request just before it is sent.
+type requestHook func(r *http.Request) error
+
+// get performs a GET request against the VALR API.
+func (c *client) get(ctx context.Context, call Call, path string,
+	query url.Values) (*snorlax.Response, error) {
+	return c.do(ctx, call, http.MethodGet, path, query, nil)
+}
+
+// request encodes req and performs it against the VALR API.
+func (c *client) request(ctx context.Context, call Call, req request) (
+	*snorlax.Response, error) {
+
+	method, path, query, body, err := encodeRequest(c.encoder, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.do(ctx, call, method, path, query, body)
+}
+
+// do builds a request to the VALR API and passes the call through the
+// middleware chain. Each request is constructed with its own headers so that
+// concurrent calls never share state. Non-2xx responses are returned as an
+// *Error.
+func (c *client) do(ctx context.Context, call Call, method, path string,
+	query url.Values, body []byte) (*snorlax.Response, error) {
+
+	u, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	u.RawQuery = query.Encode()
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create http request: %w", err)
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	call.Request = r
+
+	// Requests to public endpoints do not need to be signed.
+	c.chain(c.send(!strings.HasPrefix(path, "/public")))(&call)
+	if call.Err != nil {
+		return nil, call.Err
+	}
+
+	if call.Response == nil {
+		return nil, ErrNoResponse
+	}
+
+	return &snorlax.Response{Response: *call.Response}, nil
+}
+
+// send returns the Handler at the end of the middleware chain which signs and
+// performs the request.
+func (c *client) send(sign bool) Handler {
+	return func(call *Call) {
+		if sign {
+			creds := c.credentials(call.Request.Context())
+			if creds.IsZero() {
+				call.Err = ErrNoCredentials
+				return
+			}
+
+			if err := authenticationHook(creds)(call.Request); err != nil {
+				call.Err = fmt.Errorf("failed to sign request: %w", err)
+				return
+			}
+		}
+
+		start := time.Now()
+		res, err := c.httpClient.Do(call.Request)
+		call.Latency = time.Since(start)
+		if err != nil {
+			call.Err = fmt.Errorf("failed to perform http request: %w", err)
+			return
 		}
 
+		call.Response = res
+		if res.StatusCode >= http.StatusMultipleChoices {
+			call.Err = decodeError(res)
+		}
+	}
+}
+
+// decodeError reads a VALR error from a non-2xx response. The response body is
+// replaced so that it may be read again by middleware.
+func decodeError(res *http.Response) *Error {
+	apiErr := Error{StatusCode: res.StatusCode}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return &apiErr
+	}
+
+	// VALR error bodies contain a code and a message, anything else is left
+	// undecoded.
+	_ = json.Unmarshal(body, &apiErr)
+	apiErr.StatusCode = res.StatusCode
+
+	return &apiErr
+}
+
+// credentials returns the credentials attached to ctx, falling back to the
+// ones the client was constructed with.
+func (c *client) credentials(ctx context.Context) Credentials {
+	if creds, ok := CredentialsFromContext(ctx); ok {
+		return creds
+	}
+
+	return Credentials{Key: c.apiKey, Secret: c.apiSecret}
+}
+
+func authenticationHook(creds Credentials) requestHook {
+	return func(r *http.Request) error {
 		var body []byte
 		if r.GetBody != nil {
 			bodyReader, err := r.GetBody()
@@ -180,41 +338,48 @@ func authenticationHook(key, secret string) snorlax.RequestHook {
 		}
 
 		timestamp := strconv.FormatInt(time.Now().UnixNano()/1e6, 10)
-		signature := generateAuthSignature(secret, timestamp, r.Method,
-			r.URL.Path, body)
+		signature := generateAuthSignature(creds.Secret, timestamp, r.Method,
+			r.URL.RequestURI(), body, creds.SubaccountID)
 
-		r.Header.Set("X-VALR-API-KEY", key)
+		r.Header.Set("X-VALR-API-KEY", creds.Key)
 		r.Header.Set("X-VALR-SIGNATURE", signature)
 		r.Header.Set("X-VALR-TIMESTAMP", timestamp)
 
+		if creds.SubaccountID != "" {
+			r.Header.Set("X-VALR-SUB-ACCOUNT-ID", creds.SubaccountID)
+		}
+
 		return nil
 	}
 }
 
 func generateAuthSignature(secret string, timestamp, method, path string,
-	body []byte) string {
+	body []byte, subaccountID string) string {
 
 	mac := hmac.New(sha512.New, []byte(secret))
 	mac.Write([]byte(timestamp))
 	mac.Write([]byte(strings.ToUpper(method)))
 	mac.Write([]byte(path))
 	mac.Write(body)
+	mac.Write([]byte(subaccountID))
 
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// ToPrivateClient converts a PublicClient to a PrivateClient.
+// ToPrivateClient converts a PublicClient to a PrivateClient which signs its
+// requests using the given credentials. The PublicClient is left unmodified.
 func ToPrivateClient(c PublicClient, key, secret string) PrivateClient {
-	client := c.(*client)
+	client := c.(*client).clone()
 	client.apiKey = key
 	client.apiSecret = secret
 
 	return client
 }
 
-// ToPublicClient converts a PrivateClient to a PublicClient.
+// ToPublicClient converts a PrivateClient to a PublicClient which does not sign
+// its requests. The PrivateClient is left unmodified.
 func ToPublicClient(c PrivateClient) PublicClient {
-	client := c.(*client)
+	client := c.(*client).clone()
 	client.apiKey = ""
 	client.apiSecret = ""
 
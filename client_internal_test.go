@@ -2,7 +2,11 @@ package valr
 
 import (
 	"bytes"
+	"context"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -59,7 +63,7 @@ func (suite *clientTestSuite) TestAuthenticationHook() {
 
 	for _, test := range testcases {
 		suite.T().Run("", func(t *testing.T) {
-			hook := authenticationHook(test.key, "")
+			hook := authenticationHook(Credentials{Key: test.key})
 			suite.Require().NotNil(hook)
 
 			r, err := http.NewRequest(http.MethodGet, test.path,
@@ -67,7 +71,7 @@ func (suite *clientTestSuite) TestAuthenticationHook() {
 			suite.Require().NoError(err)
 			suite.Require().NotNil(r)
 
-			err = hook(suite.client.(*client).httpClient, r)
+			err = hook(r)
 			if test.err {
 				suite.Require().Error(err)
 			} else {
@@ -118,8 +122,99 @@ func (suite *clientTestSuite) TestGenerateAuthSignature() {
 	for _, test := range testcases {
 		suite.T().Run("", func(t *testing.T) {
 			signature := generateAuthSignature(test.secret, test.timestamp,
-				test.method, test.path, test.body)
+				test.method, test.path, test.body, "")
 			suite.Require().Equal(test.signature, signature)
 		})
 	}
 }
+
+func (suite *clientTestSuite) TestToPrivateClient() {
+	var gotKey, gotSignature string
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			gotKey = r.Header.Get("X-VALR-API-KEY")
+			gotSignature = r.Header.Get("X-VALR-SIGNATURE")
+			w.Write([]byte("[]"))
+		}))
+	defer server.Close()
+
+	public := NewPublicClient()
+	public.(*client).baseURL = server.URL
+
+	private := ToPrivateClient(public, "key", "s3cret")
+	suite.Require().NotSame(public, private)
+	suite.Require().Equal("", public.(*client).apiKey)
+	suite.Require().Equal("", public.(*client).apiSecret)
+
+	_, err := private.Balances(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal("key", gotKey)
+	suite.Require().NotEmpty(gotSignature)
+}
+
+func (suite *clientTestSuite) TestToPrivateClient_DefaultClient() {
+	private := ToPrivateClient(DefaultClient, "key", "s3cret")
+	suite.Require().Equal("key", private.(*client).apiKey)
+	suite.Require().Equal("", DefaultClient.(*client).apiKey)
+	suite.Require().Equal("", DefaultClient.(*client).apiSecret)
+}
+
+func (suite *clientTestSuite) TestToPublicClient() {
+	private := NewClient("key", "s3cret")
+	public := ToPublicClient(private)
+	suite.Require().NotSame(private, public)
+	suite.Require().Equal("", public.(*client).apiKey)
+	suite.Require().Equal("key", private.(*client).apiKey)
+	suite.Require().Equal("s3cret", private.(*client).apiSecret)
+}
+
+func (suite *clientTestSuite) TestConcurrentUse() {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("[]"))
+		}))
+	defer server.Close()
+
+	shared := NewClientForTesting(suite.T(), server.URL)
+
+	// Results are collected and checked on the test goroutine, since
+	// Require may not be called from the goroutines.
+	const n = 20
+	errs := make(chan error, 2*n)
+	keys := make([]string, n)
+	defaultKeys := make([]string, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := strconv.Itoa(i)
+			c := NewClient(key, "s3cret").(*client)
+			c.baseURL = server.URL
+
+			private := ToPrivateClient(ToPublicClient(c), key, "s3cret")
+			_, err := private.Balances(context.Background())
+			errs <- err
+
+			_, err = shared.Balances(context.Background())
+			errs <- err
+
+			fromDefault := ToPrivateClient(DefaultClient, key, "s3cret")
+			keys[i] = fromDefault.(*client).apiKey
+			defaultKeys[i] = DefaultClient.(*client).apiKey
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		suite.Require().NoError(err)
+	}
+
+	for i := 0; i < n; i++ {
+		suite.Require().Equal(strconv.Itoa(i), keys[i])
+		suite.Require().Equal("", defaultKeys[i])
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nickcorin/valr/mock"
+)
+
+// faultRequest is the body of a request to inject a fault. Durations are
+// given as strings such as "250ms" or "2s".
+type faultRequest struct {
+	Latency    string `json:"latency"`
+	Jitter     string `json:"jitter"`
+	StatusCode int    `json:"statusCode"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+	RetryAfter string `json:"retryAfter"`
+	Truncate   bool   `json:"truncate"`
+	Malformed  bool   `json:"malformed"`
+	Drop       bool   `json:"drop"`
+	Times      int    `json:"times"`
+}
+
+// fault converts the request into a mock.Fault.
+func (req *faultRequest) fault() (mock.Fault, error) {
+	f := mock.Fault{
+		StatusCode: req.StatusCode,
+		Code:       req.Code,
+		Message:    req.Message,
+		Truncate:   req.Truncate,
+		Malformed:  req.Malformed,
+		Drop:       req.Drop,
+		Times:      req.Times,
+	}
+
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"latency", req.Latency, &f.Latency},
+		{"jitter", req.Jitter, &f.Jitter},
+		{"retryAfter", req.RetryAfter, &f.RetryAfter},
+	} {
+		if d.value == "" {
+			continue
+		}
+
+		var err error
+		if *d.dst, err = time.ParseDuration(d.value); err != nil {
+			return mock.Fault{}, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+
+	return f, nil
+}
+
+// balanceRequest is the body of a request to set a balance.
+type balanceRequest struct {
+	Available string `json:"available"`
+}
+
+// newAdmin returns the handler of the admin API, which changes server at
+// runtime.
+func newAdmin(server *mock.Server) http.Handler {
+	r := mux.NewRouter()
+
+	r.HandleFunc("/responses/{route}",
+		func(w http.ResponseWriter, r *http.Request) {
+			status := http.StatusOK
+			if s := r.URL.Query().Get("status"); s != "" {
+				var err error
+				if status, err = strconv.Atoi(s); err != nil {
+					writeError(w, fmt.Errorf("invalid status: %w", err))
+					return
+				}
+			}
+
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			if !json.Valid(body) {
+				writeError(w, fmt.Errorf("response body is not valid JSON"))
+				return
+			}
+
+			server.Override(mux.Vars(r)["route"], mock.JSON(status, body))
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodPut)
+	r.HandleFunc("/responses/{route}",
+		func(w http.ResponseWriter, r *http.Request) {
+			server.Override(mux.Vars(r)["route"], nil)
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodDelete)
+	r.HandleFunc("/responses",
+		func(w http.ResponseWriter, r *http.Request) {
+			server.ClearOverrides()
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodDelete)
+
+	r.HandleFunc("/faults/{route}",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req faultRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, fmt.Errorf("invalid fault: %w", err))
+				return
+			}
+
+			f, err := req.fault()
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			server.AddFault(mux.Vars(r)["route"], f)
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodPost)
+	r.HandleFunc("/faults",
+		func(w http.ResponseWriter, r *http.Request) {
+			server.ClearFaults()
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodDelete)
+
+	r.HandleFunc("/calls/{route}",
+		func(w http.ResponseWriter, r *http.Request) {
+			route := mux.Vars(r)["route"]
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"route": route,
+				"calls": server.Calls(route),
+			})
+		}).Methods(http.MethodGet)
+
+	r.HandleFunc("/balances/{currency}",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req balanceRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeError(w, fmt.Errorf("invalid balance: %w", err))
+				return
+			}
+
+			err := server.Exchange.SetBalance(mux.Vars(r)["currency"],
+				req.Available)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodPut)
+
+	r.HandleFunc("/reset",
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := server.Reset(); err != nil {
+				writeJSON(w, http.StatusInternalServerError,
+					map[string]string{"error": err.Error()})
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}).Methods(http.MethodPost)
+
+	return r
+}
+
+// writeError writes a 400 Bad Request response describing err.
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,137 @@
+// Command valr-mock serves the mock VALR API for use by non-Go services and
+// manual testing.
+//
+// Usage:
+//
+//	valr-mock [-addr :8080] [-admin :8081] [-fixtures dir]...
+//
+// The mock is seeded from the fixtures embedded in the mock package. Fixture
+// directories given with -fixtures replace any file of the same name, e.g.
+// currencies.json or accountBalances.json, with later directories taking
+// precedence. Every request is logged to stderr.
+//
+// The admin API, served on a separate address, changes the mock at runtime:
+//
+//	PUT    /responses/{route}[?status=code]  respond to a route with the body
+//	DELETE /responses/{route}                restore a route's response
+//	DELETE /responses                        restore all responses
+//	POST   /faults/{route}                   inject a fault into a route
+//	DELETE /faults                           remove all faults
+//	GET    /calls/{route}                    count the calls made to a route
+//	PUT    /balances/{currency}              set an available balance
+//	POST   /reset                            reset all state
+//
+// Routes are named after the endpoints of the valr package's Client, e.g.
+// "Balances" or "OrderBook", and "*" refers to all routes.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/nickcorin/valr/mock"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stderr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// dirs is a flag which may be given more than once.
+type dirs []string
+
+// String satisfies the flag.Value interface.
+func (d *dirs) String() string {
+	return strings.Join(*d, ",")
+}
+
+// Set satisfies the flag.Value interface.
+func (d *dirs) Set(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+
+	*d = append(*d, dir)
+	return nil
+}
+
+// run serves the mock until ctx is cancelled.
+func run(ctx context.Context, args []string, stderr io.Writer) error {
+	flags := flag.NewFlagSet("valr-mock", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	addr := flags.String("addr", ":8080", "address to serve the mock API on")
+	admin := flags.String("admin", ":8081", "address to serve the admin API "+
+		"on, or empty to disable it")
+
+	var fixtures dirs
+	flags.Var(&fixtures, "fixtures", "directory of fixtures to load, may be "+
+		"given more than once")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	logger := log.New(stderr, "", log.LstdFlags)
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", *addr, err)
+	}
+
+	opts := []mock.Option{
+		mock.WithListener(l),
+		mock.WithMiddleware(logRequests(logger)),
+	}
+	for _, dir := range fixtures {
+		opts = append(opts, mock.WithFixtures(os.DirFS(dir)))
+	}
+
+	server, err := mock.NewServerE(opts...)
+	if err != nil {
+		l.Close()
+		return err
+	}
+	defer server.Close()
+
+	logger.Printf("serving mock VALR API on %s", server.URL)
+
+	errs := make(chan error, 1)
+	if *admin != "" {
+		adminServer := &http.Server{Addr: *admin, Handler: newAdmin(server)}
+		defer adminServer.Close()
+
+		go func() {
+			errs <- adminServer.ListenAndServe()
+		}()
+		logger.Printf("serving admin API on %s", *admin)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("failed to serve admin API: %w", err)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAdminTestSuite(t *testing.T) {
+	suite.Run(t, new(adminTestSuite))
+}
+
+type adminTestSuite struct {
+	suite.Suite
+	admin  *httptest.Server
+	client valr.Client
+	server *mock.Server
+}
+
+func (suite *adminTestSuite) SetupTest() {
+	suite.server = mock.NewServer()
+	suite.admin = httptest.NewServer(newAdmin(suite.server))
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+}
+
+func (suite *adminTestSuite) TearDownTest() {
+	suite.admin.Close()
+	suite.server.Close()
+}
+
+// do makes a request to the admin API and returns the response's status code
+// and body.
+func (suite *adminTestSuite) do(method, path, body string) (int, string) {
+	req, err := http.NewRequest(method, suite.admin.URL+path,
+		strings.NewReader(body))
+	suite.Require().NoError(err)
+
+	res, err := http.DefaultClient.Do(req)
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	suite.Require().NoError(err)
+
+	return res.StatusCode, string(b)
+}
+
+func (suite *adminTestSuite) TestResponses() {
+	status, _ := suite.do(http.MethodPut, "/responses/Currencies",
+		`[{"symbol":"XYZ"}]`)
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	currencies, err := suite.client.Currencies(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(currencies, 1)
+	suite.Require().Equal("XYZ", currencies[0].Symbol)
+
+	status, _ = suite.do(http.MethodPut, "/responses/Balances?status=401",
+		`{"code":-11,"message":"API key invalid"}`)
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	_, err = suite.client.Balances(context.TODO())
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusUnauthorized, apiErr.StatusCode)
+	suite.Require().Equal("API key invalid", apiErr.Message)
+
+	status, _ = suite.do(http.MethodDelete, "/responses/Currencies", "")
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	currencies, err = suite.client.Currencies(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal("R", currencies[0].Symbol)
+
+	status, _ = suite.do(http.MethodDelete, "/responses", "")
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	_, err = suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	status, _ = suite.do(http.MethodPut, "/responses/Balances", "{")
+	suite.Require().Equal(http.StatusBadRequest, status)
+}
+
+func (suite *adminTestSuite) TestFaults() {
+	status, _ := suite.do(http.MethodPost, "/faults/Balances",
+		`{"statusCode":500,"message":"Boom","times":1}`)
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	_, err := suite.client.Balances(context.TODO())
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusInternalServerError, apiErr.StatusCode)
+	suite.Require().Equal("Boom", apiErr.Message)
+
+	_, err = suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	status, body := suite.do(http.MethodGet, "/calls/Balances", "")
+	suite.Require().Equal(http.StatusOK, status)
+	suite.Require().JSONEq(`{"route":"Balances","calls":2}`, body)
+
+	status, _ = suite.do(http.MethodPost, "/faults/*", `{"latency":"1h"}`)
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	status, _ = suite.do(http.MethodDelete, "/faults", "")
+	suite.Require().Equal(http.StatusNoContent, status)
+
+	_, err = suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	status, body = suite.do(http.MethodPost, "/faults/Balances",
+		`{"latency":"soon"}`)
+	suite.Require().Equal(http.StatusBadRequest, status)
+	suite.Require().Contains(body, "invalid latency")
+}
+
+func (suite *adminTestSuite) TestBalancesAndReset() {
+	status, _ := suite.do(http.MethodPut, "/balances/ZAR",
+		`{"available":"42"}`)
+	suite.Require().Equal(http.StatusNoContent, status)
+	suite.Require().Equal("42", suite.balance("ZAR"))
+
+	status, _ = suite.do(http.MethodPut, "/balances/ZAR",
+		`{"available":"lots"}`)
+	suite.Require().Equal(http.StatusBadRequest, status)
+
+	status, _ = suite.do(http.MethodPost, "/reset", "")
+	suite.Require().Equal(http.StatusNoContent, status)
+	suite.Require().Equal("46.67108319597", suite.balance("ZAR"))
+}
+
+// balance returns the available balance of a currency.
+func (suite *adminTestSuite) balance(currency string) string {
+	balances, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	for _, b := range balances {
+		if b.Currency == currency {
+			return b.Available
+		}
+	}
+
+	suite.FailNow("balance not found", currency)
+	return ""
+}
+
+func TestRunTestSuite(t *testing.T) {
+	suite.Run(t, new(runTestSuite))
+}
+
+type runTestSuite struct {
+	suite.Suite
+}
+
+// syncBuffer is a bytes.Buffer which is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (suite *runTestSuite) TestServesFixturesAndLogs() {
+	dir := suite.T().TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "currencies.json"),
+		[]byte(`[{"symbol":"XYZ"}]`), 0644)
+	suite.Require().NoError(err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	addr := l.Addr().String()
+	l.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stderr syncBuffer
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, []string{"-addr", addr, "-admin", "",
+			"-fixtures", dir}, &stderr)
+	}()
+
+	client := valr.NewClientForTesting(suite.T(), "http://"+addr)
+
+	var currencies []valr.Currency
+	suite.Require().Eventually(func() bool {
+		currencies, err = client.Currencies(context.TODO())
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	suite.Require().Equal("XYZ", currencies[0].Symbol)
+
+	// Files missing from the directory fall back to the defaults.
+	pairs, err := client.CurrencyPairs(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(pairs)
+
+	cancel()
+	suite.Require().NoError(<-done)
+	suite.Require().Contains(stderr.String(), "GET /public/pairs 200")
+}
+
+func (suite *runTestSuite) TestInvalidFixtures() {
+	var stderr syncBuffer
+	err := run(context.Background(), []string{"-addr", "127.0.0.1:0",
+		"-fixtures", "does-not-exist"}, &stderr)
+	suite.Require().Error(err)
+
+	dir := suite.T().TempDir()
+	err = ioutil.WriteFile(filepath.Join(dir, "orderBook.json"),
+		[]byte(`{`), 0644)
+	suite.Require().NoError(err)
+
+	err = run(context.Background(), []string{"-addr", "127.0.0.1:0",
+		"-admin", "", "-fixtures", dir}, &stderr)
+	suite.Require().Error(err)
+	suite.Require().Contains(err.Error(), "orderBook.json")
+}
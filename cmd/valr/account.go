@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nickcorin/valr"
+)
+
+func init() {
+	register(
+		&command{
+			name:    "balances",
+			args:    "[-hide-zero]",
+			summary: "List the balances of your wallets.",
+			private: true,
+			run:     runBalances,
+		},
+		&command{
+			name:    "deposit-address",
+			args:    "<currency>",
+			summary: "Show the deposit address of a currency.",
+			private: true,
+			run:     runDepositAddress,
+		},
+		&command{
+			name:    "trades",
+			args:    "<pair>",
+			summary: "List your last 100 trades of a currency pair.",
+			private: true,
+			run:     runTrades,
+		},
+		&command{
+			name: "transactions",
+			args: "[-currency c] [-type t,...] [-start time] [-end time] " +
+				"[-limit n]",
+			summary: "List your transaction history, most recent first.",
+			private: true,
+			run:     runTransactions,
+		},
+	)
+}
+
+func runBalances(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	hideZero := flags.Bool("hide-zero", false, "hide wallets with a zero "+
+		"total balance")
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	balances, err := a.client.Balances(ctx)
+	if err != nil {
+		return err
+	}
+
+	shown := make([]valr.Balance, 0, len(balances))
+	t := table{header: []string{"Currency", "Available", "Reserved", "Total",
+		"Updated"}}
+	for _, b := range balances {
+		if *hideZero && isZero(b.Total) {
+			continue
+		}
+
+		shown = append(shown, b)
+		t.add(b.Currency, b.Available, b.Reserved, b.Total,
+			formatTime(b.UpdatedAt))
+	}
+
+	return a.print(shown, &t)
+}
+
+// isZero returns whether a decimal string is zero.
+func isZero(s string) bool {
+	r, ok := new(big.Rat).SetString(s)
+	return ok && r.Sign() == 0
+}
+
+func runDepositAddress(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	address, err := a.client.DepositAddress(ctx,
+		strings.ToUpper(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Currency", "Address"}}
+	t.add(address.Currency, address.Address)
+
+	return a.print(address, &t)
+}
+
+func runTrades(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	trades, err := a.client.TradeHistory(ctx, strings.ToUpper(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "Pair", "Side", "Price", "Quantity",
+		"Traded"}}
+	for _, trade := range trades {
+		t.add(strconv.FormatInt(trade.ID, 10), trade.CurrencyPair,
+			string(trade.Side), trade.Price, trade.Quantity,
+			formatTime(trade.TradedAt))
+	}
+
+	return a.print(trades, &t)
+}
+
+func runTransactions(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	currency := flags.String("currency", "", "only show transactions of "+
+		"this currency")
+	types := flags.String("type", "", "comma separated transaction types "+
+		"to show, e.g. LIMIT_BUY,MARKET_SELL")
+	start := flags.String("start", "", "only show transactions at or after "+
+		"this time, in RFC 3339 or YYYY-MM-DD format")
+	end := flags.String("end", "", "only show transactions before this time")
+	limit := flags.Int("limit", 100, "maximum number of transactions to "+
+		"show, or 0 for all")
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	req := valr.TransactionHistoryRequest{
+		Currency: strings.ToUpper(*currency),
+	}
+
+	for _, typ := range strings.Split(*types, ",") {
+		if typ = strings.TrimSpace(typ); typ != "" {
+			req.Types = append(req.Types,
+				valr.TransactionType(strings.ToUpper(typ)))
+		}
+	}
+
+	for _, ts := range []struct {
+		value string
+		dst   *time.Time
+	}{{*start, &req.StartTime}, {*end, &req.EndTime}} {
+		if ts.value == "" {
+			continue
+		}
+
+		var err error
+		if *ts.dst, err = parseTime(ts.value); err != nil {
+			return err
+		}
+	}
+
+	transactions := make([]valr.Transaction, 0)
+	it := valr.NewTransactionIterator(a.client, &req)
+	for (*limit <= 0 || len(transactions) < *limit) && it.Next(ctx) {
+		transactions = append(transactions, it.Transaction())
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Time", "Type", "Debit", "Debit Currency",
+		"Credit", "Credit Currency", "Fee", "Fee Currency", "ID"}}
+	for _, tx := range transactions {
+		var typ string
+		if tx.TypeInfo != nil {
+			typ = string(tx.TypeInfo.Type)
+		}
+
+		t.add(formatTime(tx.EventAt), typ, tx.DebitValue, tx.DebitCurrency,
+			tx.CreditValue, tx.CreditCurrency, tx.FeeValue, tx.FeeCurrency,
+			tx.ID)
+	}
+
+	return a.print(transactions, &t)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/nickcorin/valr"
+)
+
+// defaultProfile is the profile used when none is given.
+const defaultProfile = "default"
+
+// loadCredentials returns the credentials of profile in the credentials file
+// at path if a profile was given with the -profile flag. Otherwise it returns
+// the credentials from the environment if they are set, falling back to the
+// profile named by VALR_PROFILE or the default profile.
+func loadCredentials(getenv func(string) string, path, profile string) (
+	valr.Credentials, error) {
+
+	if profile == "" {
+		creds := valr.Credentials{
+			Key:          getenv("VALR_API_KEY"),
+			Secret:       getenv("VALR_API_SECRET"),
+			SubaccountID: getenv("VALR_SUBACCOUNT_ID"),
+		}
+		if !creds.IsZero() {
+			return creds, nil
+		}
+
+		profile = getenv("VALR_PROFILE")
+	}
+
+	if profile == "" {
+		profile = defaultProfile
+	}
+
+	missing := fmt.Errorf("no credentials: set VALR_API_KEY and "+
+		"VALR_API_SECRET or add the %q profile to %s", profile, path)
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return valr.Credentials{}, missing
+	} else if err != nil {
+		return valr.Credentials{}, fmt.Errorf("failed to open credentials "+
+			"file: %w", err)
+	}
+	defer f.Close()
+
+	profiles, err := parseProfiles(f)
+	if err != nil {
+		return valr.Credentials{}, fmt.Errorf("failed to parse %s: %w", path,
+			err)
+	}
+
+	creds, ok := profiles[profile]
+	if !ok || creds.IsZero() {
+		return valr.Credentials{}, missing
+	}
+
+	return creds, nil
+}
+
+// parseProfiles parses an INI formatted credentials file, where each section
+// is a profile with api_key, api_secret and optional subaccount_id keys. Lines
+// starting with '#' or ';' are comments.
+func parseProfiles(r io.Reader) (map[string]valr.Credentials, error) {
+	profiles := make(map[string]valr.Credentials)
+
+	var profile string
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			profile = strings.TrimSpace(line[1 : len(line)-1])
+			profiles[profile] = valr.Credentials{}
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+
+		if profile == "" {
+			return nil, fmt.Errorf("line %d: key outside of a profile", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+
+		creds := profiles[profile]
+		switch key {
+		case "api_key":
+			creds.Key = value
+		case "api_secret":
+			creds.Secret = value
+		case "subaccount_id":
+			creds.SubaccountID = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", n, key)
+		}
+		profiles[profile] = creds
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return profiles, nil
+}
@@ -0,0 +1,246 @@
+// Command valr is a command-line client for the VALR API.
+//
+// Usage:
+//
+//	valr [flags] <command> [arguments]
+//
+// Run "valr -h" for the list of commands and "valr <command> -h" for the
+// arguments of a command.
+//
+// Credentials are only needed by commands which access your account. They are
+// read from the VALR_API_KEY, VALR_API_SECRET and optional VALR_SUBACCOUNT_ID
+// environment variables or, if those are not set, from a profile in
+// ~/.valr/credentials (or the file named by VALR_CREDENTIALS_FILE):
+//
+//	[default]
+//	api_key = my-api-key
+//	api_secret = my-api-secret
+//
+//	[trading]
+//	api_key = my-other-api-key
+//	api_secret = my-other-api-secret
+//	subaccount_id = 1234
+//
+// The profile is chosen with VALR_PROFILE, or with -profile which also takes
+// precedence over the environment variables. Results are written as a table,
+// JSON or CSV using -o.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/nickcorin/valr"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// command is a subcommand of the CLI.
+type command struct {
+	// name is used to invoke the command.
+	name string
+
+	// args describes the command's arguments in its usage.
+	args string
+
+	// summary is a one line description of the command.
+	summary string
+
+	// private is set for commands which require credentials.
+	private bool
+
+	// run runs the command with its arguments, defining any flags it accepts
+	// on flags. It returns errUsage if the arguments are invalid.
+	run func(ctx context.Context, a *app, flags *flag.FlagSet,
+		args []string) error
+}
+
+// commands contains every command, sorted by name.
+var commands []*command
+
+// register adds commands to the CLI.
+func register(cmds ...*command) {
+	commands = append(commands, cmds...)
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].name < commands[j].name
+	})
+}
+
+// lookup returns the command with the given name.
+func lookup(name string) (*command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return nil, false
+}
+
+// errUsage is returned by commands given invalid arguments. The usage has
+// already been written by then.
+var errUsage = errors.New("invalid usage")
+
+// app contains the state shared by all commands.
+type app struct {
+	client valr.Client
+	format format
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// flags returns a flag set for the arguments of cmd.
+func (a *app) flags(cmd *command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: valr %s %s\n\n%s\n", cmd.name,
+			cmd.args, cmd.summary)
+
+		var hasFlags bool
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(a.stderr, "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+
+	return flags
+}
+
+// parse parses the arguments of a command, which must have between min and max
+// positional arguments. A negative max allows any number of arguments.
+func parse(flags *flag.FlagSet, args []string, min, max int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+
+	if flags.NArg() < min || (max >= 0 && flags.NArg() > max) {
+		flags.Usage()
+		return errUsage
+	}
+
+	return nil
+}
+
+// run runs the CLI and returns its exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout,
+	stderr io.Writer, getenv func(string) string) int {
+
+	flags := flag.NewFlagSet("valr", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(flags, stderr) }
+
+	output := flags.String("o", string(formatTable), "output format: "+
+		"table, json or csv")
+	profile := flags.String("profile", "", "credentials profile to use, "+
+		"overriding the environment (default $VALR_PROFILE or \"default\")")
+	credentials := flags.String("credentials", getenv("VALR_CREDENTIALS_FILE"),
+		"credentials file (default \"~/.valr/credentials\")")
+	baseURL := flags.String("base-url", getenv("VALR_BASE_URL"), "base URL "+
+		"of the VALR API")
+
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	cmd, ok := lookup(flags.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "valr: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return exitUsage
+	}
+
+	f, err := parseFormat(*output)
+	if err != nil {
+		fmt.Fprintf(stderr, "valr: %v\n", err)
+		return exitUsage
+	}
+
+	var opts []valr.Option
+	if *baseURL != "" {
+		opts = append(opts, valr.WithBaseURL(strings.TrimSuffix(*baseURL,
+			"/")))
+	}
+
+	if cmd.private {
+		if *credentials == "" {
+			home, err := os.UserHomeDir()
+			if err == nil {
+				*credentials = filepath.Join(home, ".valr", "credentials")
+			}
+		}
+
+		creds, err := loadCredentials(getenv, *credentials, *profile)
+		if err != nil {
+			fmt.Fprintf(stderr, "valr: %v\n", err)
+			return exitError
+		}
+		ctx = valr.WithCredentials(ctx, creds)
+	}
+
+	a := &app{
+		client: valr.NewClient("", "", opts...),
+		format: f,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	err = cmd.run(ctx, a, a.flags(cmd), flags.Args()[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		} else if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+
+		fmt.Fprintf(stderr, "valr: %v\n", err)
+		return exitError
+	}
+
+	return exitOK
+}
+
+// usage writes the usage of the CLI.
+func usage(flags *flag.FlagSet, w io.Writer) {
+	fmt.Fprintf(w, "Usage: valr [flags] <command> [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-18s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintf(w, "\nFlags:\n")
+	flags.PrintDefaults()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCLITestSuite(t *testing.T) {
+	suite.Run(t, new(cliTestSuite))
+}
+
+type cliTestSuite struct {
+	suite.Suite
+	env    map[string]string
+	server *mock.Server
+}
+
+func (suite *cliTestSuite) SetupTest() {
+	suite.server = mock.NewServer()
+	suite.env = map[string]string{
+		"VALR_API_KEY":    "test-key",
+		"VALR_API_SECRET": "test-secret",
+		"VALR_BASE_URL":   suite.server.URL,
+	}
+}
+
+func (suite *cliTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// run runs the CLI and returns its exit code, stdout and stderr.
+func (suite *cliTestSuite) run(args ...string) (int, string, string) {
+	return suite.runWithInput("", args...)
+}
+
+// runWithInput runs the CLI reading input from stdin.
+func (suite *cliTestSuite) runWithInput(input string, args ...string) (int,
+	string, string) {
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(input), &stdout,
+		&stderr, func(key string) string { return suite.env[key] })
+
+	return code, stdout.String(), stderr.String()
+}
+
+func (suite *cliTestSuite) TestUsage() {
+	code, _, stderr := suite.run()
+	suite.Require().Equal(exitUsage, code)
+	suite.Require().Contains(stderr, "balances")
+	suite.Require().Contains(stderr, "transactions")
+
+	code, _, stderr = suite.run("nope")
+	suite.Require().Equal(exitUsage, code)
+	suite.Require().Contains(stderr, `unknown command "nope"`)
+
+	code, _, _ = suite.run("book")
+	suite.Require().Equal(exitUsage, code)
+
+	code, _, stderr = suite.run("book", "-h")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Contains(stderr, "Usage: valr book")
+
+	code, _, stderr = suite.run("-o", "xml", "status")
+	suite.Require().Equal(exitUsage, code)
+	suite.Require().Contains(stderr, `unknown output format "xml"`)
+}
+
+func (suite *cliTestSuite) TestFormats() {
+	code, stdout, _ := suite.run("currencies")
+	suite.Require().Equal(exitOK, code)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	suite.Require().Regexp(`^Symbol\s+Short Name\s+Long Name\s+Active$`,
+		lines[0])
+	suite.Require().Regexp(`^R\s+ZAR\s+Rand\s+true$`, lines[1])
+
+	code, stdout, _ = suite.run("-o", "json", "currencies")
+	suite.Require().Equal(exitOK, code)
+
+	var currencies []valr.Currency
+	suite.Require().NoError(json.Unmarshal([]byte(stdout), &currencies))
+	suite.Require().Equal(len(lines)-1, len(currencies))
+	suite.Require().Equal("Rand", currencies[0].LongName)
+
+	code, stdout, _ = suite.run("-o", "csv", "currencies")
+	suite.Require().Equal(exitOK, code)
+
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"R", "ZAR", "Rand", "true"}, records[1])
+}
+
+func (suite *cliTestSuite) TestPublicCommands() {
+	// Public commands need no credentials.
+	delete(suite.env, "VALR_API_KEY")
+
+	for _, args := range [][]string{
+		{"pairs"},
+		{"status"},
+		{"summary"},
+		{"summary", "btczar"},
+		{"time"},
+	} {
+		code, stdout, stderr := suite.run(args...)
+		suite.Require().Equal(exitOK, code, stderr)
+		suite.Require().NotEmpty(stdout)
+	}
+
+	code, stdout, _ := suite.run("-o", "csv", "book", "-depth", "2",
+		"BTCZAR")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Equal("Side,Price,Quantity,Orders\n"+
+		"SELL,10000,0.793789,3\n"+
+		"SELL,9000,0.101,1\n"+
+		"BUY,8802,0.1,1\n"+
+		"BUY,8801,0.2,1\n", stdout)
+}
+
+func (suite *cliTestSuite) TestPrivateCommands() {
+	code, stdout, _ := suite.run("-o", "csv", "balances", "-hide-zero")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Contains(stdout, "ZAR,46.67108319597,4200.42325067,")
+
+	code, stdout, _ = suite.run("deposit-address", "eth")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Contains(stdout,
+		"0xA7Fae2Fd50886b962d46FF4280f595A3982aeAa5")
+
+	code, stdout, _ = suite.run("-o", "csv", "trades", "BTCZAR")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Contains(stdout,
+		"10634,BTCZAR,BUY,87000,0.0001,2019-05-13T15:14:48Z")
+
+	code, stdout, _ = suite.run("-o", "csv", "transactions", "-type",
+		"limit_buy", "-start", "2019-05-07")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Contains(stdout,
+		"2019-05-07T09:45:26Z,LIMIT_BUY,83,ZAR,0.000998,BTC,0.000002,BTC,")
+	suite.Require().NotContains(stdout, "REFERRAL_REBATE")
+
+	code, _, stderr := suite.run("transactions", "-start", "yesterday")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, `invalid time "yesterday"`)
+}
+
+func (suite *cliTestSuite) TestCredentials() {
+	suite.env = map[string]string{"VALR_BASE_URL": suite.server.URL}
+
+	path := filepath.Join(suite.T().TempDir(), "credentials")
+	code, _, stderr := suite.run("-credentials", path, "balances")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "no credentials")
+
+	err := ioutil.WriteFile(path, []byte(`
+# Accounts.
+[default]
+api_key = default-key
+api_secret = default-secret
+
+[trading]
+api_key = trading-key
+api_secret = trading-secret
+subaccount_id = 1234
+`), 0600)
+	suite.Require().NoError(err)
+
+	var key, subaccount string
+	suite.server.Override("Balances", func(w http.ResponseWriter,
+		r *http.Request) {
+		key = r.Header.Get("X-VALR-API-KEY")
+		subaccount = r.Header.Get("X-VALR-SUB-ACCOUNT-ID")
+		w.Write([]byte(`[]`))
+	})
+
+	code, _, stderr = suite.run("-credentials", path, "balances")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Equal("default-key", key)
+	suite.Require().Empty(subaccount)
+
+	suite.env["VALR_PROFILE"] = "trading"
+	suite.env["VALR_CREDENTIALS_FILE"] = path
+	code, _, stderr = suite.run("balances")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Equal("trading-key", key)
+	suite.Require().Equal("1234", subaccount)
+
+	code, _, stderr = suite.run("-profile", "missing", "balances")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, `add the "missing" profile`)
+
+	// The environment takes precedence over profiles.
+	suite.env["VALR_API_KEY"] = "env-key"
+	suite.env["VALR_API_SECRET"] = "env-secret"
+	code, _, _ = suite.run("balances")
+	suite.Require().Equal(exitOK, code)
+	suite.Require().Equal("env-key", key)
+
+	// An explicit profile takes precedence over the environment.
+	code, _, stderr = suite.run("-profile", "default", "balances")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Equal("default-key", key)
+}
+
+func (suite *cliTestSuite) TestParseProfiles() {
+	for _, input := range []string{
+		"api_key = x",
+		"[default]\napi_key",
+		"[default]\ncolour = blue",
+	} {
+		_, err := parseProfiles(strings.NewReader(input))
+		suite.Require().Error(err, input)
+	}
+}
@@ -0,0 +1,640 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+func init() {
+	register(
+		&command{
+			name:    "cancel",
+			args:    "[-customer] [-yes] <pair> <order-id>",
+			summary: "Cancel an open order.",
+			private: true,
+			run:     runCancel,
+		},
+		&command{
+			name:    "cancel-all",
+			args:    "[-yes] <pair>",
+			summary: "Cancel all open orders of a currency pair.",
+			private: true,
+			run:     runCancelAll,
+		},
+		&command{
+			name: "limit",
+			args: "[-post-only] [-customer-id id] [-yes] <pair> <buy|sell> " +
+				"<quantity> <price>",
+			summary: "Place a limit order.",
+			private: true,
+			run:     runLimit,
+		},
+		&command{
+			name: "market",
+			args: "[-customer-id id] [-yes] <pair> <buy|sell> " +
+				"<quantity>",
+			summary: "Place a market order for a quantity of the base " +
+				"currency.",
+			private: true,
+			run:     runMarket,
+		},
+		&command{
+			name:    "order",
+			args:    "[-customer] <pair> <order-id>",
+			summary: "Show the status of an order.",
+			private: true,
+			run:     runOrder,
+		},
+		&command{
+			name: "post-only",
+			args: "[-customer-id id] [-yes] <pair> <buy|sell> <quantity> " +
+				"<price>",
+			summary: "Place a limit order which only adds liquidity.",
+			private: true,
+			run:     runPostOnly,
+		},
+		&command{
+			name: "simple",
+			args: "[-yes] <pair> <buy|sell> <amount>",
+			summary: "Buy paying an amount of the quote currency, or sell " +
+				"an amount of the base currency.",
+			private: true,
+			run:     runSimple,
+		},
+	)
+}
+
+// amountPlaces is the number of decimal places amounts are shown and sent
+// with.
+const amountPlaces = 8
+
+// errAborted is returned when the user does not confirm an action.
+var errAborted = errors.New("aborted")
+
+// confirm writes a description of an action and asks the user to confirm it,
+// returning errAborted if they do not. Nothing is asked if yes is set.
+func (a *app) confirm(yes bool, question string, description ...string) error {
+	for _, line := range description {
+		fmt.Fprintln(a.stderr, line)
+	}
+
+	if yes {
+		return nil
+	}
+
+	fmt.Fprintf(a.stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errAborted
+	}
+}
+
+// printOrderID writes the ID of an order which was placed or cancelled.
+func (a *app) printOrderID(id string) error {
+	t := table{header: []string{"ID"}}
+	t.add(id)
+
+	return a.print(map[string]string{"id": id}, &t)
+}
+
+// orderFlags are the flags shared by commands which place orders.
+type orderFlags struct {
+	customerID *string
+	yes        *bool
+}
+
+func newOrderFlags(flags *flag.FlagSet, customerID bool) orderFlags {
+	var f orderFlags
+	if customerID {
+		f.customerID = flags.String("customer-id", "", "your own ID for "+
+			"the order")
+	}
+	f.yes = flags.Bool("yes", false, "do not ask for confirmation")
+
+	return f
+}
+
+// orderArgs contains the positional arguments shared by commands which
+// place orders.
+type orderArgs struct {
+	pair   valr.Pair
+	side   valr.Side
+	amount *big.Rat
+}
+
+// parseOrder parses the pair, side and amount of an order, validating the
+// pair against registry.
+func parseOrder(registry *valr.PairRegistry, args []string) (orderArgs,
+	error) {
+
+	pair, err := registry.Parse(args[0])
+	if err != nil {
+		return orderArgs{}, err
+	}
+
+	side, err := valr.ParseSide(args[1])
+	if err != nil {
+		return orderArgs{}, err
+	}
+
+	amount, err := decimal.ParsePositive(args[2])
+	if err != nil {
+		return orderArgs{}, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	return orderArgs{pair: pair, side: side, amount: amount}, nil
+}
+
+// estimate is the expected execution of an order against the order book.
+type estimate struct {
+	// quantity is the amount of the base currency filled.
+	quantity *big.Rat
+
+	// notional is the amount of the quote currency filled.
+	notional *big.Rat
+
+	// complete is set if the order book is deep enough to fill the order.
+	complete bool
+}
+
+// price returns the average price of the estimated fill.
+func (e estimate) price() *big.Rat {
+	if e.quantity.Sign() == 0 {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Quo(e.notional, e.quantity)
+}
+
+// estimateFill walks the side of book which an order on side takes liquidity
+// from until it has filled amount of the base currency, or of the quote
+// currency if byQuote is set.
+func estimateFill(book *valr.OrderBook, side valr.Side, amount *big.Rat,
+	byQuote bool) (estimate, error) {
+
+	entries := book.Bids
+	if side == valr.SideBuy {
+		entries = book.Asks
+	}
+
+	e := estimate{quantity: new(big.Rat), notional: new(big.Rat)}
+	remaining := new(big.Rat).Set(amount)
+	for _, entry := range entries {
+		price, ok := new(big.Rat).SetString(entry.Price)
+		if !ok {
+			return estimate{}, fmt.Errorf("invalid price in order book: "+
+				"%q", entry.Price)
+		}
+
+		quantity, ok := new(big.Rat).SetString(entry.Quantity)
+		if !ok {
+			return estimate{}, fmt.Errorf("invalid quantity in order "+
+				"book: %q", entry.Quantity)
+		}
+
+		available := quantity
+		if byQuote {
+			available = new(big.Rat).Mul(quantity, price)
+		}
+
+		if available.Cmp(remaining) >= 0 {
+			available = remaining
+			e.complete = true
+		}
+		remaining = new(big.Rat).Sub(remaining, available)
+
+		if byQuote {
+			e.notional.Add(e.notional, available)
+			e.quantity.Add(e.quantity, new(big.Rat).Quo(available, price))
+		} else {
+			e.quantity.Add(e.quantity, available)
+			e.notional.Add(e.notional, new(big.Rat).Mul(available, price))
+		}
+
+		if e.complete {
+			break
+		}
+	}
+
+	return e, nil
+}
+
+// describeBook returns the best prices of an order book.
+func describeBook(book *valr.OrderBook) string {
+	bid, ask := "none", "none"
+	if len(book.Bids) > 0 {
+		bid = book.Bids[0].Price
+	}
+	if len(book.Asks) > 0 {
+		ask = book.Asks[0].Price
+	}
+
+	return fmt.Sprintf("Order book: best bid %s, best ask %s", bid, ask)
+}
+
+// describeEstimate describes the expected execution of an order for amount of
+// the base currency, or of the quote currency if byQuote is set.
+func describeEstimate(pair valr.Pair, e estimate, amount *big.Rat,
+	byQuote bool) string {
+
+	if e.quantity.Sign() == 0 {
+		return "Estimated fill: none, the order book is empty"
+	}
+
+	s := fmt.Sprintf("Estimated fill: %s %s for %s %s at an average price "+
+		"of %s", decimal.FormatPlaces(e.quantity, amountPlaces), pair.Base,
+		decimal.FormatPlaces(e.notional, amountPlaces), pair.Quote,
+		decimal.FormatPlaces(e.price(), amountPlaces))
+	if !e.complete {
+		currency := string(pair.Base)
+		if byQuote {
+			currency = string(pair.Quote)
+		}
+		s += fmt.Sprintf(" (the order book is too shallow to fill %s %s)",
+			decimal.FormatPlaces(amount, amountPlaces), currency)
+	}
+
+	return s
+}
+
+// placeLimit confirms and places a limit order.
+func placeLimit(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string, postOnly *bool) error {
+
+	f := newOrderFlags(flags, true)
+	if err := parse(flags, args, 4, 4); err != nil {
+		return err
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, a.client)
+	if err != nil {
+		return err
+	}
+
+	o, err := parseOrder(registry, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	price, err := decimal.ParsePositive(flags.Arg(3))
+	if err != nil {
+		return fmt.Errorf("invalid price: %w", err)
+	}
+
+	req := valr.LimitOrderRequest{
+		CustomerOrderID: *f.customerID,
+		Pair:            o.pair.Symbol,
+		PostOnly:        *postOnly,
+		Price:           decimal.FormatPlaces(price, amountPlaces),
+		Quantity:        decimal.FormatPlaces(o.amount, amountPlaces),
+		Side:            o.side,
+	}
+	if err = registry.ValidateLimitOrder(&req); err != nil {
+		return err
+	}
+
+	book, err := a.client.OrderBook(ctx, o.pair.Symbol)
+	if err != nil {
+		return err
+	}
+
+	kind := "Limit"
+	if req.PostOnly {
+		kind = "Post-only limit"
+	}
+
+	err = a.confirm(*f.yes, "Place order?",
+		fmt.Sprintf("%s %s %s %s at %s %s on %s", kind, req.Side,
+			req.Quantity, o.pair.Base, req.Price, o.pair.Quote,
+			o.pair.Symbol),
+		fmt.Sprintf("Notional: %s %s", decimal.FormatPlaces(
+			new(big.Rat).Mul(o.amount, price), amountPlaces), o.pair.Quote),
+		describeBook(book))
+	if err != nil {
+		return err
+	}
+
+	id, err := a.client.LimitOrder(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return a.printOrderID(id)
+}
+
+func runLimit(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	postOnly := flags.Bool("post-only", false, "cancel the order instead "+
+		"of matching it immediately")
+
+	return placeLimit(ctx, a, flags, args, postOnly)
+}
+
+func runPostOnly(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	postOnly := true
+	return placeLimit(ctx, a, flags, args, &postOnly)
+}
+
+func runMarket(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	f := newOrderFlags(flags, true)
+	if err := parse(flags, args, 3, 3); err != nil {
+		return err
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, a.client)
+	if err != nil {
+		return err
+	}
+
+	o, err := parseOrder(registry, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	req := valr.MarketOrderRequest{
+		BaseAmount:      decimal.FormatPlaces(o.amount, amountPlaces),
+		CustomerOrderID: *f.customerID,
+		Pair:            o.pair.Symbol,
+		Side:            o.side,
+	}
+	if err = registry.ValidateMarketOrder(&req); err != nil {
+		return err
+	}
+
+	book, err := a.client.OrderBook(ctx, o.pair.Symbol)
+	if err != nil {
+		return err
+	}
+
+	e, err := estimateFill(book, o.side, o.amount, false)
+	if err != nil {
+		return err
+	}
+
+	err = a.confirm(*f.yes, "Place order?",
+		fmt.Sprintf("Market %s %s %s on %s", req.Side, req.BaseAmount,
+			o.pair.Base, o.pair.Symbol),
+		describeEstimate(o.pair, e, o.amount, false))
+	if err != nil {
+		return err
+	}
+
+	id, err := a.client.MarketOrder(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return a.printOrderID(id)
+}
+
+func runSimple(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	f := newOrderFlags(flags, false)
+	if err := parse(flags, args, 3, 3); err != nil {
+		return err
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, a.client)
+	if err != nil {
+		return err
+	}
+
+	o, err := parseOrder(registry, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	// Buys pay in the quote currency and sells in the base currency.
+	pay, byQuote := string(o.pair.Base), false
+	if o.side == valr.SideBuy {
+		pay, byQuote = string(o.pair.Quote), true
+	}
+
+	req := valr.SimpleOrderRequest{
+		Amount:        decimal.FormatPlaces(o.amount, amountPlaces),
+		Pair:          o.pair.Symbol,
+		QuoteCurrency: pay,
+		Side:          o.side,
+	}
+
+	book, err := a.client.OrderBook(ctx, o.pair.Symbol)
+	if err != nil {
+		return err
+	}
+
+	e, err := estimateFill(book, o.side, o.amount, byQuote)
+	if err != nil {
+		return err
+	}
+
+	err = a.confirm(*f.yes, "Place order?",
+		fmt.Sprintf("Simple %s on %s paying %s %s", req.Side,
+			o.pair.Symbol, req.Amount, pay),
+		describeEstimate(o.pair, e, o.amount, byQuote))
+	if err != nil {
+		return err
+	}
+
+	id, err := a.client.SimpleOrder(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return a.printOrderID(id)
+}
+
+// describeOrder describes the unfilled part of an order.
+func describeOrder(side valr.Side, pair valr.Pair, remaining,
+	price string) string {
+
+	s := fmt.Sprintf("%s %s %s", side, remaining, pair.Base)
+	if price == "" {
+		return s
+	}
+
+	s += fmt.Sprintf(" at %s %s", price, pair.Quote)
+
+	quantity, ok := new(big.Rat).SetString(remaining)
+	p, pok := new(big.Rat).SetString(price)
+	if ok && pok {
+		s += fmt.Sprintf(", notional %s %s", decimal.FormatPlaces(
+			new(big.Rat).Mul(quantity, p), amountPlaces), pair.Quote)
+	}
+
+	return s
+}
+
+func runCancel(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	customer := flags.Bool("customer", false, "the order ID is your "+
+		"customer order ID")
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := parse(flags, args, 2, 2); err != nil {
+		return err
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, a.client)
+	if err != nil {
+		return err
+	}
+
+	pair, err := registry.Parse(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	statusReq := valr.OrderStatusRequest{Pair: pair.Symbol}
+	cancelReq := valr.CancelOrderRequest{Pair: pair.Symbol}
+	if *customer {
+		statusReq.CustomerOrderID = flags.Arg(1)
+		cancelReq.CustomerOrderID = flags.Arg(1)
+	} else {
+		statusReq.OrderID = flags.Arg(1)
+		cancelReq.OrderID = flags.Arg(1)
+	}
+
+	info, err := a.client.OrderStatus(ctx, &statusReq)
+	if err != nil {
+		return err
+	}
+
+	if info.Status.Done() {
+		return fmt.Errorf("order %s is already %s", info.ID,
+			strings.ToLower(string(info.Status)))
+	}
+
+	err = a.confirm(*yes, "Cancel order?",
+		fmt.Sprintf("Order %s on %s: %s remaining", info.ID, pair.Symbol,
+			describeOrder(info.Side, pair, info.RemainingQuantity,
+				info.OriginalPrice)))
+	if err != nil {
+		return err
+	}
+
+	if err = a.client.CancelOrder(ctx, &cancelReq); err != nil {
+		return err
+	}
+
+	return a.printOrderID(info.ID)
+}
+
+func runCancelAll(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	yes := flags.Bool("yes", false, "do not ask for confirmation")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, a.client)
+	if err != nil {
+		return err
+	}
+
+	pair, err := registry.Parse(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	orders, err := a.client.OpenOrders(ctx)
+	if err != nil {
+		return err
+	}
+
+	var open []valr.OpenOrder
+	description := []string{}
+	for _, o := range orders {
+		if !strings.EqualFold(o.CurrencyPair, pair.Symbol) {
+			continue
+		}
+
+		open = append(open, o)
+		description = append(description, fmt.Sprintf("Order %s: %s", o.ID,
+			describeOrder(o.Side, pair, o.RemainingQuantity, o.Price)))
+	}
+
+	cancelled := make([]map[string]string, 0, len(open))
+	t := table{header: []string{"ID"}}
+	if len(open) == 0 {
+		fmt.Fprintf(a.stderr, "No open orders on %s\n", pair.Symbol)
+		return a.print(cancelled, &t)
+	}
+
+	err = a.confirm(*yes, fmt.Sprintf("Cancel %d orders?", len(open)),
+		description...)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range open {
+		err = a.client.CancelOrder(ctx, &valr.CancelOrderRequest{
+			OrderID: o.ID,
+			Pair:    pair.Symbol,
+		})
+		if err != nil {
+			return fmt.Errorf("cancelled %d of %d orders: %w",
+				len(cancelled), len(open), err)
+		}
+
+		cancelled = append(cancelled, map[string]string{"id": o.ID})
+		t.add(o.ID)
+	}
+
+	return a.print(cancelled, &t)
+}
+
+func runOrder(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	customer := flags.Bool("customer", false, "the order ID is your "+
+		"customer order ID")
+	if err := parse(flags, args, 2, 2); err != nil {
+		return err
+	}
+
+	req := valr.OrderStatusRequest{Pair: strings.ToUpper(flags.Arg(0))}
+	if *customer {
+		req.CustomerOrderID = flags.Arg(1)
+	} else {
+		req.OrderID = flags.Arg(1)
+	}
+
+	info, err := a.client.OrderStatus(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"ID", "Pair", "Type", "Side", "Status",
+		"Price", "Quantity", "Remaining", "Average Price", "Fee",
+		"Failed Reason", "Created", "Updated"}}
+	t.add(info.ID, info.CurrencyPair, string(info.Type), string(info.Side),
+		string(info.Status), info.OriginalPrice, info.OriginalQuantity,
+		info.RemainingQuantity, info.AveragePrice, info.TotalFee,
+		info.FailedReason, formatTime(info.CreatedAt),
+		formatTime(info.UpdatedAt))
+
+	return a.print(info, &t)
+}
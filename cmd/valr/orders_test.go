@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+)
+
+// orderID returns the ID printed by a command which placed an order.
+func (suite *cliTestSuite) orderID(stdout string) string {
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	suite.Require().Len(lines, 2)
+	suite.Require().Equal("ID", strings.TrimSpace(lines[0]))
+
+	return strings.TrimSpace(lines[1])
+}
+
+// orderStatus returns the status of an order using the order command.
+func (suite *cliTestSuite) orderStatus(args ...string) valr.OrderInfo {
+	code, stdout, stderr := suite.run(append([]string{"-o", "json",
+		"order"}, args...)...)
+	suite.Require().Equal(exitOK, code, stderr)
+
+	var info valr.OrderInfo
+	suite.Require().NoError(json.Unmarshal([]byte(stdout), &info))
+
+	return info
+}
+
+func (suite *cliTestSuite) TestLimitOrder() {
+	code, _, stderr := suite.runWithInput("n\n", "limit", "btc/zar", "buy",
+		"0.002", "9000")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr,
+		"Limit BUY 0.002 BTC at 9000 ZAR on BTCZAR\n"+
+			"Notional: 18 ZAR\n"+
+			"Order book: best bid 8802, best ask 9000\n"+
+			"Place order? [y/N] ")
+	suite.Require().Contains(stderr, "valr: aborted")
+	suite.Require().Zero(suite.server.Calls("LimitOrder"))
+
+	code, stdout, stderr := suite.runWithInput("y\n", "limit",
+		"-customer-id", "mine", "BTCZAR", "buy", "0.002", "9000")
+	suite.Require().Equal(exitOK, code, stderr)
+
+	info := suite.orderStatus("BTCZAR", suite.orderID(stdout))
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+
+	info = suite.orderStatus("-customer", "BTCZAR", "mine")
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+
+	code, _, stderr = suite.run("limit", "BTCZAR", "buy", "0.00001", "9000")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "invalid quantity")
+	suite.Require().Equal(1, suite.server.Calls("LimitOrder"))
+}
+
+func (suite *cliTestSuite) TestPostOnlyOrder() {
+	code, stdout, stderr := suite.run("post-only", "-yes", "BTCZAR", "buy",
+		"0.002", "9000")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Contains(stderr, "Post-only limit BUY 0.002 BTC")
+	suite.Require().NotContains(stderr, "[y/N]")
+
+	// The order would have matched, so it fails.
+	info := suite.orderStatus("BTCZAR", suite.orderID(stdout))
+	suite.Require().Equal(valr.OrderStatusFailed, info.Status)
+	suite.Require().Equal(valr.OrderTypePostOnly, info.Type)
+}
+
+func (suite *cliTestSuite) TestMarketOrder() {
+	code, stdout, stderr := suite.runWithInput("yes\n", "market", "BTCZAR",
+		"sell", "0.002")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Contains(stderr, "Market SELL 0.002 BTC on BTCZAR\n"+
+		"Estimated fill: 0.002 BTC for 17.604 ZAR at an average price "+
+		"of 8802\n")
+
+	info := suite.orderStatus("BTCZAR", suite.orderID(stdout))
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+
+	suite.server.Override("OrderBook", mock.Value(&valr.OrderBook{
+		Asks: []valr.OrderBookEntry{
+			{Price: "9000", Quantity: "0.25", Side: valr.SideSell},
+			{Price: "9100", Quantity: "0.25", Side: valr.SideSell},
+		},
+	}))
+
+	code, _, stderr = suite.run("market", "BTCZAR", "buy", "1")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "Estimated fill: 0.5 BTC for 4525 ZAR "+
+		"at an average price of 9050 (the order book is too shallow to "+
+		"fill 1 BTC)")
+
+	code, _, stderr = suite.run("market", "BTCZAR", "sell", "1")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "Estimated fill: none, the order book "+
+		"is empty")
+}
+
+func (suite *cliTestSuite) TestSimpleOrder() {
+	code, stdout, stderr := suite.run("simple", "-yes", "BTCZAR", "buy",
+		"18")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Contains(stderr, "Simple BUY on BTCZAR paying 18 ZAR\n"+
+		"Estimated fill: 0.002 BTC for 18 ZAR at an average price of 9000\n")
+
+	info := suite.orderStatus("BTCZAR", suite.orderID(stdout))
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal(valr.OrderTypeSimple, info.Type)
+}
+
+func (suite *cliTestSuite) TestCancel() {
+	code, _, stderr := suite.run("limit", "-yes", "-customer-id", "c1",
+		"BTCZAR", "buy", "0.002", "8000")
+	suite.Require().Equal(exitOK, code, stderr)
+
+	code, stdout, stderr := suite.runWithInput("y\n", "cancel", "-customer",
+		"BTCZAR", "c1")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Contains(stderr, ": BUY 0.002 BTC at 8000 ZAR, "+
+		"notional 16 ZAR remaining\nCancel order? [y/N] ")
+
+	info := suite.orderStatus("BTCZAR", suite.orderID(stdout))
+	suite.Require().Equal(valr.OrderStatusCancelled, info.Status)
+
+	code, _, stderr = suite.run("cancel", "BTCZAR", info.ID)
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "is already cancelled")
+}
+
+func (suite *cliTestSuite) TestCancelAll() {
+	code, stdout, stderr := suite.run("-o", "json", "cancel-all", "BTCZAR")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().Contains(stderr, "No open orders on BTCZAR")
+	suite.Require().Equal("[]\n", stdout)
+
+	for _, price := range []string{"8000", "8100"} {
+		code, _, stderr = suite.run("limit", "-yes", "BTCZAR", "buy",
+			"0.002", price)
+		suite.Require().Equal(exitOK, code, stderr)
+	}
+
+	code, _, stderr = suite.runWithInput("", "cancel-all", "BTCZAR")
+	suite.Require().Equal(exitError, code)
+	suite.Require().Contains(stderr, "Cancel 2 orders? [y/N] ")
+
+	code, stdout, stderr = suite.run("-o", "json", "cancel-all", "-yes",
+		"btczar")
+	suite.Require().Equal(exitOK, code, stderr)
+
+	var cancelled []map[string]string
+	suite.Require().NoError(json.Unmarshal([]byte(stdout), &cancelled))
+	suite.Require().Len(cancelled, 2)
+	suite.Require().Empty(suite.server.Exchange.OpenOrders())
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// format is the format in which results are written.
+type format string
+
+// Output formats.
+const (
+	formatCSV   format = "csv"
+	formatJSON  format = "json"
+	formatTable format = "table"
+)
+
+func parseFormat(s string) (format, error) {
+	switch f := format(strings.ToLower(s)); f {
+	case formatCSV, formatJSON, formatTable:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q", s)
+	}
+}
+
+// table contains a result as rows of columns, used for table and CSV output.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// add appends a row to the table.
+func (t *table) add(columns ...string) {
+	t.rows = append(t.rows, columns)
+}
+
+// print writes a result in the app's format. JSON output encodes v, while
+// table and CSV output use t.
+func (a *app) print(v interface{}, t *table) error {
+	switch a.format {
+	case formatJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+
+	case formatCSV:
+		w := csv.NewWriter(a.stdout)
+		if err := w.Write(t.header); err != nil {
+			return err
+		}
+		return w.WriteAll(t.rows)
+
+	default:
+		w := tabwriter.NewWriter(a.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(t.header, "\t"))
+		for _, row := range t.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// formatTime formats a timestamp for table and CSV output.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseTime parses a timestamp given as an argument, either in RFC 3339 format
+// or as a date.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected "+
+			"RFC 3339 or YYYY-MM-DD", s)
+	}
+
+	return t, nil
+}
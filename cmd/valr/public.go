@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"strings"
+
+	"github.com/nickcorin/valr"
+)
+
+func init() {
+	register(
+		&command{
+			name:    "book",
+			args:    "[-depth n] <pair>",
+			summary: "Show the order book of a currency pair.",
+			run:     runBook,
+		},
+		&command{
+			name:    "currencies",
+			summary: "List the currencies supported by VALR.",
+			run:     runCurrencies,
+		},
+		&command{
+			name:    "pairs",
+			summary: "List the currency pairs supported by VALR.",
+			run:     runPairs,
+		},
+		&command{
+			name:    "status",
+			summary: "Show the current status of VALR.",
+			run:     runStatus,
+		},
+		&command{
+			name:    "summary",
+			args:    "[pair...]",
+			summary: "Show the market summary of all or some currency pairs.",
+			run:     runSummary,
+		},
+		&command{
+			name:    "time",
+			summary: "Show the time on VALR's servers.",
+			run:     runTime,
+		},
+	)
+}
+
+func runBook(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	depth := flags.Int("depth", 10, "number of price levels to show on "+
+		"each side")
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	book, err := a.client.OrderBook(ctx, strings.ToUpper(flags.Arg(0)))
+	if err != nil {
+		return err
+	}
+
+	if *depth > 0 {
+		if len(book.Asks) > *depth {
+			book.Asks = book.Asks[:*depth]
+		}
+		if len(book.Bids) > *depth {
+			book.Bids = book.Bids[:*depth]
+		}
+	}
+
+	// Asks are listed from the highest price down so that the best prices
+	// meet in the middle.
+	t := table{header: []string{"Side", "Price", "Quantity", "Orders"}}
+	for i := len(book.Asks) - 1; i >= 0; i-- {
+		addBookEntry(&t, book.Asks[i])
+	}
+	for _, entry := range book.Bids {
+		addBookEntry(&t, entry)
+	}
+
+	return a.print(book, &t)
+}
+
+func addBookEntry(t *table, entry valr.OrderBookEntry) {
+	t.add(string(entry.Side), entry.Price, entry.Quantity,
+		strconv.Itoa(entry.OrderCount))
+}
+
+func runCurrencies(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	currencies, err := a.client.Currencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Symbol", "Short Name", "Long Name",
+		"Active"}}
+	for _, c := range currencies {
+		t.add(c.Symbol, c.ShortName, c.LongName, strconv.FormatBool(c.IsActive))
+	}
+
+	return a.print(currencies, &t)
+}
+
+func runPairs(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	pairs, err := a.client.CurrencyPairs(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Symbol", "Base", "Quote", "Min Base",
+		"Max Base", "Min Quote", "Max Quote", "Active"}}
+	for _, p := range pairs {
+		t.add(p.Symbol, p.BaseCurrency, p.QuoteCurrency, p.MinBaseAmount,
+			p.MaxBaseAmount, p.MinQuoteAmount, p.MaxQuoteAmount,
+			strconv.FormatBool(p.Active))
+	}
+
+	return a.print(pairs, &t)
+}
+
+func runStatus(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	status, err := a.client.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Status"}}
+	t.add(string(status))
+
+	return a.print(map[string]valr.Status{"status": status}, &t)
+}
+
+func runSummary(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 0, -1); err != nil {
+		return err
+	}
+
+	var summaries []valr.MarketSummary
+	if flags.NArg() == 0 {
+		var err error
+		if summaries, err = a.client.MarketSummary(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, pair := range flags.Args() {
+		summary, err := a.client.MarketSummaryForCurrency(ctx,
+			strings.ToUpper(pair))
+		if err != nil {
+			return err
+		}
+		summaries = append(summaries, *summary)
+	}
+
+	t := table{header: []string{"Pair", "Last", "Bid", "Ask", "High", "Low",
+		"Volume", "Change"}}
+	for _, s := range summaries {
+		t.add(s.CurrencyPair, s.LastTradedPrice, s.BidPrice, s.AskPrice,
+			s.HighPrice, s.LowPrice, s.BaseVolume, s.ChangeFromPrevious)
+	}
+
+	return a.print(summaries, &t)
+}
+
+func runTime(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	serverTime, err := a.client.ServerTime(ctx)
+	if err != nil {
+		return err
+	}
+
+	t := table{header: []string{"Epoch", "Time"}}
+	t.add(strconv.FormatInt(serverTime.Epoch, 10), formatTime(serverTime.Time))
+
+	return a.print(serverTime, &t)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+func init() {
+	register(&command{
+		name: "watch",
+		args: "[-interval duration] [-count n] <pair>...",
+		summary: "Continuously show the top of book, spread, last trade and " +
+			"24h change of currency pairs.",
+		run: runWatch,
+	})
+}
+
+// clearScreen moves the cursor to the top left of the terminal and clears it.
+const clearScreen = "\033[H\033[2J"
+
+// ticker is the state of a pair's market at a point in time.
+type ticker struct {
+	Pair          string `json:"pair"`
+	Bid           string `json:"bid"`
+	Ask           string `json:"ask"`
+	Spread        string `json:"spread"`
+	SpreadPercent string `json:"spreadPercent"`
+	Last          string `json:"lastTradedPrice"`
+	Change        string `json:"changeFromPrevious"`
+	High          string `json:"highPrice"`
+	Low           string `json:"lowPrice"`
+	Volume        string `json:"baseVolume"`
+	Error         string `json:"error,omitempty"`
+}
+
+// snapshot is a single refresh of the watched pairs.
+type snapshot struct {
+	Time    time.Time `json:"time"`
+	Tickers []ticker  `json:"tickers"`
+}
+
+func runWatch(ctx context.Context, a *app, flags *flag.FlagSet,
+	args []string) error {
+
+	interval := flags.Duration("interval", 2*time.Second, "time between "+
+		"refreshes")
+	count := flags.Int("count", 0, "number of refreshes after which to "+
+		"stop, or 0 to refresh until interrupted")
+	if err := parse(flags, args, 1, -1); err != nil {
+		return err
+	}
+
+	if *interval <= 0 {
+		return fmt.Errorf("invalid interval %s: must be positive", *interval)
+	}
+
+	pairs := make([]string, 0, flags.NArg())
+	for _, pair := range flags.Args() {
+		pairs = append(pairs, strings.ToUpper(pair))
+	}
+
+	// Only redraw in place when writing a table to a terminal, so that
+	// redirected output keeps every refresh.
+	redraw := a.format == formatTable && isTerminal(a.stdout)
+
+	t := time.NewTicker(*interval)
+	defer t.Stop()
+
+	for n := 1; ; n++ {
+		s := watch(ctx, a.client, pairs)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if redraw {
+			fmt.Fprint(a.stdout, clearScreen)
+		} else if n > 1 && a.format == formatTable {
+			fmt.Fprintln(a.stdout)
+		}
+
+		if err := a.printSnapshot(s, n == 1); err != nil {
+			return err
+		}
+
+		if *count > 0 && n >= *count {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// isTerminal returns whether w is a terminal.
+func isTerminal(w interface{}) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// watch fetches the current state of each pair's market. Failures are
+// recorded on the pair's ticker so that the other pairs are still shown.
+func watch(ctx context.Context, c valr.PublicClient,
+	pairs []string) snapshot {
+
+	s := snapshot{Time: time.Now().UTC()}
+	for _, pair := range pairs {
+		t := ticker{Pair: pair}
+
+		book, err := c.OrderBook(ctx, pair)
+		if err != nil {
+			t.Error = err.Error()
+			s.Tickers = append(s.Tickers, t)
+			continue
+		}
+
+		summary, err := c.MarketSummaryForCurrency(ctx, pair)
+		if err != nil {
+			t.Error = err.Error()
+			s.Tickers = append(s.Tickers, t)
+			continue
+		}
+
+		if len(book.Bids) > 0 {
+			t.Bid = book.Bids[0].Price
+		}
+		if len(book.Asks) > 0 {
+			t.Ask = book.Asks[0].Price
+		}
+		t.Spread, t.SpreadPercent = spread(t.Bid, t.Ask)
+
+		t.Last = summary.LastTradedPrice
+		t.Change = summary.ChangeFromPrevious
+		t.High = summary.HighPrice
+		t.Low = summary.LowPrice
+		t.Volume = summary.BaseVolume
+
+		s.Tickers = append(s.Tickers, t)
+	}
+
+	return s
+}
+
+// spread returns the difference between the best bid and ask, and that
+// difference as a percentage of the mid price.
+func spread(bid, ask string) (string, string) {
+	b, ok := new(big.Rat).SetString(bid)
+	if !ok {
+		return "", ""
+	}
+
+	a, ok := new(big.Rat).SetString(ask)
+	if !ok {
+		return "", ""
+	}
+
+	diff := new(big.Rat).Sub(a, b)
+
+	mid := new(big.Rat).Add(a, b)
+	mid.Quo(mid, big.NewRat(2, 1))
+	if mid.Sign() == 0 {
+		return decimal.FormatPlaces(diff, amountPlaces), ""
+	}
+
+	percent := new(big.Rat).Quo(diff, mid)
+	percent.Mul(percent, big.NewRat(100, 1))
+
+	return decimal.FormatPlaces(diff, amountPlaces), percent.FloatString(2)
+}
+
+// printSnapshot writes a refresh of the watched pairs. Each refresh is written
+// as a single line of JSON, and CSV output only includes the header in the
+// first refresh.
+func (a *app) printSnapshot(s snapshot, first bool) error {
+	if a.format == formatTable {
+		fmt.Fprintf(a.stdout, "Updated %s\n\n", s.Time.Format(time.RFC3339))
+	}
+
+	t := table{header: []string{"Time", "Pair", "Bid", "Ask", "Spread",
+		"Spread %", "Last", "Change %", "High", "Low", "Volume"}}
+	if a.format == formatTable {
+		t.header = t.header[1:]
+	}
+
+	for _, ticker := range s.Tickers {
+		row := []string{formatTime(s.Time), ticker.Pair}
+		if ticker.Error != "" {
+			row = append(row, "error: "+ticker.Error)
+		} else {
+			row = append(row, ticker.Bid, ticker.Ask, ticker.Spread,
+				ticker.SpreadPercent, ticker.Last, ticker.Change,
+				ticker.High, ticker.Low, ticker.Volume)
+		}
+
+		if a.format == formatTable {
+			row = row[1:]
+		}
+		t.add(row...)
+	}
+
+	switch a.format {
+	case formatJSON:
+		return json.NewEncoder(a.stdout).Encode(s)
+	case formatCSV:
+		if !first {
+			return csv.NewWriter(a.stdout).WriteAll(t.rows)
+		}
+	}
+
+	return a.print(s, &t)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+)
+
+func (suite *cliTestSuite) TestWatch() {
+	code, stdout, stderr := suite.run("watch", "-interval", "10ms",
+		"-count", "2", "btczar", "NOPE")
+	suite.Require().Equal(exitOK, code, stderr)
+	suite.Require().NotContains(stdout, clearScreen)
+
+	// Refreshes are separated by a blank line.
+	lines := strings.Split(stdout, "\n")
+	suite.Require().Len(lines, 12)
+
+	for _, lines := range [][]string{lines[:5], lines[6:11]} {
+		suite.Require().Regexp(`^Updated \d{4}-`, lines[0])
+		suite.Require().Regexp(`^Pair\s+Bid\s+Ask\s+Spread\s+Spread %\s+`+
+			`Last\s+Change %\s+High\s+Low\s+Volume$`, lines[2])
+		suite.Require().Regexp(`^BTCZAR\s+8802\s+9000\s+198\s+2.22\s+7005\s+`+
+			`0\s+10000\s+7005\s+0.16065663$`, lines[3])
+		suite.Require().Regexp(`^NOPE\s+error: `, lines[4])
+	}
+	suite.Require().Equal(2, suite.server.Calls("MarketSummaryForCurrency"))
+}
+
+func (suite *cliTestSuite) TestWatchFormats() {
+	code, stdout, _ := suite.run("-o", "json", "watch", "-interval", "10ms",
+		"-count", "3", "BTCZAR")
+	suite.Require().Equal(exitOK, code)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	suite.Require().Len(lines, 3)
+
+	var s snapshot
+	suite.Require().NoError(json.Unmarshal([]byte(lines[2]), &s))
+	suite.Require().Len(s.Tickers, 1)
+	suite.Require().Equal("9000", s.Tickers[0].Ask)
+	suite.Require().Equal("2.22", s.Tickers[0].SpreadPercent)
+
+	code, stdout, _ = suite.run("-o", "csv", "watch", "-interval", "10ms",
+		"-count", "2", "BTCZAR")
+	suite.Require().Equal(exitOK, code)
+
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	suite.Require().NoError(err)
+	suite.Require().Len(records, 3)
+	suite.Require().Equal("Time", records[0][0])
+	suite.Require().Equal("BTCZAR", records[2][1])
+
+	code, _, _ = suite.run("watch", "-interval", "0s", "BTCZAR")
+	suite.Require().Equal(exitError, code)
+
+	code, _, _ = suite.run("watch")
+	suite.Require().Equal(exitUsage, code)
+}
@@ -0,0 +1,43 @@
+package valr
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoCredentials is returned when a PrivateClient method is called without
+// any credentials configured on the client or attached to the context.
+var ErrNoCredentials = errors.New("valr: no credentials provided for " +
+	"authenticated endpoint")
+
+// Credentials contains the API key pair used to sign requests to
+// authenticated endpoints. SubaccountID is optional and, when set, performs
+// the request on behalf of the given subaccount.
+type Credentials struct {
+	Key          string
+	Secret       string
+	SubaccountID string
+}
+
+// IsZero returns whether the credentials are incomplete and cannot be used to
+// sign a request.
+func (c Credentials) IsZero() bool {
+	return c.Key == "" || c.Secret == ""
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a copy of ctx carrying the given credentials. Calls
+// made with the returned context are signed using these credentials instead
+// of the ones the client was constructed with, allowing a single shared Client
+// to serve many accounts.
+func WithCredentials(ctx context.Context, creds Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, creds)
+}
+
+// CredentialsFromContext returns the credentials attached to ctx using
+// WithCredentials, if any.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	creds, ok := ctx.Value(credentialsKey{}).(Credentials)
+	return creds, ok
+}
@@ -0,0 +1,135 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCredentialsTestSuite(t *testing.T) {
+	suite.Run(t, new(credentialsTestSuite))
+}
+
+type credentialsTestSuite struct {
+	suite.Suite
+	headers chan http.Header
+	server  *httptest.Server
+}
+
+func (suite *credentialsTestSuite) SetupTest() {
+	suite.headers = make(chan http.Header, 10)
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			suite.headers <- r.Header.Clone()
+			w.Write([]byte("[]"))
+		}))
+}
+
+func (suite *credentialsTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *credentialsTestSuite) client(key, secret string) valr.Client {
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL)
+	return valr.ToPrivateClient(c, key, secret).(valr.Client)
+}
+
+func (suite *credentialsTestSuite) TestNoCredentials() {
+	c := suite.client("", "")
+
+	_, err := c.Balances(context.Background())
+	suite.Require().True(errors.Is(err, valr.ErrNoCredentials))
+}
+
+func (suite *credentialsTestSuite) TestPublicWithoutCredentials() {
+	c := suite.client("", "")
+
+	_, err := c.Currencies(context.Background())
+	suite.Require().NoError(err)
+
+	headers := <-suite.headers
+	suite.Require().Empty(headers.Get("X-VALR-API-KEY"))
+	suite.Require().Empty(headers.Get("X-VALR-SIGNATURE"))
+}
+
+func (suite *credentialsTestSuite) TestContextCredentials() {
+	c := suite.client("", "")
+
+	ctx := valr.WithCredentials(context.Background(), valr.Credentials{
+		Key:          "tenant-key",
+		Secret:       "tenant-secret",
+		SubaccountID: "12345",
+	})
+
+	_, err := c.Balances(ctx)
+	suite.Require().NoError(err)
+
+	headers := <-suite.headers
+	suite.Require().Equal("tenant-key", headers.Get("X-VALR-API-KEY"))
+	suite.Require().Equal("12345", headers.Get("X-VALR-SUB-ACCOUNT-ID"))
+	suite.Require().NotEmpty(headers.Get("X-VALR-SIGNATURE"))
+	suite.Require().NotEmpty(headers.Get("X-VALR-TIMESTAMP"))
+}
+
+func (suite *credentialsTestSuite) TestContextOverridesClient() {
+	c := suite.client("client-key", "client-secret")
+
+	_, err := c.Balances(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal("client-key",
+		(<-suite.headers).Get("X-VALR-API-KEY"))
+
+	ctx := valr.WithCredentials(context.Background(), valr.Credentials{
+		Key:    "tenant-key",
+		Secret: "tenant-secret",
+	})
+
+	_, err = c.Balances(ctx)
+	suite.Require().NoError(err)
+
+	headers := <-suite.headers
+	suite.Require().Equal("tenant-key", headers.Get("X-VALR-API-KEY"))
+	suite.Require().Empty(headers.Get("X-VALR-SUB-ACCOUNT-ID"))
+}
+
+func (suite *credentialsTestSuite) TestIncompleteContextCredentials() {
+	c := suite.client("client-key", "client-secret")
+
+	ctx := valr.WithCredentials(context.Background(), valr.Credentials{
+		Key: "tenant-key",
+	})
+
+	_, err := c.Balances(ctx)
+	suite.Require().True(errors.Is(err, valr.ErrNoCredentials))
+}
+
+func (suite *credentialsTestSuite) TestConcurrentTenants() {
+	c := suite.client("", "")
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+
+			ctx := valr.WithCredentials(context.Background(),
+				valr.Credentials{Key: key, Secret: key})
+			_, err := c.Balances(ctx)
+			suite.Require().NoError(err)
+		}(key)
+	}
+	wg.Wait()
+	close(suite.headers)
+
+	seen := make(map[string]bool)
+	for headers := range suite.headers {
+		seen[headers.Get("X-VALR-API-KEY")] = true
+	}
+	suite.Require().Len(seen, 5)
+}
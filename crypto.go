@@ -14,17 +14,13 @@ type DepositAddress struct {
 // DepositAddress satisfies the PrivateClient interface.
 func (c *client) DepositAddress(ctx context.Context, currency string) (
 	*DepositAddress, error) {
-	res, err := c.httpClient.Get(ctx,
-		fmt.Sprintf("/wallet/crypto/%s/deposit/address", currency), nil)
+	res, err := c.request(ctx,
+		Call{Endpoint: "DepositAddress", Currency: currency},
+		&CryptoDepositAddressRequest{Currency: currency})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get default deposit address: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch default deposit address: %d "+
-			"status code received", res.StatusCode)
-	}
-
 	var addr DepositAddress
 	if err = res.JSON(&addr); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal deposit address: %w", err)
@@ -46,17 +42,13 @@ type WithdrawalInfo struct {
 func (c *client) WithdrawalInfo(ctx context.Context, currency string) (
 	*WithdrawalInfo, error) {
 
-	res, err := c.httpClient.Get(ctx,
-		fmt.Sprintf("/wallet/crypto/:%s/withdraw", currency), nil)
+	res, err := c.request(ctx,
+		Call{Endpoint: "WithdrawalInfo", Currency: currency},
+		&CryptoWithdrawalInfoRequest{Currency: currency})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get withdrawal info: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch withdrawal info: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	var info WithdrawalInfo
 	if err = res.JSON(&info); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal deposit address: %w", err)
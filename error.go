@@ -0,0 +1,23 @@
+package valr
+
+import (
+	"fmt"
+)
+
+// Error is returned when VALR responds with a non-2xx status code. The Code
+// and Message are decoded from the response body when present.
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       int    `json:"code"`
+	Message    string `json:"message"`
+}
+
+// Error satisfies the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("valr: %d status code received", e.StatusCode)
+	}
+
+	return fmt.Sprintf("valr: %s (code %d, status %d)", e.Message, e.Code,
+		e.StatusCode)
+}
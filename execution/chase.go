@@ -0,0 +1,124 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/nickcorin/valr"
+)
+
+// Chase describes an order to be filled by a post-only order which follows
+// the top of the book.
+type Chase struct {
+	Order
+
+	// LimitPrice, if set, is the worst price at which the order may be
+	// placed. The order is not re-priced beyond it.
+	LimitPrice string
+}
+
+// Chase fills an order with a post-only limit order at the best price on its
+// side of the book. Whenever another order is placed at a better price, the
+// order is cancelled and placed again at the new best price. Post-only orders
+// which fail because the book moved are placed again at the next poll.
+//
+// The progress made is returned even if an error occurs.
+func (e *Executor) Chase(ctx context.Context, req Chase) (Progress, error) {
+	x, err := e.newExecution(ctx, req.Order)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid chase: %w", err)
+	}
+
+	var limit *big.Rat
+	if req.LimitPrice != "" {
+		if limit, err = x.price(req.LimitPrice); err != nil {
+			return Progress{}, fmt.Errorf("invalid chase: invalid limit "+
+				"price: %w", err)
+		}
+	}
+
+	for {
+		quantity := x.round(x.remaining())
+		if quantity.Sign() <= 0 {
+			return x.progress(), nil
+		}
+
+		price, err := x.top(ctx, limit)
+		if err != nil {
+			return x.finish(ctx, err)
+		}
+
+		if price == nil {
+			if err = sleep(ctx, e.interval); err != nil {
+				return x.finish(ctx, err)
+			}
+			continue
+		}
+
+		if err = x.place(ctx, price, quantity, true); err != nil {
+			return x.finish(ctx, fmt.Errorf("failed to place order: %w",
+				err))
+		}
+
+		// The top of the book is checked between polls, stopping the wait to
+		// re-price the order if it has been overtaken.
+		var topErr error
+		info, err := x.wait(ctx, func() bool {
+			top, err := x.top(ctx, limit)
+			if err != nil {
+				topErr = err
+				return true
+			}
+
+			// A price which is better for the order is further from the
+			// top of the book.
+			return top != nil && better(x.side, x.activePrice, top)
+		})
+
+		switch {
+		case isPostOnlyFailure(info):
+			if err = sleep(ctx, e.interval); err != nil {
+				return x.finish(ctx, err)
+			}
+			continue
+		case err != nil:
+			return x.finish(ctx, err)
+		case topErr != nil:
+			return x.finish(ctx, topErr)
+		case info != nil && info.Status == valr.OrderStatusCancelled:
+			return x.progress(), fmt.Errorf("%w: order %s was cancelled",
+				ErrOrderFailed, info.ID)
+		case info == nil:
+			if err = x.cancel(ctx); err != nil {
+				return x.finish(ctx, fmt.Errorf("failed to cancel order: %w",
+					err))
+			}
+		}
+	}
+}
+
+// top returns the best price on the execution's side of the book, no worse
+// than limit, or nil if that side is empty and there is no limit.
+func (x *execution) top(ctx context.Context, limit *big.Rat) (*big.Rat,
+	error) {
+
+	price, err := x.best(ctx, x.side)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order book: %w", err)
+	}
+
+	if price == nil || (limit != nil && better(x.side, limit, price)) {
+		return limit, nil
+	}
+
+	return price, nil
+}
+
+// isPostOnlyFailure returns whether an order failed because it would have
+// matched.
+func isPostOnlyFailure(info *valr.OrderInfo) bool {
+	return info != nil && info.Status == valr.OrderStatusFailed &&
+		strings.HasPrefix(strings.ToLower(info.FailedReason), "post only")
+}
@@ -0,0 +1,486 @@
+// Package execution works large orders on VALR gradually to reduce their
+// market impact. Orders are split into limit orders placed with
+// LimitOrderRequest and withdrawn with CancelOrderRequest, using one of the
+// following algorithms:
+//
+//   - TWAP slices an order evenly over a period of time.
+//   - Iceberg shows only part of an order on the book at a time.
+//   - Chase keeps a post-only order at the top of the book until it fills.
+//
+// Each algorithm stops when its context is cancelled, cancelling any order it
+// has on the book, and reports its progress as its orders fill.
+package execution
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// DefaultPollInterval is the default interval at which the status of orders
+// and the order book are checked.
+const DefaultPollInterval = time.Second
+
+// cancelTimeout bounds the time spent withdrawing an order once the context
+// of an execution has been cancelled.
+const cancelTimeout = 10 * time.Second
+
+// ErrOrderFailed is returned when an order placed by an algorithm fails, e.g.
+// due to insufficient balance. It is wrapped with the reason given by VALR.
+var ErrOrderFailed = errors.New("order failed")
+
+// Order describes the total quantity to buy or sell.
+type Order struct {
+	Pair string
+	Side valr.Side
+
+	// Quantity is the amount of the base currency to buy or sell.
+	Quantity string
+}
+
+// Progress describes how much of an Order has been filled.
+type Progress struct {
+	// Filled is the quantity of the base currency filled, and FilledQuote is
+	// the quote currency paid or received for it.
+	Filled      *big.Rat
+	FilledQuote *big.Rat
+
+	// Orders are the IDs of the orders placed, oldest first.
+	Orders []string
+
+	Quantity *big.Rat
+}
+
+// Remaining returns the quantity which has not been filled.
+func (p Progress) Remaining() *big.Rat {
+	return new(big.Rat).Sub(p.Quantity, p.Filled)
+}
+
+// AveragePrice returns the average price of the quantity filled, or zero if
+// nothing has been filled.
+func (p Progress) AveragePrice() *big.Rat {
+	if p.Filled.Sign() == 0 {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Quo(p.FilledQuote, p.Filled)
+}
+
+// Executor runs execution algorithms using a client. Child orders are sized
+// and priced within the precision of their pair, which is loaded from VALR on
+// first use unless given with WithPairRegistry.
+type Executor struct {
+	client   valr.Client
+	interval time.Duration
+	progress func(Progress)
+
+	mu       sync.Mutex
+	registry *valr.PairRegistry
+}
+
+// Option configures an Executor.
+type Option func(*Executor)
+
+// WithPollInterval sets the interval at which the status of orders and the
+// order book are checked, which defaults to DefaultPollInterval.
+func WithPollInterval(d time.Duration) Option {
+	return func(e *Executor) {
+		e.interval = d
+	}
+}
+
+// WithProgress sets a function which is called each time more of an order is
+// filled or a new order is placed. It is called from the goroutine running the
+// algorithm.
+func WithProgress(fn func(Progress)) Option {
+	return func(e *Executor) {
+		e.progress = fn
+	}
+}
+
+// WithPairRegistry sets the registry used to validate orders and round the
+// quantities of child orders to the precision of their pair.
+func WithPairRegistry(r *valr.PairRegistry) Option {
+	return func(e *Executor) {
+		e.registry = r
+	}
+}
+
+// New returns an Executor which places orders using client.
+func New(client valr.Client, opts ...Option) *Executor {
+	e := Executor{client: client, interval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(&e)
+	}
+
+	return &e
+}
+
+// pairs returns the registry of currency pairs, loading it on first use.
+func (e *Executor) pairs(ctx context.Context) (*valr.PairRegistry, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.registry != nil {
+		return e.registry, nil
+	}
+
+	registry, err := valr.LoadPairRegistry(ctx, e.client)
+	if err != nil {
+		return nil, err
+	}
+	e.registry = registry
+
+	return registry, nil
+}
+
+// execution tracks the progress of an Order across the child orders placed to
+// fill it. At most one child order is active at a time.
+type execution struct {
+	e        *Executor
+	registry *valr.PairRegistry
+
+	pair     string
+	side     valr.Side
+	quantity *big.Rat
+
+	// filled and filledQuote are the totals of settled child orders.
+	filled      *big.Rat
+	filledQuote *big.Rat
+
+	// active is the ID of the child order on the book, if any, with its
+	// fills so far.
+	active            string
+	activePrice       *big.Rat
+	activeFilled      *big.Rat
+	activeFilledQuote *big.Rat
+
+	// prefix identifies the execution in the customer order IDs of its
+	// orders.
+	prefix string
+	orders []string
+}
+
+func (e *Executor) newExecution(ctx context.Context, o Order) (*execution,
+	error) {
+
+	if err := o.Side.Validate(); err != nil {
+		return nil, err
+	}
+
+	quantity, err := decimal.ParsePositive(o.Quantity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	registry, err := e.pairs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load currency pairs: %w", err)
+	}
+
+	pair, err := registry.Lookup(o.Pair)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = registry.ValidateQuantity(pair.Symbol, o.Quantity); err != nil {
+		return nil, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	var id [8]byte
+	if _, err = rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+
+	return &execution{
+		e:                 e,
+		registry:          registry,
+		prefix:            hex.EncodeToString(id[:]),
+		pair:              pair.Symbol,
+		side:              o.Side,
+		quantity:          quantity,
+		filled:            new(big.Rat),
+		filledQuote:       new(big.Rat),
+		activeFilled:      new(big.Rat),
+		activeFilledQuote: new(big.Rat),
+	}, nil
+}
+
+// progress returns the current progress of the execution.
+func (x *execution) progress() Progress {
+	return Progress{
+		Filled:      new(big.Rat).Add(x.filled, x.activeFilled),
+		FilledQuote: new(big.Rat).Add(x.filledQuote, x.activeFilledQuote),
+		Orders:      append([]string(nil), x.orders...),
+		Quantity:    new(big.Rat).Set(x.quantity),
+	}
+}
+
+// report calls the progress function, if any.
+func (x *execution) report() {
+	if x.e.progress != nil {
+		x.e.progress(x.progress())
+	}
+}
+
+// remaining returns the quantity which has not been filled.
+func (x *execution) remaining() *big.Rat {
+	remaining := new(big.Rat).Sub(x.quantity, x.filled)
+	return remaining.Sub(remaining, x.activeFilled)
+}
+
+// round rounds a quantity down to the precision of the execution's pair.
+func (x *execution) round(quantity *big.Rat) *big.Rat {
+	// The pair was found when the execution was created.
+	rounded, _ := x.registry.RoundQuantity(x.pair, quantity)
+	return rounded
+}
+
+// price parses a limit price, which must be a multiple of the pair's tick
+// size.
+func (x *execution) price(s string) (*big.Rat, error) {
+	if err := x.registry.ValidatePrice(x.pair, s); err != nil {
+		return nil, err
+	}
+
+	return decimal.ParsePositive(s)
+}
+
+// place places a child limit order, which becomes the active order.
+func (x *execution) place(ctx context.Context, price, quantity *big.Rat,
+	postOnly bool) error {
+
+	customerID := fmt.Sprintf("%s-%d", x.prefix, len(x.orders)+1)
+	id, err := x.e.client.LimitOrder(ctx, &valr.LimitOrderRequest{
+		CustomerOrderID: customerID,
+		Pair:            x.pair,
+		PostOnly:        postOnly,
+		Price:           decimal.Format(price),
+		Quantity:        decimal.Format(quantity),
+		Side:            x.side,
+	})
+	if err != nil && ctx.Err() != nil {
+		// The order may have been placed even though the response was lost,
+		// so it is cancelled by its customer order ID.
+		cleanup, cancel := context.WithTimeout(context.Background(),
+			cancelTimeout)
+		defer cancel()
+
+		_ = x.e.client.CancelOrder(cleanup, &valr.CancelOrderRequest{
+			CustomerOrderID: customerID,
+			Pair:            x.pair,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	x.active, x.activePrice = id, price
+	x.orders = append(x.orders, id)
+	x.report()
+
+	return nil
+}
+
+// poll updates the fills of the active order, settling it once it is done.
+// The order's final status is returned.
+func (x *execution) poll(ctx context.Context) (*valr.OrderInfo, error) {
+	info, err := x.e.client.OrderStatus(ctx, &valr.OrderStatusRequest{
+		OrderID: x.active,
+		Pair:    x.pair,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	filled, quote, err := fills(info)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := filled.Cmp(x.activeFilled) != 0
+	x.activeFilled, x.activeFilledQuote = filled, quote
+
+	if info.Status.Done() {
+		x.filled.Add(x.filled, filled)
+		x.filledQuote.Add(x.filledQuote, quote)
+		x.active, x.activePrice = "", nil
+		x.activeFilled, x.activeFilledQuote = new(big.Rat), new(big.Rat)
+	}
+
+	if changed {
+		x.report()
+	}
+
+	return info, nil
+}
+
+// cancel cancels the active order and waits for it to be done, settling its
+// fills.
+func (x *execution) cancel(ctx context.Context) error {
+	if x.active == "" {
+		return nil
+	}
+
+	err := x.e.client.CancelOrder(ctx, &valr.CancelOrderRequest{
+		OrderID: x.active,
+		Pair:    x.pair,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Cancellation is processed asynchronously, so the order may fill in the
+	// meantime.
+	for {
+		info, err := x.poll(ctx)
+		if err != nil {
+			return err
+		}
+
+		if info.Status.Done() {
+			return nil
+		}
+
+		if err = sleep(ctx, x.e.interval); err != nil {
+			return err
+		}
+	}
+}
+
+// wait polls the active order until it is done or until returns true. The
+// order's final status is returned if it is done, or nil if until stopped the
+// wait.
+func (x *execution) wait(ctx context.Context,
+	until func() bool) (*valr.OrderInfo, error) {
+
+	for {
+		info, err := x.poll(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Status == valr.OrderStatusFailed {
+			return info, fmt.Errorf("%w: %s", ErrOrderFailed,
+				info.FailedReason)
+		}
+
+		if info.Status.Done() {
+			return info, nil
+		}
+
+		if err = sleep(ctx, x.e.interval); err != nil {
+			return nil, err
+		}
+
+		if until() {
+			return nil, nil
+		}
+	}
+}
+
+// finish cancels the active order, if any, and returns the progress of the
+// execution with err. The active order is cancelled with a fresh context if
+// ctx has been cancelled.
+func (x *execution) finish(ctx context.Context, err error) (Progress, error) {
+	if x.active != "" {
+		if ctx.Err() != nil {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(context.Background(),
+				cancelTimeout)
+			defer cancel()
+		}
+
+		if cancelErr := x.cancel(ctx); cancelErr != nil && err == nil {
+			err = fmt.Errorf("failed to cancel order: %w", cancelErr)
+		}
+	}
+
+	return x.progress(), err
+}
+
+// best returns the best price on one side of a pair's order book, or nil if
+// that side is empty.
+func (x *execution) best(ctx context.Context, side valr.Side) (*big.Rat,
+	error) {
+
+	book, err := x.e.client.OrderBook(ctx, x.pair)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := book.Bids
+	if side == valr.SideSell {
+		entries = book.Asks
+	}
+
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	price, err := decimal.ParsePositive(entries[0].Price)
+	if err != nil {
+		return nil, fmt.Errorf("invalid order book price: %w", err)
+	}
+
+	return price, nil
+}
+
+// better returns whether a is a better price than b for an order on side.
+func better(side valr.Side, a, b *big.Rat) bool {
+	if side == valr.SideBuy {
+		return a.Cmp(b) < 0
+	}
+
+	return a.Cmp(b) > 0
+}
+
+// fills returns the quantity filled by an order and the quote amount paid or
+// received for it.
+func fills(info *valr.OrderInfo) (*big.Rat, *big.Rat, error) {
+	original, ok := new(big.Rat).SetString(info.OriginalQuantity)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid original quantity %q",
+			info.OriginalQuantity)
+	}
+
+	remaining, ok := new(big.Rat).SetString(info.RemainingQuantity)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid remaining quantity %q",
+			info.RemainingQuantity)
+	}
+
+	filled := original.Sub(original, remaining)
+	if filled.Sign() == 0 {
+		return filled, new(big.Rat), nil
+	}
+
+	average, ok := new(big.Rat).SetString(info.AveragePrice)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid average price %q",
+			info.AveragePrice)
+	}
+
+	return filled, average.Mul(average, filled), nil
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
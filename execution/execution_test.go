@@ -0,0 +1,373 @@
+package execution_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/execution"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestExecutionTestSuite(t *testing.T) {
+	suite.Run(t, new(executionTestSuite))
+}
+
+type executionTestSuite struct {
+	suite.Suite
+	client   valr.Client
+	mu       sync.Mutex
+	progress []execution.Progress
+	server   *mock.Server
+}
+
+func (suite *executionTestSuite) SetupTest() {
+	suite.server = mock.NewServer()
+	suite.Require().NoError(suite.server.Exchange.SetBalance("ZAR", "10000"))
+	suite.Require().NoError(suite.server.Exchange.SetBalance("BTC", "1"))
+
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+	suite.progress = nil
+}
+
+func (suite *executionTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// executor returns an Executor which polls every millisecond and records its
+// progress.
+func (suite *executionTestSuite) executor() *execution.Executor {
+	return execution.New(suite.client,
+		execution.WithPollInterval(time.Millisecond),
+		execution.WithProgress(func(p execution.Progress) {
+			suite.mu.Lock()
+			defer suite.mu.Unlock()
+
+			suite.progress = append(suite.progress, p)
+		}))
+}
+
+// openOrders returns the prices of the account's open orders.
+func (suite *executionTestSuite) openOrders() []string {
+	var prices []string
+	for _, o := range suite.server.Exchange.OpenOrders() {
+		prices = append(prices, o.Price)
+	}
+
+	return prices
+}
+
+// eventually waits for the account's open orders to have the given prices.
+func (suite *executionTestSuite) eventually(prices ...string) {
+	suite.Require().Eventually(func() bool {
+		open := suite.openOrders()
+		if len(open) != len(prices) {
+			return false
+		}
+
+		for i := range prices {
+			if open[i] != prices[i] {
+				return false
+			}
+		}
+
+		return true
+	}, time.Second, time.Millisecond)
+}
+
+// requireRat asserts that a decimal has the expected value.
+func (suite *executionTestSuite) requireRat(expected string, actual *big.Rat) {
+	value, ok := new(big.Rat).SetString(expected)
+	suite.Require().True(ok)
+	suite.Require().Equal(0, value.Cmp(actual), "expected %s, got %s",
+		expected, actual.FloatString(8))
+}
+
+func (suite *executionTestSuite) TestTWAP() {
+	p, err := suite.executor().TWAP(context.TODO(), execution.TWAP{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+		Duration: 30 * time.Millisecond,
+		Slices:   3,
+	})
+	suite.Require().NoError(err)
+
+	// Each slice takes the best ask of 9000, the last slice taking the
+	// remainder left by rounding.
+	suite.requireRat("0.1", p.Filled)
+	suite.requireRat("9000", p.AveragePrice())
+	suite.requireRat("0", p.Remaining())
+	suite.Require().Len(p.Orders, 3)
+
+	for i, quantity := range []string{"0.03333333", "0.03333333",
+		"0.03333334"} {
+		info, err := suite.client.OrderStatus(context.TODO(),
+			&valr.OrderStatusRequest{OrderID: p.Orders[i], Pair: "BTCZAR"})
+		suite.Require().NoError(err)
+		suite.Require().Equal(quantity, info.OriginalQuantity)
+		suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	}
+
+	suite.Require().NotEmpty(suite.progress)
+	suite.requireRat("0.1", suite.progress[len(suite.progress)-1].Filled)
+}
+
+func (suite *executionTestSuite) TestTWAPLimitPrice() {
+	start := time.Now()
+	p, err := suite.executor().TWAP(context.TODO(), execution.TWAP{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.2",
+			Side:     valr.SideSell,
+		},
+		Duration:   30 * time.Millisecond,
+		LimitPrice: "8900",
+		Slices:     3,
+	})
+	suite.Require().NoError(err)
+	suite.Require().True(time.Since(start) >= 30*time.Millisecond)
+
+	// The bids are below the limit price, so each slice rests at the limit
+	// and is cancelled at the end of its interval, carrying its quantity into
+	// the next slice.
+	suite.requireRat("0", p.Filled)
+	suite.Require().Len(p.Orders, 3)
+	suite.Require().Empty(suite.openOrders())
+
+	info, err := suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{OrderID: p.Orders[2], Pair: "BTCZAR"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("0.2", info.OriginalQuantity)
+	suite.Require().Equal("8900", info.OriginalPrice)
+	suite.Require().Equal(valr.OrderStatusCancelled, info.Status)
+}
+
+func (suite *executionTestSuite) TestIceberg() {
+	done := make(chan struct{})
+
+	var (
+		p   execution.Progress
+		err error
+	)
+	go func() {
+		defer close(done)
+
+		p, err = suite.executor().Iceberg(context.TODO(), execution.Iceberg{
+			DisplayQuantity: "0.05",
+			Order: execution.Order{
+				Pair:     "BTCZAR",
+				Quantity: "0.12",
+				Side:     valr.SideBuy,
+			},
+			PostOnly: true,
+			Price:    "8900",
+		})
+	}()
+
+	// Only the display quantity is shown, and the next part of the order is
+	// placed once the last has filled.
+	for _, remaining := range []string{"0.05", "0.05", "0.02"} {
+		suite.Require().Eventually(func() bool {
+			open := suite.server.Exchange.OpenOrders()
+			return len(open) == 1 && open[0].RemainingQuantity == remaining
+		}, time.Second, time.Millisecond)
+
+		suite.Require().NoError(suite.server.Exchange.AddLiquidity("BTCZAR",
+			valr.SideSell, "8900", remaining))
+	}
+
+	<-done
+	suite.Require().NoError(err)
+	suite.requireRat("0.12", p.Filled)
+	suite.requireRat("8900", p.AveragePrice())
+	suite.Require().Len(p.Orders, 3)
+}
+
+func (suite *executionTestSuite) TestIcebergFails() {
+	p, err := suite.executor().Iceberg(context.TODO(), execution.Iceberg{
+		DisplayQuantity: "0.05",
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+		PostOnly: true,
+		Price:    "9000",
+	})
+	suite.Require().True(errors.Is(err, execution.ErrOrderFailed))
+	suite.Require().Contains(err.Error(), "Post only")
+	suite.Require().Len(p.Orders, 1)
+}
+
+func (suite *executionTestSuite) TestChase() {
+	done := make(chan struct{})
+
+	var (
+		p   execution.Progress
+		err error
+	)
+	go func() {
+		defer close(done)
+
+		p, err = suite.executor().Chase(context.TODO(), execution.Chase{
+			LimitPrice: "8900",
+			Order: execution.Order{
+				Pair:     "BTCZAR",
+				Quantity: "0.1",
+				Side:     valr.SideBuy,
+			},
+		})
+	}()
+
+	// The order joins the best bid, follows a better bid and stops at the
+	// limit price.
+	suite.eventually("8802")
+
+	suite.Require().NoError(suite.server.Exchange.AddLiquidity("BTCZAR",
+		valr.SideBuy, "8850", "0.1"))
+	suite.eventually("8850")
+
+	suite.Require().NoError(suite.server.Exchange.AddLiquidity("BTCZAR",
+		valr.SideBuy, "8950", "0.1"))
+	suite.eventually("8900")
+
+	// The better bids fill first.
+	suite.Require().NoError(suite.server.Exchange.AddLiquidity("BTCZAR",
+		valr.SideSell, "8900", "0.2"))
+
+	<-done
+	suite.Require().NoError(err)
+	suite.requireRat("0.1", p.Filled)
+	suite.requireRat("8900", p.AveragePrice())
+	suite.Require().Len(p.Orders, 3)
+	suite.Require().Empty(suite.openOrders())
+}
+
+func (suite *executionTestSuite) TestCancel() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	var err error
+	go func() {
+		defer close(done)
+
+		_, err = suite.executor().Chase(ctx, execution.Chase{
+			Order: execution.Order{
+				Pair:     "BTCZAR",
+				Quantity: "0.1",
+				Side:     valr.SideSell,
+			},
+		})
+	}()
+
+	suite.eventually("9000")
+	cancel()
+
+	<-done
+	suite.Require().True(errors.Is(err, context.Canceled))
+	suite.Require().Empty(suite.openOrders())
+}
+
+func (suite *executionTestSuite) TestInvalidOrders() {
+	_, err := suite.executor().TWAP(context.TODO(), execution.TWAP{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+	})
+	suite.Require().Error(err)
+
+	_, err = suite.executor().Iceberg(context.TODO(), execution.Iceberg{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "-1",
+			Side:     valr.SideBuy,
+		},
+	})
+	suite.Require().Error(err)
+
+	_, err = suite.executor().Chase(context.TODO(), execution.Chase{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     "HOLD",
+		},
+	})
+	suite.Require().True(errors.Is(err, valr.ErrInvalidSide))
+}
+
+func (suite *executionTestSuite) TestPrecision() {
+	registry := valr.NewPairRegistry([]valr.CurrencyPair{{
+		Active:            true,
+		BaseCurrency:      "BTC",
+		BaseDecimalPlaces: "4",
+		QuoteCurrency:     "ZAR",
+		Symbol:            "BTCZAR",
+		TickSize:          "1",
+	}}, nil)
+	executor := execution.New(suite.client,
+		execution.WithPairRegistry(registry),
+		execution.WithPollInterval(time.Millisecond))
+
+	p, err := executor.TWAP(context.TODO(), execution.TWAP{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+		Duration: 30 * time.Millisecond,
+		Slices:   3,
+	})
+	suite.Require().NoError(err)
+	suite.requireRat("0.1", p.Filled)
+
+	// Each slice is rounded down to the 4 decimal places of the pair.
+	for i, quantity := range []string{"0.0333", "0.0333", "0.0334"} {
+		info, err := suite.client.OrderStatus(context.TODO(),
+			&valr.OrderStatusRequest{OrderID: p.Orders[i], Pair: "BTCZAR"})
+		suite.Require().NoError(err)
+		suite.Require().Equal(quantity, info.OriginalQuantity)
+	}
+
+	_, err = executor.TWAP(context.TODO(), execution.TWAP{
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.00001",
+			Side:     valr.SideBuy,
+		},
+		Duration: 30 * time.Millisecond,
+		Slices:   3,
+	})
+	suite.Require().True(errors.Is(err, valr.ErrPrecision))
+
+	_, err = executor.Chase(context.TODO(), execution.Chase{
+		LimitPrice: "8900.5",
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+	})
+	suite.Require().True(errors.Is(err, valr.ErrPrecision))
+
+	_, err = executor.Iceberg(context.TODO(), execution.Iceberg{
+		DisplayQuantity: "0.00001",
+		Order: execution.Order{
+			Pair:     "BTCZAR",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		},
+		Price: "8900",
+	})
+	suite.Require().Error(err)
+	suite.Require().Empty(suite.openOrders())
+}
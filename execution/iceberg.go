@@ -0,0 +1,84 @@
+package execution
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// Iceberg describes an order of which only part is shown on the book at a
+// time.
+type Iceberg struct {
+	Order
+
+	// Price is the limit price of every order placed.
+	Price string
+
+	// DisplayQuantity is the largest quantity shown on the book at a time.
+	DisplayQuantity string
+
+	// PostOnly places every order as post-only, so that the iceberg only
+	// provides liquidity.
+	PostOnly bool
+}
+
+// Iceberg fills an order by placing a limit order for the display quantity,
+// and another each time the last has filled, until the total quantity has
+// been filled.
+//
+// The progress made is returned even if an error occurs.
+func (e *Executor) Iceberg(ctx context.Context, req Iceberg) (Progress,
+	error) {
+
+	x, err := e.newExecution(ctx, req.Order)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid iceberg: %w", err)
+	}
+
+	price, err := x.price(req.Price)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid iceberg: invalid price: %w",
+			err)
+	}
+
+	display, err := decimal.ParsePositive(req.DisplayQuantity)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid iceberg: invalid display "+
+			"quantity: %w", err)
+	}
+
+	if display = x.round(display); display.Sign() == 0 {
+		return Progress{}, fmt.Errorf("invalid iceberg: display quantity "+
+			"%s is below the precision of %s", req.DisplayQuantity, x.pair)
+	}
+
+	for {
+		quantity := x.round(x.remaining())
+		if quantity.Sign() <= 0 {
+			return x.progress(), nil
+		}
+
+		if quantity.Cmp(display) > 0 {
+			quantity = new(big.Rat).Set(display)
+		}
+
+		if err = x.place(ctx, price, quantity, req.PostOnly); err != nil {
+			return x.finish(ctx, fmt.Errorf("failed to place order: %w",
+				err))
+		}
+
+		info, err := x.wait(ctx, func() bool { return false })
+		if err != nil {
+			return x.finish(ctx, err)
+		}
+
+		// An order which was cancelled outside of the iceberg stops it.
+		if info.Status == valr.OrderStatusCancelled {
+			return x.progress(), fmt.Errorf("%w: order %s was cancelled",
+				ErrOrderFailed, info.ID)
+		}
+	}
+}
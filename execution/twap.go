@@ -0,0 +1,115 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// TWAP describes an order to be filled evenly over a period of time.
+type TWAP struct {
+	Order
+
+	// Duration is the period over which the order is filled, which is divided
+	// into Slices intervals.
+	Duration time.Duration
+	Slices   int
+
+	// LimitPrice, if set, is the worst price at which any slice may be
+	// filled.
+	LimitPrice string
+}
+
+// TWAP fills an order over a period of time. At the start of each interval a
+// limit order is placed at the best price on the opposite side of the book,
+// or at the limit price if that is worse, for the slice's share of the
+// quantity plus any quantity not filled by earlier slices. Orders which have
+// not filled by the end of their interval are cancelled.
+//
+// The progress made is returned even if an error occurs.
+func (e *Executor) TWAP(ctx context.Context, req TWAP) (Progress, error) {
+	x, err := e.newExecution(ctx, req.Order)
+	if err != nil {
+		return Progress{}, fmt.Errorf("invalid TWAP: %w", err)
+	}
+
+	if req.Slices <= 0 || req.Duration < 0 {
+		return Progress{}, errors.New("invalid TWAP: slices and duration " +
+			"must be positive")
+	}
+
+	var limit *big.Rat
+	if req.LimitPrice != "" {
+		if limit, err = x.price(req.LimitPrice); err != nil {
+			return Progress{}, fmt.Errorf("invalid TWAP: invalid limit "+
+				"price: %w", err)
+		}
+	}
+
+	start := time.Now()
+	interval := req.Duration / time.Duration(req.Slices)
+
+	for i := 1; i <= req.Slices; i++ {
+		end := start.Add(time.Duration(i) * interval)
+
+		// The target is rounded down to the pair's precision so that the
+		// final slice takes up any remainder.
+		target := new(big.Rat).Mul(x.quantity, big.NewRat(int64(i),
+			int64(req.Slices)))
+		if i < req.Slices {
+			target = x.round(target)
+		}
+
+		quantity := target.Sub(target, x.filled)
+		if quantity.Sign() > 0 {
+			if err = x.slice(ctx, quantity, limit, end); err != nil {
+				return x.finish(ctx, err)
+			}
+		}
+
+		if err = sleep(ctx, time.Until(end)); err != nil {
+			return x.finish(ctx, err)
+		}
+	}
+
+	return x.progress(), nil
+}
+
+// slice places a TWAP slice and waits until it is filled or end, cancelling
+// any remainder. Slices are skipped if the book is empty and there is no
+// limit price.
+func (x *execution) slice(ctx context.Context, quantity, limit *big.Rat,
+	end time.Time) error {
+
+	price, err := x.best(ctx, x.side.Opposite())
+	if err != nil {
+		return fmt.Errorf("failed to fetch order book: %w", err)
+	}
+
+	if price == nil || (limit != nil && better(x.side, limit, price)) {
+		price = limit
+	}
+
+	if price == nil {
+		return nil
+	}
+
+	if err = x.place(ctx, price, quantity, false); err != nil {
+		return fmt.Errorf("failed to place order: %w", err)
+	}
+
+	info, err := x.wait(ctx, func() bool {
+		return !time.Now().Before(end)
+	})
+	if err != nil || info != nil {
+		return err
+	}
+
+	if err = x.cancel(ctx); err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Package decimal parses and formats the decimal strings used by the VALR API.
+// Decimals are held as big.Rat so that no precision is lost in between.
+package decimal
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Places is the number of decimal places used by Format.
+const Places = 18
+
+// Parse parses a decimal string, treating an empty string as zero.
+func Parse(s string) (*big.Rat, error) {
+	if s == "" {
+		return new(big.Rat), nil
+	}
+
+	value, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	return value, nil
+}
+
+// ParsePositive parses a decimal string which must be greater than zero.
+func ParsePositive(s string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(s)
+	if !ok || value.Sign() <= 0 {
+		return nil, fmt.Errorf("%q must be a positive decimal", s)
+	}
+
+	return value, nil
+}
+
+// Format formats a decimal with up to Places decimal places and no trailing
+// zeros.
+func Format(value *big.Rat) string {
+	return FormatPlaces(value, Places)
+}
+
+// FormatPlaces formats a decimal rounded to the given number of decimal places,
+// trimming trailing zeros.
+func FormatPlaces(value *big.Rat, places int) string {
+	s := value.FloatString(places)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+
+	return s
+}
+
+// Truncate returns value rounded towards zero to the given number of decimal
+// places.
+func Truncate(value *big.Rat, places int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+
+	n := new(big.Int).Mul(value.Num(), scale)
+	n.Quo(n, value.Denom())
+
+	return new(big.Rat).SetFrac(n, scale)
+}
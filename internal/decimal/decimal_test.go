@@ -0,0 +1,51 @@
+package decimal_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/nickcorin/valr/internal/decimal"
+	"github.com/stretchr/testify/suite"
+)
+
+type decimalTestSuite struct {
+	suite.Suite
+}
+
+func TestDecimalTestSuite(t *testing.T) {
+	suite.Run(t, new(decimalTestSuite))
+}
+
+func (suite *decimalTestSuite) TestParse() {
+	value, err := decimal.Parse("")
+	suite.Require().NoError(err)
+	suite.Require().Equal(0, value.Sign())
+
+	value, err = decimal.Parse("-1.5")
+	suite.Require().NoError(err)
+	suite.Require().Equal("-3/2", value.RatString())
+
+	_, err = decimal.Parse("one")
+	suite.Require().Error(err)
+}
+
+func (suite *decimalTestSuite) TestParsePositive() {
+	value, err := decimal.ParsePositive("0.25")
+	suite.Require().NoError(err)
+	suite.Require().Equal("1/4", value.RatString())
+
+	for _, s := range []string{"", "0", "-1", "one"} {
+		_, err = decimal.ParsePositive(s)
+		suite.Require().Error(err, s)
+	}
+}
+
+func (suite *decimalTestSuite) TestFormat() {
+	suite.Require().Equal("0", decimal.Format(new(big.Rat)))
+	suite.Require().Equal("12", decimal.Format(big.NewRat(12, 1)))
+	suite.Require().Equal("0.333333333333333333",
+		decimal.Format(big.NewRat(1, 3)))
+	suite.Require().Equal("0.33333333",
+		decimal.FormatPlaces(big.NewRat(1, 3), 8))
+	suite.Require().Equal("1.5", decimal.FormatPlaces(big.NewRat(3, 2), 8))
+}
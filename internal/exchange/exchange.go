@@ -0,0 +1,394 @@
+// Package exchange implements the account keeping and order matching shared by
+// the simulated exchanges of the mock and paper packages.
+//
+// An Account holds balances, orders and trades. Orders are matched against
+// levels of liquidity supplied by the caller, which is the simulated order book
+// in the mock and the live order book when paper trading.
+package exchange
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// Reasons given for failed orders.
+const (
+	ReasonInsufficientBalance   = "Insufficient Balance"
+	ReasonInsufficientLiquidity = "Insufficient Liquidity"
+	ReasonPostOnly              = "Post only cancelled as it would have " +
+		"matched"
+)
+
+// TradeHistoryLimit is the maximum number of trades returned by TradeHistory.
+const TradeHistoryLimit = 100
+
+// BadRequest returns the error VALR responds with for an invalid request.
+func BadRequest(message string) *valr.Error {
+	return &valr.Error{StatusCode: http.StatusBadRequest, Code: -11,
+		Message: message}
+}
+
+// Balance is the balance of a currency.
+type Balance struct {
+	Available *big.Rat
+	Reserved  *big.Rat
+	UpdatedAt time.Time
+}
+
+// Fill is the execution of part of one of an account's orders.
+type Fill struct {
+	Order *Order
+
+	// Quantity is the amount of the base currency filled.
+	Quantity *big.Rat
+
+	// Price is the price of the fill.
+	Price *big.Rat
+
+	// Cost is the amount of the quote currency filled.
+	Cost *big.Rat
+
+	// Fee is the fee charged, in the currency received.
+	Fee *big.Rat
+
+	// Received is the amount received after fees.
+	Received *big.Rat
+
+	// Time is the time of the fill.
+	Time time.Time
+}
+
+// Account is a simulated exchange account. An Account is not safe for
+// concurrent use.
+type Account struct {
+	// OnFill, if set, is called with every fill of the account's orders after
+	// its balances have been settled.
+	OnFill func(Fill)
+
+	balances   map[string]*Balance
+	currencies []string
+	customers  map[string]string
+	lastOrder  int64
+	lastTrade  int64
+	now        func() time.Time
+	orders     map[string]*Order
+	trades     map[string][]valr.Trade
+}
+
+// NewAccount returns an empty Account which uses now to timestamp orders,
+// trades and balance updates.
+func NewAccount(now func() time.Time) *Account {
+	return &Account{
+		balances:  make(map[string]*Balance),
+		customers: make(map[string]string),
+		now:       now,
+		orders:    make(map[string]*Order),
+		trades:    make(map[string][]valr.Trade),
+	}
+}
+
+// SetClock sets the function used to timestamp orders, trades and balance
+// updates.
+func (a *Account) SetClock(now func() time.Time) {
+	a.now = now
+}
+
+// Now returns the current time of the account's clock.
+func (a *Account) Now() time.Time {
+	return a.now()
+}
+
+// Balance returns the balance of a currency, creating it if required.
+func (a *Account) Balance(currency string) *Balance {
+	b, ok := a.balances[currency]
+	if !ok {
+		b = &Balance{Available: new(big.Rat), Reserved: new(big.Rat)}
+		a.balances[currency] = b
+		a.currencies = append(a.currencies, currency)
+	}
+
+	return b
+}
+
+// Balances returns the account's balances in the order the currencies were
+// first used.
+func (a *Account) Balances() []valr.Balance {
+	balances := make([]valr.Balance, 0, len(a.currencies))
+	for _, currency := range a.currencies {
+		b := a.balances[currency]
+		balances = append(balances, valr.Balance{
+			Available: decimal.Format(b.Available),
+			Currency:  currency,
+			Reserved:  decimal.Format(b.Reserved),
+			Total: decimal.Format(new(big.Rat).Add(b.Available,
+				b.Reserved)),
+			UpdatedAt: b.UpdatedAt,
+		})
+	}
+
+	return balances
+}
+
+// AddTrade records a trade which was not made by the account's orders, such as
+// when loading trade history. Trades made afterwards are numbered after it.
+func (a *Account) AddTrade(t valr.Trade) {
+	pair := strings.ToUpper(t.CurrencyPair)
+	a.trades[pair] = append(a.trades[pair], t)
+	if t.ID > a.lastTrade {
+		a.lastTrade = t.ID
+	}
+}
+
+// TradeHistory returns the account's most recent trades for a pair, most
+// recent first.
+func (a *Account) TradeHistory(pair string) []valr.Trade {
+	trades := a.trades[strings.ToUpper(pair)]
+
+	history := make([]valr.Trade, 0, len(trades))
+	for i := len(trades) - 1; i >= 0 && len(history) < TradeHistoryLimit; i-- {
+		history = append(history, trades[i])
+	}
+
+	return history
+}
+
+// Register assigns an ID to a new order and records it as one of the
+// account's orders, so that it can be looked up.
+func (a *Account) Register(o *Order) error {
+	key := customerKey(o.Pair.Symbol, o.CustomerID)
+	if o.CustomerID != "" {
+		if _, ok := a.customers[key]; ok {
+			return BadRequest("Duplicate customer order ID")
+		}
+	}
+
+	a.lastOrder++
+	o.ID = fmt.Sprintf("00000000-0000-4000-8000-%012d", a.lastOrder)
+	o.CreatedAt = a.now()
+	o.UpdatedAt = o.CreatedAt
+
+	a.orders[o.ID] = o
+	if o.CustomerID != "" {
+		a.customers[key] = o.ID
+	}
+
+	return nil
+}
+
+// Lookup returns one of the account's orders by ID or customer order ID.
+func (a *Account) Lookup(pair, id, customerID string) (*Order, error) {
+	if id == "" {
+		id = a.customers[customerKey(pair, customerID)]
+	}
+
+	o, ok := a.orders[id]
+	if !ok || !strings.EqualFold(o.Pair.Symbol, pair) {
+		return nil, &valr.Error{StatusCode: http.StatusNotFound, Code: -1,
+			Message: "Order not found"}
+	}
+
+	return o, nil
+}
+
+// customerKey returns the key of a customer order ID, which is unique per
+// pair.
+func customerKey(pair, customerID string) string {
+	return strings.ToUpper(pair) + "/" + customerID
+}
+
+// Open returns the account's open orders for a pair, or for all pairs if pair
+// is empty, oldest first.
+func (a *Account) Open(pair string) []*Order {
+	var orders []*Order
+	for _, o := range a.orders {
+		if !o.Status.Done() && (pair == "" ||
+			strings.EqualFold(o.Pair.Symbol, pair)) {
+			orders = append(orders, o)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders
+}
+
+// Reserve moves the balance required by a limit order from available to
+// reserved, returning false if there is not enough available.
+func (a *Account) Reserve(o *Order) bool {
+	b, amount := a.reservation(o)
+	if b.Available.Cmp(amount) < 0 {
+		return false
+	}
+
+	b.Available.Sub(b.Available, amount)
+	b.Reserved.Add(b.Reserved, amount)
+	b.UpdatedAt = a.now()
+
+	return true
+}
+
+// Cancel cancels an open order, releasing the balance reserved for its
+// unfilled part. Cancelling an order which is already done has no effect.
+func (a *Account) Cancel(o *Order) {
+	if o.Status.Done() {
+		return
+	}
+
+	if o.Price != nil {
+		b, amount := a.reservation(o)
+		b.Reserved.Sub(b.Reserved, amount)
+		b.Available.Add(b.Available, amount)
+		b.UpdatedAt = a.now()
+	}
+
+	o.Status = valr.OrderStatusCancelled
+	o.UpdatedAt = a.now()
+}
+
+// reservation returns the balance and amount reserved for the unfilled part of
+// a limit order.
+func (a *Account) reservation(o *Order) (*Balance, *big.Rat) {
+	currency, amount := o.Pair.Base, new(big.Rat).Set(o.Remaining)
+	if o.Side == valr.SideBuy {
+		currency, amount = o.Pair.Quote, amount.Mul(amount, o.Price)
+	}
+
+	return a.Balance(string(currency)), amount
+}
+
+// Fail marks an order as failed.
+func (a *Account) Fail(o *Order, reason string) {
+	o.Status = valr.OrderStatusFailed
+	o.FailedReason = reason
+	o.UpdatedAt = a.now()
+}
+
+// Fill records the execution of quantity units of one of the account's orders
+// at price, settling its balances and charging a fee at rate on the amount
+// received. A nil rate charges no fee.
+func (a *Account) Fill(o *Order, quantity, price, rate *big.Rat) {
+	now := a.now()
+
+	cost := new(big.Rat).Mul(quantity, price)
+	o.Remaining.Sub(o.Remaining, quantity)
+	o.FilledQuote.Add(o.FilledQuote, cost)
+	o.UpdatedAt = now
+
+	o.Status = valr.OrderStatusPartiallyFilled
+	if o.Remaining.Sign() == 0 {
+		o.Status = valr.OrderStatusFilled
+	}
+
+	received := new(big.Rat).Set(quantity)
+	if o.Side == valr.SideSell {
+		received.Set(cost)
+	}
+
+	fee := new(big.Rat)
+	if rate != nil {
+		fee.Mul(received, rate)
+	}
+	received.Sub(received, fee)
+	o.Fee.Add(o.Fee, fee)
+
+	base := a.Balance(string(o.Pair.Base))
+	quote := a.Balance(string(o.Pair.Quote))
+
+	switch {
+	case o.Side == valr.SideBuy && o.Price != nil:
+		// The reservation was made at the order's price, any improvement is
+		// returned.
+		reserved := new(big.Rat).Mul(quantity, o.Price)
+		quote.Reserved.Sub(quote.Reserved, reserved)
+		quote.Available.Add(quote.Available, reserved.Sub(reserved, cost))
+		base.Available.Add(base.Available, received)
+	case o.Side == valr.SideBuy:
+		quote.Available.Sub(quote.Available, cost)
+		base.Available.Add(base.Available, received)
+	case o.Price != nil:
+		base.Reserved.Sub(base.Reserved, quantity)
+		quote.Available.Add(quote.Available, received)
+	default:
+		base.Available.Sub(base.Available, quantity)
+		quote.Available.Add(quote.Available, received)
+	}
+	base.UpdatedAt, quote.UpdatedAt = now, now
+
+	a.lastTrade++
+	a.trades[o.Pair.Symbol] = append(a.trades[o.Pair.Symbol], valr.Trade{
+		CurrencyPair: o.Pair.Symbol,
+		ID:           a.lastTrade,
+		Price:        decimal.Format(price),
+		Quantity:     decimal.Format(quantity),
+		Side:         o.Side,
+		TradedAt:     now,
+	})
+
+	if a.OnFill != nil {
+		a.OnFill(Fill{Order: o, Quantity: quantity, Price: price, Cost: cost,
+			Fee: fee, Received: received, Time: now})
+	}
+}
+
+// Take fills a limit order as a taker against the levels which cross its
+// price, charging a fee at rate.
+func (a *Account) Take(o *Order, levels []*Level, rate *big.Rat) {
+	Match(o, levels, func(quantity, price *big.Rat) {
+		a.Fill(o, quantity, price, rate)
+	})
+}
+
+// Execute fills a market or simple order against levels, charging a fee at
+// rate. Buys are limited by the quote currency available, and any quantity
+// which cannot be filled or afforded is cancelled.
+func (a *Account) Execute(o *Order, levels []*Level, rate *big.Rat) {
+	if o.Side == valr.SideSell &&
+		a.Balance(string(o.Pair.Base)).Available.Cmp(o.Quantity) < 0 {
+		a.Fail(o, ReasonInsufficientBalance)
+		return
+	}
+
+	var unaffordable bool
+	for _, l := range levels {
+		if o.Remaining.Sign() == 0 {
+			break
+		}
+
+		quantity := l.available(o.Remaining)
+		if o.Side == valr.SideBuy {
+			quote := a.Balance(string(o.Pair.Quote))
+			affordable := new(big.Rat).Quo(quote.Available, l.Price)
+			if affordable.Cmp(quantity) < 0 {
+				quantity, unaffordable = affordable, true
+			}
+			if quantity.Sign() == 0 {
+				break
+			}
+		}
+
+		a.Fill(o, quantity, l.Price, rate)
+		l.take(quantity)
+		if unaffordable {
+			break
+		}
+	}
+
+	switch {
+	case o.Remaining.Sign() == 0:
+	case o.Remaining.Cmp(o.Quantity) < 0:
+		o.Status = valr.OrderStatusCancelled
+	case unaffordable || (o.Side == valr.SideBuy && len(levels) > 0):
+		a.Fail(o, ReasonInsufficientBalance)
+	default:
+		a.Fail(o, ReasonInsufficientLiquidity)
+	}
+}
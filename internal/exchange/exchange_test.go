@@ -0,0 +1,143 @@
+package exchange_test
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/exchange"
+	"github.com/stretchr/testify/suite"
+)
+
+type exchangeTestSuite struct {
+	suite.Suite
+	account *exchange.Account
+	fills   []exchange.Fill
+	pair    valr.Pair
+}
+
+func TestExchangeTestSuite(t *testing.T) {
+	suite.Run(t, new(exchangeTestSuite))
+}
+
+func (suite *exchangeTestSuite) SetupTest() {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.account = exchange.NewAccount(func() time.Time { return now })
+	suite.fills = nil
+	suite.account.OnFill = func(f exchange.Fill) {
+		suite.fills = append(suite.fills, f)
+	}
+	suite.pair = valr.Pair{Base: "BTC", Quote: "ZAR", Symbol: "BTCZAR"}
+}
+
+// order registers a new account order.
+func (suite *exchangeTestSuite) order(side valr.Side, typ valr.OrderType,
+	price, quantity string) *exchange.Order {
+
+	o, err := exchange.NewOrder(suite.pair, side, typ, price, quantity, "")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.account.Register(o))
+	return o
+}
+
+// levels returns levels of liquidity, given as alternating prices and
+// quantities.
+func levels(values ...int64) []*exchange.Level {
+	var levels []*exchange.Level
+	for i := 0; i < len(values); i += 2 {
+		levels = append(levels, &exchange.Level{
+			Price:    big.NewRat(values[i], 1),
+			Quantity: big.NewRat(values[i+1], 1),
+		})
+	}
+
+	return levels
+}
+
+func (suite *exchangeTestSuite) balance(currency string) string {
+	b := suite.account.Balance(currency)
+	return b.Available.RatString() + "/" + b.Reserved.RatString()
+}
+
+func (suite *exchangeTestSuite) TestTakeAndCancel() {
+	suite.account.Balance("ZAR").Available.SetInt64(1000)
+
+	o := suite.order(valr.SideBuy, valr.OrderTypeLimit, "100", "5")
+	suite.Require().True(suite.account.Reserve(o))
+	suite.Require().Equal("500/500", suite.balance("ZAR"))
+
+	book := levels(90, 2, 100, 1, 110, 10)
+	suite.account.Take(o, book, big.NewRat(1, 100))
+
+	// Both crossing levels are taken at their own price, with the price
+	// improvement returned.
+	suite.Require().Equal(valr.OrderStatusPartiallyFilled, o.Status)
+	suite.Require().Equal("2", o.Remaining.RatString())
+	suite.Require().Equal("0", book[0].Quantity.RatString())
+	suite.Require().Equal("10", book[2].Quantity.RatString())
+	suite.Require().Len(suite.fills, 2)
+	suite.Require().Equal("3/100", o.Fee.RatString())
+	suite.Require().Equal("297/100/0", suite.balance("BTC"))
+	suite.Require().Equal("520/200", suite.balance("ZAR"))
+
+	suite.Require().Len(suite.account.Open(""), 1)
+	suite.account.Cancel(o)
+	suite.Require().Equal(valr.OrderStatusCancelled, o.Status)
+	suite.Require().Equal("720/0", suite.balance("ZAR"))
+	suite.Require().Empty(suite.account.Open("BTCZAR"))
+
+	info := o.Info()
+	suite.Require().Equal("93.333333333333333333", info.AveragePrice)
+	suite.Require().Equal("0.03", info.TotalFee)
+	suite.Require().Equal("BTC", info.FeeCurrency)
+	suite.Require().Len(suite.account.TradeHistory("btczar"), 2)
+}
+
+func (suite *exchangeTestSuite) TestExecute() {
+	suite.account.Balance("ZAR").Available.SetInt64(250)
+
+	// Buys are limited by the quote currency available.
+	o := suite.order(valr.SideBuy, valr.OrderTypeMarket, "", "5")
+	suite.account.Execute(o, levels(100, 2, 200, 2), nil)
+	suite.Require().Equal(valr.OrderStatusCancelled, o.Status)
+	suite.Require().Equal("9/4", o.Filled().RatString())
+	suite.Require().Equal("0/0", suite.balance("ZAR"))
+
+	o = suite.order(valr.SideSell, valr.OrderTypeMarket, "", "3")
+	suite.account.Execute(o, levels(100, 1), nil)
+	suite.Require().Equal(valr.OrderStatusFailed, o.Status)
+	suite.Require().Equal(exchange.ReasonInsufficientBalance,
+		o.FailedReason)
+
+	o = suite.order(valr.SideSell, valr.OrderTypeMarket, "", "1")
+	suite.account.Execute(o, nil, nil)
+	suite.Require().Equal(exchange.ReasonInsufficientLiquidity,
+		o.FailedReason)
+}
+
+func (suite *exchangeTestSuite) TestInvalidOrders() {
+	_, err := exchange.NewOrder(suite.pair, valr.Side("HOLD"),
+		valr.OrderTypeLimit, "1", "1", "")
+	suite.Require().Error(err)
+
+	_, err = exchange.NewOrder(suite.pair, valr.SideBuy,
+		valr.OrderTypeLimit, "0", "1", "")
+	suite.Require().Error(err)
+
+	o, err := exchange.NewOrder(suite.pair, valr.SideBuy,
+		valr.OrderTypeLimit, "1", "1", "a")
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.account.Register(o))
+
+	found, err := suite.account.Lookup("btczar", "", "a")
+	suite.Require().NoError(err)
+	suite.Require().Equal(o, found)
+
+	o, _ = exchange.NewOrder(suite.pair, valr.SideBuy, valr.OrderTypeLimit,
+		"1", "1", "a")
+	suite.Require().Error(suite.account.Register(o))
+
+	_, err = suite.account.Lookup("ETHZAR", "", "a")
+	suite.Require().Error(err)
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"math/big"
+)
+
+// Level is liquidity available at a price on one side of an order book.
+type Level struct {
+	Price    *big.Rat
+	Quantity *big.Rat
+
+	// Taken, if set, is called with the quantity taken from the level after
+	// the order taking it has been filled.
+	Taken func(quantity *big.Rat)
+}
+
+// available returns the quantity of the level which an order with remaining
+// quantity left to fill may take.
+func (l *Level) available(remaining *big.Rat) *big.Rat {
+	quantity := new(big.Rat).Set(remaining)
+	if l.Quantity.Cmp(quantity) < 0 {
+		quantity.Set(l.Quantity)
+	}
+
+	return quantity
+}
+
+// take removes quantity from the level.
+func (l *Level) take(quantity *big.Rat) {
+	l.Quantity.Sub(l.Quantity, quantity)
+	if l.Taken != nil {
+		l.Taken(quantity)
+	}
+}
+
+// Match takes liquidity for o from levels, best first, for as long as they
+// cross its price. fill is called with the quantity and price of each fill,
+// which is at the level's price, and must reduce the remaining quantity of o.
+func Match(o *Order, levels []*Level, fill func(quantity, price *big.Rat)) {
+	for _, l := range levels {
+		if o.Remaining.Sign() == 0 || !o.Crosses(l.Price) {
+			break
+		}
+
+		quantity := l.available(o.Remaining)
+		if quantity.Sign() == 0 {
+			continue
+		}
+
+		fill(quantity, l.Price)
+		l.take(quantity)
+	}
+}
+
+// Purchasable returns the quantity which amount of the quote currency buys
+// from asks.
+func Purchasable(asks []*Level, amount *big.Rat) *big.Rat {
+	quantity, remaining := new(big.Rat), new(big.Rat).Set(amount)
+	for _, l := range asks {
+		cost := new(big.Rat).Mul(l.Quantity, l.Price)
+		if cost.Cmp(remaining) >= 0 {
+			return quantity.Add(quantity,
+				new(big.Rat).Quo(remaining, l.Price))
+		}
+
+		quantity.Add(quantity, l.Quantity)
+		remaining.Sub(remaining, cost)
+	}
+
+	return quantity
+}
@@ -0,0 +1,143 @@
+package exchange
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// Order is an order placed on a simulated exchange.
+type Order struct {
+	CreatedAt    time.Time
+	CustomerID   string
+	FailedReason string
+	Fee          *big.Rat
+	FilledQuote  *big.Rat
+	ID           string
+	Pair         valr.Pair
+	Price        *big.Rat
+	Quantity     *big.Rat
+	Remaining    *big.Rat
+	Side         valr.Side
+	Status       valr.OrderStatus
+	Type         valr.OrderType
+	UpdatedAt    time.Time
+}
+
+// NewOrder validates the parameters of an order and returns it. Market and
+// simple orders have no price. The order must be registered with an Account
+// before it is filled.
+func NewOrder(pair valr.Pair, side valr.Side, typ valr.OrderType, price,
+	quantity, customerID string) (*Order, error) {
+
+	if err := side.Validate(); err != nil {
+		return nil, BadRequest(err.Error())
+	}
+
+	o := Order{
+		CustomerID:  customerID,
+		Fee:         new(big.Rat),
+		FilledQuote: new(big.Rat),
+		Pair:        pair,
+		Side:        side,
+		Status:      valr.OrderStatusPlaced,
+		Type:        typ,
+	}
+
+	var err error
+	if o.Quantity, err = decimal.ParsePositive(quantity); err != nil {
+		return nil, BadRequest("Invalid quantity: " + err.Error())
+	}
+	o.Remaining = new(big.Rat).Set(o.Quantity)
+
+	if typ != valr.OrderTypeMarket && typ != valr.OrderTypeSimple {
+		if o.Price, err = decimal.ParsePositive(price); err != nil {
+			return nil, BadRequest("Invalid price: " + err.Error())
+		}
+	}
+
+	return &o, nil
+}
+
+// Filled returns the quantity of the order which has been filled.
+func (o *Order) Filled() *big.Rat {
+	return new(big.Rat).Sub(o.Quantity, o.Remaining)
+}
+
+// FeeCurrency returns the currency in which fees are charged, which is the
+// currency received.
+func (o *Order) FeeCurrency() string {
+	if o.Side == valr.SideBuy {
+		return string(o.Pair.Base)
+	}
+
+	return string(o.Pair.Quote)
+}
+
+// Crosses returns whether the order would match liquidity at price on the
+// opposite side of the book. Orders without a price cross any price.
+func (o *Order) Crosses(price *big.Rat) bool {
+	switch {
+	case o.Price == nil:
+		return true
+	case o.Side == valr.SideBuy:
+		return o.Price.Cmp(price) >= 0
+	default:
+		return o.Price.Cmp(price) <= 0
+	}
+}
+
+// Info returns the status of the order.
+func (o *Order) Info() *valr.OrderInfo {
+	info := valr.OrderInfo{
+		AveragePrice:      "0",
+		CreatedAt:         o.CreatedAt,
+		CurrencyPair:      o.Pair.Symbol,
+		CustomerOrderID:   o.CustomerID,
+		FailedReason:      o.FailedReason,
+		FeeCurrency:       o.FeeCurrency(),
+		ID:                o.ID,
+		OriginalPrice:     "0",
+		OriginalQuantity:  decimal.Format(o.Quantity),
+		RemainingQuantity: decimal.Format(o.Remaining),
+		Side:              o.Side,
+		Status:            o.Status,
+		TotalFee:          decimal.Format(o.Fee),
+		Type:              o.Type,
+		UpdatedAt:         o.UpdatedAt,
+	}
+
+	if o.Price != nil {
+		info.OriginalPrice = decimal.Format(o.Price)
+	}
+
+	if filled := o.Filled(); filled.Sign() > 0 {
+		info.AveragePrice = decimal.Format(filled.Quo(o.FilledQuote, filled))
+	}
+
+	return &info
+}
+
+// OpenOrder returns the order as listed among open orders.
+func (o *Order) OpenOrder() valr.OpenOrder {
+	percentage := o.Filled()
+	percentage.Mul(percentage, big.NewRat(100, 1))
+	percentage.Quo(percentage, o.Quantity)
+
+	return valr.OpenOrder{
+		CreatedAt:         o.CreatedAt,
+		CurrencyPair:      o.Pair.Symbol,
+		CustomerOrderID:   o.CustomerID,
+		FilledPercentage:  percentage.FloatString(2),
+		ID:                o.ID,
+		OriginalQuantity:  decimal.Format(o.Quantity),
+		Price:             decimal.Format(o.Price),
+		RemainingQuantity: decimal.Format(o.Remaining),
+		Side:              o.Side,
+		Status:            o.Status,
+		Type:              o.Type,
+		UpdatedAt:         o.UpdatedAt,
+	}
+}
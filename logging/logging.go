@@ -0,0 +1,180 @@
+// Package logging provides a logrus integration for the VALR client, emitting
+// a structured entry for every call. Credentials and withdrawal addresses are
+// always redacted.
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/nickcorin/valr"
+	"github.com/sirupsen/logrus"
+)
+
+// Redacted replaces sensitive values in log entries.
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are never logged in the clear.
+var sensitiveHeaders = []string{
+	"X-VALR-API-KEY",
+	"X-VALR-SIGNATURE",
+}
+
+// sensitiveFields are JSON body fields which are never logged in the clear.
+var sensitiveFields = []string{
+	"address",
+}
+
+type config struct {
+	bodies       bool
+	errorLevel   logrus.Level
+	headers      bool
+	successLevel logrus.Level
+}
+
+// Option configures the logging middleware.
+type Option func(c *config)
+
+// WithLevels sets the levels at which successful and failed calls are logged.
+// By default successful calls are logged at DebugLevel and failed calls at
+// ErrorLevel.
+func WithLevels(success, failure logrus.Level) Option {
+	return func(c *config) {
+		c.successLevel = success
+		c.errorLevel = failure
+	}
+}
+
+// WithHeaders includes the request headers in each entry. API keys and
+// signatures are redacted.
+func WithHeaders() Option {
+	return func(c *config) {
+		c.headers = true
+	}
+}
+
+// WithBodies includes the request body in each entry. Withdrawal addresses
+// are redacted.
+func WithBodies() Option {
+	return func(c *config) {
+		c.bodies = true
+	}
+}
+
+// Middleware returns a valr.Middleware which logs every call made by the
+// client to logger.
+func Middleware(logger *logrus.Logger, opts ...Option) valr.Middleware {
+	cfg := config{
+		errorLevel:   logrus.ErrorLevel,
+		successLevel: logrus.DebugLevel,
+	}
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {
+			next(call)
+
+			level := cfg.successLevel
+			if call.Err != nil {
+				level = cfg.errorLevel
+			}
+
+			if !logger.IsLevelEnabled(level) {
+				return
+			}
+
+			logger.WithFields(fields(call, cfg)).Log(level, "valr request")
+		}
+	}
+}
+
+func fields(call *valr.Call, cfg config) logrus.Fields {
+	f := logrus.Fields{
+		"endpoint": call.Endpoint,
+		"latency":  call.Latency.Seconds(),
+		"method":   call.Request.Method,
+		"path":     call.Request.URL.Path,
+	}
+
+	if call.Pair != "" {
+		f["pair"] = call.Pair
+	}
+
+	if call.Currency != "" {
+		f["currency"] = call.Currency
+	}
+
+	if call.Response != nil {
+		f["status_code"] = call.Response.StatusCode
+	}
+
+	if call.Err != nil {
+		f[logrus.ErrorKey] = call.Err.Error()
+
+		var apiErr *valr.Error
+		if errors.As(call.Err, &apiErr) {
+			f["valr_error_code"] = apiErr.Code
+			f["valr_error_message"] = apiErr.Message
+		}
+	}
+
+	if cfg.headers {
+		f["headers"] = redactHeaders(call.Request.Header)
+	}
+
+	if cfg.bodies && call.Request.GetBody != nil {
+		if body, err := call.Request.GetBody(); err == nil {
+			data, err := ioutil.ReadAll(body)
+			body.Close()
+			if err == nil && len(data) > 0 {
+				f["body"] = redactBody(data)
+			}
+		}
+	}
+
+	return f
+}
+
+// redactHeaders returns a copy of h with sensitive values replaced.
+func redactHeaders(h http.Header) map[string]string {
+	redacted := make(map[string]string, len(h))
+	for k := range h {
+		redacted[k] = h.Get(k)
+	}
+
+	for _, k := range sensitiveHeaders {
+		if _, ok := redacted[http.CanonicalHeaderKey(k)]; ok {
+			redacted[http.CanonicalHeaderKey(k)] = Redacted
+		}
+	}
+
+	return redacted
+}
+
+// redactBody returns the JSON body with sensitive fields replaced. Bodies
+// which cannot be decoded are redacted entirely, as their contents cannot be
+// inspected.
+func redactBody(data []byte) string {
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return Redacted
+	}
+
+	for _, k := range sensitiveFields {
+		if _, ok := body[k]; ok {
+			body[k] = Redacted
+		}
+	}
+
+	redacted, err := json.Marshal(body)
+	if err != nil {
+		return Redacted
+	}
+
+	return string(redacted)
+}
@@ -0,0 +1,155 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/logging"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLoggingTestSuite(t *testing.T) {
+	suite.Run(t, new(loggingTestSuite))
+}
+
+type loggingTestSuite struct {
+	suite.Suite
+	hook   *test.Hook
+	logger *logrus.Logger
+	server *httptest.Server
+}
+
+func (suite *loggingTestSuite) SetupSuite() {
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/account/balances":
+				w.Write([]byte(`[]`))
+			case "/public/status":
+				w.Write([]byte(`{"status":"online"}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+}
+
+func (suite *loggingTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *loggingTestSuite) SetupTest() {
+	suite.logger, suite.hook = test.NewNullLogger()
+	suite.logger.SetLevel(logrus.TraceLevel)
+}
+
+func (suite *loggingTestSuite) TestEntry() {
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(logging.Middleware(suite.logger,
+			logging.WithHeaders())))
+
+	_, err := c.Balances(context.Background())
+	suite.Require().NoError(err)
+
+	entry := suite.hook.LastEntry()
+	suite.Require().NotNil(entry)
+	suite.Require().Equal(logrus.DebugLevel, entry.Level)
+	suite.Require().Equal("Balances", entry.Data["endpoint"])
+	suite.Require().Equal(http.MethodGet, entry.Data["method"])
+	suite.Require().Equal("/account/balances", entry.Data["path"])
+	suite.Require().Equal(http.StatusOK, entry.Data["status_code"])
+	suite.Require().Contains(entry.Data, "latency")
+
+	headers := entry.Data["headers"].(map[string]string)
+	suite.Require().Equal(logging.Redacted, headers["X-Valr-Api-Key"])
+	suite.Require().Equal(logging.Redacted, headers["X-Valr-Signature"])
+	suite.Require().NotEqual(logging.Redacted, headers["X-Valr-Timestamp"])
+
+	line, err := entry.String()
+	suite.Require().NoError(err)
+	suite.Require().NotContains(line, "test-key")
+}
+
+func (suite *loggingTestSuite) TestLevels() {
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(logging.Middleware(suite.logger,
+			logging.WithLevels(logrus.InfoLevel, logrus.WarnLevel))))
+
+	_, err := c.Status(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal(logrus.InfoLevel, suite.hook.LastEntry().Level)
+
+	// The test server does not serve this route.
+	_, err = c.OrderBook(context.Background(), "BTCZAR")
+	suite.Require().Error(err)
+
+	entry := suite.hook.LastEntry()
+	suite.Require().Equal(logrus.WarnLevel, entry.Level)
+	suite.Require().Equal(http.StatusNotFound, entry.Data["status_code"])
+	suite.Require().Contains(entry.Data, logrus.ErrorKey)
+}
+
+func (suite *loggingTestSuite) TestDisabledLevel() {
+	suite.logger.SetLevel(logrus.InfoLevel)
+
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(logging.Middleware(suite.logger)))
+
+	_, err := c.Status(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Empty(suite.hook.AllEntries())
+}
+
+func (suite *loggingTestSuite) TestBodyRedaction() {
+	mw := logging.Middleware(suite.logger, logging.WithBodies(),
+		logging.WithHeaders())
+
+	body := `{"amount":"0.1",` +
+		`"address":"0xA7Fae2Fd50886b962d46FF4280f595A3982aeAa5"}`
+	r, err := http.NewRequest(http.MethodPost,
+		"https://api.valr.com/v1/wallet/crypto/ETH/withdrawal",
+		bytes.NewBufferString(body))
+	suite.Require().NoError(err)
+	r.Header.Set("X-VALR-API-KEY", "my-api-key")
+	r.Header.Set("X-VALR-SIGNATURE", "my-signature")
+
+	mw(func(call *valr.Call) {
+		call.Response = &http.Response{StatusCode: http.StatusAccepted}
+		call.Latency = time.Millisecond
+	})(&valr.Call{Endpoint: "Withdraw", Currency: "ETH", Request: r})
+
+	entry := suite.hook.LastEntry()
+	suite.Require().NotNil(entry)
+	suite.Require().Equal("ETH", entry.Data["currency"])
+
+	logged := entry.Data["body"].(string)
+	suite.Require().Contains(logged, `"amount":"0.1"`)
+	suite.Require().Contains(logged, logging.Redacted)
+
+	line, err := entry.String()
+	suite.Require().NoError(err)
+	for _, secret := range []string{"0xA7Fae2Fd5", "my-api-key",
+		"my-signature"} {
+		suite.Require().False(strings.Contains(line, secret))
+	}
+}
+
+func (suite *loggingTestSuite) TestUndecodableBodyRedaction() {
+	mw := logging.Middleware(suite.logger, logging.WithBodies())
+
+	r, err := http.NewRequest(http.MethodPost, "https://api.valr.com/v1/x",
+		bytes.NewBufferString("address=0xA7Fae2Fd5"))
+	suite.Require().NoError(err)
+
+	mw(func(call *valr.Call) {})(&valr.Call{Endpoint: "X", Request: r})
+
+	suite.Require().Equal(logging.Redacted,
+		suite.hook.LastEntry().Data["body"])
+}
@@ -0,0 +1,125 @@
+// Package metrics provides a Prometheus integration for the VALR client,
+// recording per-endpoint request counts, status codes, VALR error codes,
+// latencies and rate limit waits.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics contains the collectors used to instrument a VALR client.
+type Metrics struct {
+	errors        *prometheus.CounterVec
+	latency       *prometheus.HistogramVec
+	rateLimitWait *prometheus.HistogramVec
+	requests      *prometheus.CounterVec
+}
+
+// New returns Metrics with all collectors registered on reg.
+func New(reg prometheus.Registerer) (*Metrics, error) {
+	m := Metrics{
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "valr",
+			Subsystem: "client",
+			Name:      "errors_total",
+			Help:      "Error responses received from VALR by error code.",
+		}, []string{"endpoint", "valr_code"}),
+
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "valr",
+			Subsystem: "client",
+			Name:      "request_duration_seconds",
+			Help:      "Request latency in seconds.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+
+		rateLimitWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "valr",
+			Subsystem: "client",
+			Name:      "rate_limit_wait_seconds",
+			Help:      "Time spent waiting on VALR rate limits in seconds.",
+			Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60},
+		}, []string{"endpoint"}),
+
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "valr",
+			Subsystem: "client",
+			Name:      "requests_total",
+			Help:      "Requests made to VALR by status code.",
+		}, []string{"endpoint", "code"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.errors, m.latency,
+		m.rateLimitWait, m.requests} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return &m, nil
+}
+
+// Middleware returns a valr.Middleware which records every call made by the
+// client.
+func (m *Metrics) Middleware() valr.Middleware {
+	return func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {
+			next(call)
+
+			// Calls which never reached VALR, e.g. due to a network failure,
+			// are recorded with the code "error".
+			code := "error"
+			if call.Response != nil {
+				code = strconv.Itoa(call.Response.StatusCode)
+				m.latency.WithLabelValues(call.Endpoint).Observe(
+					call.Latency.Seconds())
+			}
+			m.requests.WithLabelValues(call.Endpoint, code).Inc()
+
+			var apiErr *valr.Error
+			if errors.As(call.Err, &apiErr) {
+				m.errors.WithLabelValues(call.Endpoint,
+					strconv.Itoa(apiErr.Code)).Inc()
+			}
+
+			if call.Response != nil &&
+				call.Response.StatusCode == http.StatusTooManyRequests {
+				m.ObserveRateLimitWait(call.Endpoint,
+					retryAfter(call.Response))
+			}
+		}
+	}
+}
+
+// ObserveRateLimitWait records time spent waiting before calling an endpoint
+// due to rate limiting. Responses with a 429 status code are recorded
+// automatically using their Retry-After header, callers performing their own
+// client-side throttling may record their waits here.
+func (m *Metrics) ObserveRateLimitWait(endpoint string, d time.Duration) {
+	m.rateLimitWait.WithLabelValues(endpoint).Observe(d.Seconds())
+}
+
+// retryAfter parses the Retry-After header of a response, which may either be
+// a number of seconds or an HTTP date.
+func retryAfter(res *http.Response) time.Duration {
+	header := res.Header.Get("Retry-After")
+	if header == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(header); err == nil && time.Until(t) > 0 {
+		return time.Until(t)
+	}
+
+	return 0
+}
@@ -0,0 +1,118 @@
+package metrics_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMetricsTestSuite(t *testing.T) {
+	suite.Run(t, new(metricsTestSuite))
+}
+
+type metricsTestSuite struct {
+	suite.Suite
+	client   valr.Client
+	registry *prometheus.Registry
+	server   *httptest.Server
+}
+
+func (suite *metricsTestSuite) SetupTest() {
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/public/BTCZAR/orderbook":
+				w.Write([]byte(`{"Asks":[],"Bids":[]}`))
+			case "/public/FOOBAR/orderbook":
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-11,"message":"Invalid pair"}`))
+			default:
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+
+	suite.registry = prometheus.NewRegistry()
+	m, err := metrics.New(suite.registry)
+	suite.Require().NoError(err)
+
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(m.Middleware()))
+}
+
+func (suite *metricsTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *metricsTestSuite) TestRequests() {
+	for i := 0; i < 3; i++ {
+		_, err := suite.client.OrderBook(context.Background(), "BTCZAR")
+		suite.Require().NoError(err)
+	}
+
+	_, err := suite.client.OrderBook(context.Background(), "FOOBAR")
+	suite.Require().Error(err)
+
+	expected := `
+		# HELP valr_client_requests_total Requests made to VALR by status code.
+		# TYPE valr_client_requests_total counter
+		valr_client_requests_total{code="200",endpoint="OrderBook"} 3
+		valr_client_requests_total{code="400",endpoint="OrderBook"} 1
+	`
+	suite.Require().NoError(testutil.GatherAndCompare(suite.registry,
+		strings.NewReader(expected), "valr_client_requests_total"))
+
+	expected = "# HELP valr_client_errors_total Error responses received " +
+		"from VALR by error code.\n" +
+		"# TYPE valr_client_errors_total counter\n" +
+		`valr_client_errors_total{endpoint="OrderBook",valr_code="-11"} 1` +
+		"\n"
+	suite.Require().NoError(testutil.GatherAndCompare(suite.registry,
+		strings.NewReader(expected), "valr_client_errors_total"))
+
+	count, err := testutil.GatherAndCount(suite.registry,
+		"valr_client_request_duration_seconds")
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, count)
+}
+
+func (suite *metricsTestSuite) TestRateLimitWait() {
+	_, err := suite.client.Balances(context.Background())
+	suite.Require().Error(err)
+
+	const name = "valr_client_rate_limit_wait_seconds"
+	expected := "# HELP " + name + " Time spent waiting on VALR rate " +
+		"limits in seconds.\n" +
+		"# TYPE " + name + " histogram\n"
+
+	// The two second wait falls in every bucket from 2 upwards.
+	for _, bucket := range []struct {
+		le    string
+		count int
+	}{
+		{"0.1", 0}, {"0.5", 0}, {"1", 0}, {"2", 1}, {"5", 1}, {"10", 1},
+		{"30", 1}, {"60", 1}, {"+Inf", 1},
+	} {
+		expected += fmt.Sprintf("%s_bucket{endpoint=\"Balances\",le=%q} %d\n",
+			name, bucket.le, bucket.count)
+	}
+
+	expected += name + `_sum{endpoint="Balances"} 2` + "\n" +
+		name + `_count{endpoint="Balances"} 1` + "\n"
+	suite.Require().NoError(testutil.GatherAndCompare(suite.registry,
+		strings.NewReader(expected), name))
+}
+
+func (suite *metricsTestSuite) TestDuplicateRegistration() {
+	_, err := metrics.New(suite.registry)
+	suite.Require().Error(err)
+}
@@ -0,0 +1,88 @@
+package valr
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNoResponse is returned when the middleware chain completes a call without
+// setting either its Response or its Err.
+var ErrNoResponse = errors.New("valr: middleware returned without a " +
+	"response or error")
+
+// Call describes a single request made by the client to the VALR API. A Call
+// is passed through the middleware chain before and after the request is sent.
+type Call struct {
+	// Endpoint is the name of the client method which made the call, e.g.
+	// "Balances" or "OrderBook".
+	Endpoint string
+
+	// Pair is the currency pair the call relates to, if any.
+	Pair string
+
+	// Currency is the currency the call relates to, if any.
+	Currency string
+
+	// Request is the HTTP request being sent. Middleware may modify the
+	// request before passing the call on. Authentication headers are added
+	// once the call reaches the end of the chain.
+	Request *http.Request
+
+	// Response is the HTTP response received from VALR. It is nil until the
+	// request has been sent, or if sending the request failed. The body of a
+	// successful response is decoded by the client and must not be consumed
+	// by middleware.
+	Response *http.Response
+
+	// Err is the error encountered while performing the call. Non-2xx
+	// responses are decoded into an *Error.
+	Err error
+
+	// Latency is the time taken to send the request and receive the response
+	// headers.
+	Latency time.Duration
+}
+
+// Handler performs a Call, populating its Response, Err and Latency.
+type Handler func(call *Call)
+
+// Middleware wraps a Handler with additional behaviour such as logging,
+// metrics or policy checks. Middleware may short-circuit a call by setting
+// call.Err and returning without calling next. A call which is short-circuited
+// without an error fails with ErrNoResponse.
+type Middleware func(next Handler) Handler
+
+// Option configures a client during construction.
+type Option func(c *client)
+
+// WithMiddleware appends middleware to the client's chain. Middleware is
+// applied in the order given, the first being the outermost.
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *client) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithHTTPClient sets the http.Client used to perform requests.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBaseURL sets the base URL prepended to all request paths.
+func WithBaseURL(baseURL string) Option {
+	return func(c *client) {
+		c.baseURL = baseURL
+	}
+}
+
+// chain wraps h with the client's middleware.
+func (c *client) chain(h Handler) Handler {
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+
+	return h
+}
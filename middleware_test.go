@@ -0,0 +1,144 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMiddlewareTestSuite(t *testing.T) {
+	suite.Run(t, new(middlewareTestSuite))
+}
+
+type middlewareTestSuite struct {
+	suite.Suite
+	server *mock.Server
+}
+
+func (suite *middlewareTestSuite) SetupSuite() {
+	suite.server = mock.NewServer()
+}
+
+func (suite *middlewareTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *middlewareTestSuite) TestCallDetails() {
+	var calls []valr.Call
+	record := func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {
+			next(call)
+			calls = append(calls, *call)
+		}
+	}
+
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(record))
+
+	_, err := c.OrderBook(context.Background(), "BTCZAR")
+	suite.Require().NoError(err)
+
+	_, err = c.Balances(context.Background())
+	suite.Require().NoError(err)
+
+	suite.Require().Len(calls, 2)
+
+	suite.Require().Equal("OrderBook", calls[0].Endpoint)
+	suite.Require().Equal("/public/BTCZAR/orderbook",
+		calls[0].Request.URL.Path)
+	suite.Require().Equal(http.StatusOK, calls[0].Response.StatusCode)
+	suite.Require().NoError(calls[0].Err)
+	suite.Require().NotZero(calls[0].Latency)
+
+	suite.Require().Equal("Balances", calls[1].Endpoint)
+	suite.Require().NotEmpty(calls[1].Request.Header.Get("X-VALR-SIGNATURE"))
+}
+
+func (suite *middlewareTestSuite) TestOrder() {
+	var order []string
+	named := func(name string) valr.Middleware {
+		return func(next valr.Handler) valr.Handler {
+			return func(call *valr.Call) {
+				order = append(order, name+":before")
+				next(call)
+				order = append(order, name+":after")
+			}
+		}
+	}
+
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(named("outer"), named("inner")))
+
+	_, err := c.Status(context.Background())
+	suite.Require().NoError(err)
+	suite.Require().Equal([]string{"outer:before", "inner:before",
+		"inner:after", "outer:after"}, order)
+}
+
+func (suite *middlewareTestSuite) TestShortCircuit() {
+	errBlocked := errors.New("blocked by policy")
+	policy := func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {
+			if call.Endpoint == "Balances" {
+				call.Err = errBlocked
+				return
+			}
+			next(call)
+		}
+	}
+
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(policy))
+
+	_, err := c.Balances(context.Background())
+	suite.Require().True(errors.Is(err, errBlocked))
+
+	_, err = c.Currencies(context.Background())
+	suite.Require().NoError(err)
+}
+
+func (suite *middlewareTestSuite) TestNoResponse() {
+	drop := func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {}
+	}
+
+	c := valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(drop))
+
+	_, err := c.Balances(context.Background())
+	suite.Require().True(errors.Is(err, valr.ErrNoResponse))
+}
+
+func (suite *middlewareTestSuite) TestDecodedError() {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-11,"message":"Invalid currency pair"}`))
+		}))
+	defer server.Close()
+
+	var seen error
+	c := valr.NewClientForTesting(suite.T(), server.URL,
+		valr.WithMiddleware(func(next valr.Handler) valr.Handler {
+			return func(call *valr.Call) {
+				next(call)
+				seen = call.Err
+			}
+		}))
+
+	_, err := c.OrderBook(context.Background(), "FOOBAR")
+	suite.Require().Error(err)
+
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusBadRequest, apiErr.StatusCode)
+	suite.Require().Equal(-11, apiErr.Code)
+	suite.Require().Equal("Invalid currency pair", apiErr.Message)
+	suite.Require().Equal(apiErr, seen)
+}
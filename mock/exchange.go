@@ -0,0 +1,566 @@
+package mock
+
+import (
+	"fmt"
+	"io/fs"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+	"github.com/nickcorin/valr/internal/exchange"
+)
+
+// orderBookDepth is the number of price levels returned on each side of an
+// order book.
+const orderBookDepth = 20
+
+// Exchange is a simulated VALR exchange. It keeps account balances, matches
+// orders per pair with price-time priority and records the account's trades.
+// Orders placed through the Exchange belong to the account, while liquidity
+// added with AddLiquidity belongs to other participants. Fees are not charged.
+// An Exchange is safe for concurrent use.
+type Exchange struct {
+	mu sync.Mutex
+
+	account *exchange.Account
+	books   map[string]*book
+	now     func() time.Time
+	pairs   map[string]valr.Pair
+}
+
+// resting is an order resting on the book, which is either one of the
+// account's orders or liquidity of another participant.
+type resting struct {
+	*exchange.Order
+	owned bool
+}
+
+// book contains the resting orders of a pair, each side sorted by priority.
+type book struct {
+	asks []*resting
+	bids []*resting
+}
+
+// NewExchange returns an empty Exchange. Pairs must be added with AddPair
+// before they can be traded.
+func NewExchange() *Exchange {
+	return &Exchange{
+		account: exchange.NewAccount(time.Now),
+		books:   make(map[string]*book),
+		now:     time.Now,
+		pairs:   make(map[string]valr.Pair),
+	}
+}
+
+// Reset removes all pairs, balances, orders and trades from the exchange. The
+// clock is kept.
+func (e *Exchange) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.account = exchange.NewAccount(e.now)
+	e.books = make(map[string]*book)
+	e.pairs = make(map[string]valr.Pair)
+}
+
+// SetClock sets the function used to timestamp orders, trades and balance
+// updates.
+func (e *Exchange) SetClock(now func() time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.now = now
+	e.account.SetClock(now)
+}
+
+// Seed loads the pairs, balances, BTCZAR order book and trade history from the
+// mock's fixtures.
+func (e *Exchange) Seed() error {
+	return e.SeedFrom(defaultFixtures)
+}
+
+// SeedFrom loads the pairs, balances, order book and trade history from the
+// currencyPairs.json, accountBalances.json, orderBook.json and
+// tradehistory.json files in fsys. These use the same format as the responses
+// of the corresponding VALR endpoints.
+func (e *Exchange) SeedFrom(fsys fs.FS) error {
+	var pairs []valr.CurrencyPair
+	if err := readFixture(fsys, "currencyPairs.json", &pairs); err != nil {
+		return err
+	}
+
+	var balances []valr.Balance
+	if err := readFixture(fsys, "accountBalances.json", &balances); err != nil {
+		return err
+	}
+
+	var orderBook valr.OrderBook
+	if err := readFixture(fsys, "orderBook.json", &orderBook); err != nil {
+		return err
+	}
+
+	var trades []valr.Trade
+	if err := readFixture(fsys, "tradehistory.json", &trades); err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		e.AddPair(pair)
+	}
+
+	e.mu.Lock()
+	for _, b := range balances {
+		available, err := decimal.Parse(b.Available)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+
+		reserved, err := decimal.Parse(b.Reserved)
+		if err != nil {
+			e.mu.Unlock()
+			return err
+		}
+
+		bal := e.account.Balance(b.Currency)
+		bal.Available, bal.Reserved, bal.UpdatedAt = available, reserved,
+			b.UpdatedAt
+	}
+
+	// Trade history is listed most recent first.
+	for i := len(trades) - 1; i >= 0; i-- {
+		e.account.AddTrade(trades[i])
+	}
+	e.mu.Unlock()
+
+	// Aggregated levels are split back into their individual orders, with the
+	// last order taking whatever remains so that the level's total is exact.
+	for _, entry := range append(orderBook.Asks, orderBook.Bids...) {
+		remaining, err := decimal.Parse(entry.Quantity)
+		if err != nil {
+			return err
+		}
+
+		count := entry.OrderCount
+		if count < 1 {
+			count = 1
+		}
+
+		share := new(big.Rat).Quo(remaining, big.NewRat(int64(count), 1))
+		quantity := decimal.Format(share)
+
+		for i := 0; i < count; i++ {
+			if i == count-1 {
+				quantity = decimal.Format(remaining)
+			} else {
+				share, _ = decimal.Parse(quantity)
+				remaining.Sub(remaining, share)
+			}
+
+			if err := e.AddLiquidity(entry.CurrencyPair, entry.Side,
+				entry.Price, quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// AddPair makes a pair available for trading.
+func (e *Exchange) AddPair(pair valr.CurrencyPair) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	symbol := strings.ToUpper(pair.Symbol)
+	e.pairs[symbol] = valr.Pair{
+		Base:   valr.CurrencyCode(strings.ToUpper(pair.BaseCurrency)),
+		Quote:  valr.CurrencyCode(strings.ToUpper(pair.QuoteCurrency)),
+		Symbol: symbol,
+	}
+	if _, ok := e.books[symbol]; !ok {
+		e.books[symbol] = &book{}
+	}
+}
+
+// SetBalance sets the available balance of a currency.
+func (e *Exchange) SetBalance(currency, available string) error {
+	amount, err := decimal.Parse(available)
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bal := e.account.Balance(currency)
+	bal.Available = amount
+	bal.UpdatedAt = e.account.Now()
+
+	return nil
+}
+
+// AddLiquidity places a limit order on behalf of another participant. It is
+// matched against the account's resting orders like any other order and rests
+// on the book if it is not filled.
+func (e *Exchange) AddLiquidity(pair string, side valr.Side, price,
+	quantity string) error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, err := e.newOrder(pair, side, valr.OrderTypeLimit, price, quantity, "")
+	if err != nil {
+		return err
+	}
+
+	exchange.Match(o, e.levels(o.Pair.Symbol, side),
+		func(quantity, price *big.Rat) {
+			o.Remaining.Sub(o.Remaining, quantity)
+		})
+	e.settle(o.Pair.Symbol)
+
+	if o.Remaining.Sign() > 0 {
+		e.rest(&resting{Order: o})
+	}
+
+	return nil
+}
+
+// LimitOrder places a limit order for the account and returns its ID. Orders
+// without sufficient balance and post-only orders which would match are
+// accepted but fail, as they do on VALR.
+func (e *Exchange) LimitOrder(req *valr.LimitOrderRequest) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	typ := valr.OrderTypeLimit
+	if req.PostOnly {
+		typ = valr.OrderTypePostOnly
+	}
+
+	o, err := e.newOrder(req.Pair, req.Side, typ, req.Price, req.Quantity,
+		req.CustomerOrderID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = e.account.Register(o); err != nil {
+		return "", err
+	}
+
+	levels := e.levels(o.Pair.Symbol, o.Side)
+	if req.PostOnly && len(levels) > 0 && o.Crosses(levels[0].Price) {
+		e.account.Fail(o, exchange.ReasonPostOnly)
+		return o.ID, nil
+	}
+
+	if !e.account.Reserve(o) {
+		e.account.Fail(o, exchange.ReasonInsufficientBalance)
+		return o.ID, nil
+	}
+
+	e.account.Take(o, levels, nil)
+	e.settle(o.Pair.Symbol)
+
+	if o.Remaining.Sign() > 0 {
+		e.rest(&resting{Order: o, owned: true})
+	}
+
+	return o.ID, nil
+}
+
+// MarketOrder places a market order for the account and returns its ID. Any
+// quantity which cannot be filled from the book or afforded is cancelled.
+func (e *Exchange) MarketOrder(req *valr.MarketOrderRequest) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, err := e.newOrder(req.Pair, req.Side, valr.OrderTypeMarket, "",
+		req.BaseAmount, req.CustomerOrderID)
+	if err != nil {
+		return "", err
+	}
+
+	return e.execute(o)
+}
+
+// SimpleOrder places a simple buy or sell for the account and returns its ID.
+// Buys pay the amount in the pair's quote currency and sells pay it in the
+// base currency. Simple orders are filled from the book like market orders.
+func (e *Exchange) SimpleOrder(req *valr.SimpleOrderRequest) (string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p, err := e.pair(req.Pair)
+	if err != nil {
+		return "", err
+	}
+
+	pay := string(p.Base)
+	if req.Side == valr.SideBuy {
+		pay = string(p.Quote)
+	}
+
+	if !strings.EqualFold(req.QuoteCurrency, pay) {
+		return "", exchange.BadRequest("Pay in currency must be " + pay)
+	}
+
+	// Buys are converted into the quantity the amount would buy from the
+	// book, falling back to the amount itself if there is no liquidity so
+	// that the order fails.
+	quantity := req.Amount
+	if req.Side == valr.SideBuy {
+		amount, err := decimal.ParsePositive(req.Amount)
+		if err != nil {
+			return "", exchange.BadRequest("Invalid amount: " + err.Error())
+		}
+
+		asks := e.levels(p.Symbol, valr.SideBuy)
+		if base := exchange.Purchasable(asks, amount); base.Sign() > 0 {
+			quantity = decimal.Format(base)
+		}
+	}
+
+	o, err := e.newOrder(req.Pair, req.Side, valr.OrderTypeSimple, "",
+		quantity, "")
+	if err != nil {
+		return "", err
+	}
+
+	return e.execute(o)
+}
+
+// execute registers a market or simple order for the account and fills it
+// from the book. Any quantity which cannot be filled or afforded is cancelled.
+func (e *Exchange) execute(o *exchange.Order) (string, error) {
+	if err := e.account.Register(o); err != nil {
+		return "", err
+	}
+
+	e.account.Execute(o, e.levels(o.Pair.Symbol, o.Side), nil)
+	e.settle(o.Pair.Symbol)
+
+	return o.ID, nil
+}
+
+// CancelOrder cancels one of the account's open orders, releasing its
+// reserved balance. Cancelling an order which is already done has no effect.
+func (e *Exchange) CancelOrder(req *valr.CancelOrderRequest) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, err := e.lookup(req.Pair, req.OrderID, req.CustomerOrderID)
+	if err != nil {
+		return err
+	}
+
+	e.account.Cancel(o)
+	e.settle(o.Pair.Symbol)
+
+	return nil
+}
+
+// OrderStatus returns the current state of one of the account's orders.
+func (e *Exchange) OrderStatus(req *valr.OrderStatusRequest) (*valr.OrderInfo,
+	error) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o, err := e.lookup(req.Pair, req.OrderID, req.CustomerOrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Info(), nil
+}
+
+// OpenOrders returns the account's orders which are resting on the book,
+// oldest first.
+func (e *Exchange) OpenOrders() []valr.OpenOrder {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	open := e.account.Open("")
+
+	orders := make([]valr.OpenOrder, 0, len(open))
+	for _, o := range open {
+		orders = append(orders, o.OpenOrder())
+	}
+
+	return orders
+}
+
+// Balances returns the account's balances.
+func (e *Exchange) Balances() []valr.Balance {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.account.Balances()
+}
+
+// TradeHistory returns the account's most recent trades for a pair, most
+// recent first.
+func (e *Exchange) TradeHistory(pair string) []valr.Trade {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.account.TradeHistory(pair)
+}
+
+// OrderBook returns the aggregated order book of a pair.
+func (e *Exchange) OrderBook(pair string) (*valr.OrderBook, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	p, err := e.pair(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	b := e.books[p.Symbol]
+
+	return &valr.OrderBook{
+		Asks: aggregate(b.asks),
+		Bids: aggregate(b.bids),
+	}, nil
+}
+
+// aggregate combines orders of the same price into order book entries.
+func aggregate(orders []*resting) []valr.OrderBookEntry {
+	entries := []valr.OrderBookEntry{}
+
+	var quantity *big.Rat
+	for i, o := range orders {
+		if i == 0 || o.Price.Cmp(orders[i-1].Price) != 0 {
+			if len(entries) == orderBookDepth {
+				break
+			}
+
+			quantity = new(big.Rat)
+			entries = append(entries, valr.OrderBookEntry{
+				CurrencyPair: o.Pair.Symbol,
+				Price:        decimal.Format(o.Price),
+				Side:         o.Side,
+			})
+		}
+
+		entry := &entries[len(entries)-1]
+		quantity.Add(quantity, o.Remaining)
+		entry.OrderCount++
+		entry.Quantity = decimal.Format(quantity)
+	}
+
+	return entries
+}
+
+// newOrder validates the parameters of an order for a tradable pair and
+// returns it.
+func (e *Exchange) newOrder(pair string, side valr.Side, typ valr.OrderType,
+	price, quantity, customerID string) (*exchange.Order, error) {
+
+	p, err := e.pair(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	return exchange.NewOrder(p, side, typ, price, quantity, customerID)
+}
+
+// lookup returns one of the account's orders by ID or customer order ID.
+func (e *Exchange) lookup(pair, id, customerID string) (*exchange.Order,
+	error) {
+
+	p, err := e.pair(pair)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.account.Lookup(p.Symbol, id, customerID)
+}
+
+// pair returns a tradable pair by symbol.
+func (e *Exchange) pair(symbol string) (valr.Pair, error) {
+	p, ok := e.pairs[strings.ToUpper(symbol)]
+	if !ok {
+		return valr.Pair{}, exchange.BadRequest(
+			fmt.Sprintf("Invalid currency pair %q", symbol))
+	}
+
+	return p, nil
+}
+
+// levels returns the resting orders which an order on side of a pair takes
+// liquidity from, best first. Taking from a level fills the resting order at
+// its own price, settling the account's balances if it is one of its orders.
+func (e *Exchange) levels(pair string, side valr.Side) []*exchange.Level {
+	b := e.books[pair]
+
+	opposite := b.bids
+	if side == valr.SideBuy {
+		opposite = b.asks
+	}
+
+	levels := make([]*exchange.Level, 0, len(opposite))
+	for _, r := range opposite {
+		r := r
+		levels = append(levels, &exchange.Level{
+			Price:    r.Price,
+			Quantity: new(big.Rat).Set(r.Remaining),
+			Taken: func(quantity *big.Rat) {
+				if r.owned {
+					e.account.Fill(r.Order, quantity, r.Price, nil)
+				} else {
+					r.Remaining.Sub(r.Remaining, quantity)
+				}
+			},
+		})
+	}
+
+	return levels
+}
+
+// settle removes orders which are no longer open from a pair's book.
+func (e *Exchange) settle(pair string) {
+	b := e.books[pair]
+	b.asks, b.bids = open(b.asks), open(b.bids)
+}
+
+// open returns the orders which have quantity remaining and have not been
+// cancelled or failed.
+func open(orders []*resting) []*resting {
+	kept := orders[:0]
+	for _, r := range orders {
+		if r.Remaining.Sign() > 0 && (!r.owned || !r.Status.Done()) {
+			kept = append(kept, r)
+		}
+	}
+
+	return kept
+}
+
+// rest adds an order to its side of the book behind all orders of the same or
+// better price.
+func (e *Exchange) rest(r *resting) {
+	b := e.books[r.Pair.Symbol]
+
+	side := &b.asks
+	better := func(p *big.Rat) bool { return p.Cmp(r.Price) <= 0 }
+	if r.Side == valr.SideBuy {
+		side = &b.bids
+		better = func(p *big.Rat) bool { return p.Cmp(r.Price) >= 0 }
+	}
+
+	i := sort.Search(len(*side), func(i int) bool {
+		return !better((*side)[i].Price)
+	})
+
+	*side = append(*side, nil)
+	copy((*side)[i+1:], (*side)[i:])
+	(*side)[i] = r
+}
@@ -0,0 +1,250 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestExchangeTestSuite(t *testing.T) {
+	suite.Run(t, new(exchangeTestSuite))
+}
+
+// exchangeTestSuite places orders end to end against the mock server's
+// simulated exchange, which is reseeded from the fixtures before every test.
+type exchangeTestSuite struct {
+	suite.Suite
+	client valr.Client
+	server *mock.Server
+}
+
+func (suite *exchangeTestSuite) SetupTest() {
+	suite.server = mock.NewServer()
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+}
+
+func (suite *exchangeTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *exchangeTestSuite) balance(currency string) valr.Balance {
+	balances, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	for _, b := range balances {
+		if b.Currency == currency {
+			return b
+		}
+	}
+
+	suite.FailNow("balance not found", currency)
+	return valr.Balance{}
+}
+
+func (suite *exchangeTestSuite) status(id string) *valr.OrderInfo {
+	info, err := suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{OrderID: id, Pair: "BTCZAR"})
+	suite.Require().NoError(err)
+
+	return info
+}
+
+func (suite *exchangeTestSuite) TestLimitOrderMatches() {
+	suite.Require().NoError(suite.server.Exchange.SetBalance("ZAR", "10000"))
+
+	id, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "BTCZAR",
+			Price:    "10000",
+			Quantity: "0.2",
+			Side:     valr.SideBuy,
+		})
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(id)
+
+	info := suite.status(id)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal("9495", info.AveragePrice)
+	suite.Require().Equal("0", info.RemainingQuantity)
+
+	// The order fills at the resting prices, so the unused reservation is
+	// returned.
+	suite.Require().Equal("8101", suite.balance("ZAR").Available)
+	suite.Require().Equal("0.244511644725", suite.balance("BTC").Available)
+
+	trades, err := suite.client.TradeHistory(context.TODO(), "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Equal("10000", trades[0].Price)
+	suite.Require().Equal("0.099", trades[0].Quantity)
+	suite.Require().Equal("9000", trades[1].Price)
+	suite.Require().Equal("0.101", trades[1].Quantity)
+	suite.Require().Equal(valr.SideBuy, trades[0].Side)
+
+	book, err := suite.client.OrderBook(context.TODO(), "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderBookEntry{
+		CurrencyPair: "BTCZAR",
+		OrderCount:   3,
+		Price:        "10000",
+		Quantity:     "0.694789",
+		Side:         valr.SideSell,
+	}, book.Asks[0])
+}
+
+func (suite *exchangeTestSuite) TestRestingOrderCanBeCancelled() {
+	id, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			CustomerOrderID: "my-order",
+			Pair:            "BTCZAR",
+			Price:           "20000",
+			Quantity:        "0.01",
+			Side:            valr.SideSell,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal("0.02", suite.balance("BTC").Reserved)
+
+	open, err := suite.client.OpenOrders(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(open, 1)
+	suite.Require().Equal(id, open[0].ID)
+	suite.Require().Equal("my-order", open[0].CustomerOrderID)
+	suite.Require().Equal(valr.OrderStatusPlaced, open[0].Status)
+
+	err = suite.client.CancelOrder(context.TODO(), &valr.CancelOrderRequest{
+		CustomerOrderID: "my-order",
+		Pair:            "BTCZAR",
+	})
+	suite.Require().NoError(err)
+
+	info, err := suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{CustomerOrderID: "my-order", Pair: "BTCZAR"})
+	suite.Require().NoError(err)
+	suite.Require().Equal(id, info.ID)
+	suite.Require().Equal(valr.OrderStatusCancelled, info.Status)
+	suite.Require().Equal("0.01", suite.balance("BTC").Reserved)
+
+	open, err = suite.client.OpenOrders(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Empty(open)
+}
+
+func (suite *exchangeTestSuite) TestFailedOrders() {
+	suite.Require().NoError(suite.server.Exchange.SetBalance("ZAR", "1"))
+
+	id, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "BTCZAR",
+			Price:    "100",
+			Quantity: "1",
+			Side:     valr.SideBuy,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderStatusFailed, suite.status(id).Status)
+	suite.Require().Equal("Insufficient Balance", suite.status(id).FailedReason)
+
+	id, err = suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "BTCZAR",
+			PostOnly: true,
+			Price:    "8000",
+			Quantity: "0.01",
+			Side:     valr.SideSell,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderStatusFailed, suite.status(id).Status)
+	suite.Require().Equal(valr.OrderTypePostOnly, suite.status(id).Type)
+}
+
+func (suite *exchangeTestSuite) TestMarketOrder() {
+	id, err := suite.client.MarketOrder(context.TODO(),
+		&valr.MarketOrderRequest{
+			BaseAmount: "0.04",
+			Pair:       "BTCZAR",
+			Side:       valr.SideSell,
+		})
+	suite.Require().NoError(err)
+
+	info := suite.status(id)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal("8802", info.AveragePrice)
+	suite.Require().Equal("398.75108319597", suite.balance("ZAR").Available)
+	suite.Require().Equal("0.004511644725", suite.balance("BTC").Available)
+}
+
+func (suite *exchangeTestSuite) TestSimpleOrder() {
+	id, err := suite.client.SimpleOrder(context.TODO(),
+		&valr.SimpleOrderRequest{
+			Amount:        "45",
+			Pair:          "BTCZAR",
+			QuoteCurrency: "ZAR",
+			Side:          valr.SideBuy,
+		})
+	suite.Require().NoError(err)
+
+	info := suite.status(id)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal(valr.OrderTypeSimple, info.Type)
+	suite.Require().Equal("9000", info.AveragePrice)
+	suite.Require().Equal("0.005", info.OriginalQuantity)
+	suite.Require().Equal("1.67108319597", suite.balance("ZAR").Available)
+	suite.Require().Equal("0.049511644725", suite.balance("BTC").Available)
+
+	_, err = suite.client.SimpleOrder(context.TODO(),
+		&valr.SimpleOrderRequest{
+			Amount:        "0.01",
+			Pair:          "BTCZAR",
+			QuoteCurrency: "ZAR",
+			Side:          valr.SideSell,
+		})
+
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func (suite *exchangeTestSuite) TestLiquidityFillsRestingOrder() {
+	id, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "BTCZAR",
+			Price:    "8900",
+			Quantity: "0.001",
+			Side:     valr.SideBuy,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderStatusPlaced, suite.status(id).Status)
+
+	suite.Require().NoError(suite.server.Exchange.AddLiquidity("BTCZAR",
+		valr.SideSell, "8850", "0.0004"))
+
+	info := suite.status(id)
+	suite.Require().Equal(valr.OrderStatusPartiallyFilled, info.Status)
+	suite.Require().Equal("0.0006", info.RemainingQuantity)
+	suite.Require().Equal("8900", info.AveragePrice)
+
+	// The filled part of the reservation is spent, the rest remains reserved.
+	suite.Require().Equal("4205.76325067", suite.balance("ZAR").Reserved)
+}
+
+func (suite *exchangeTestSuite) TestInvalidOrders() {
+	_, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "NOPE",
+			Price:    "1",
+			Quantity: "1",
+			Side:     valr.SideBuy,
+		})
+
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusBadRequest, apiErr.StatusCode)
+
+	_, err = suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{OrderID: "missing", Pair: "BTCZAR"})
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusNotFound, apiErr.StatusCode)
+}
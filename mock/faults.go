@@ -0,0 +1,235 @@
+package mock
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nickcorin/valr"
+)
+
+// AllRoutes may be used in place of a route name to apply a fault to every
+// route. Faults added for a specific route take precedence.
+const AllRoutes = "*"
+
+// Fault describes how the server misbehaves when a route is called. A Fault
+// with only Latency or Jitter set delays the normal response.
+type Fault struct {
+	// Latency delays the response by a fixed duration.
+	Latency time.Duration
+
+	// Jitter delays the response by a random duration of up to Jitter on top
+	// of Latency.
+	Jitter time.Duration
+
+	// StatusCode responds with a VALR error body with this status code
+	// instead of calling the route.
+	StatusCode int
+
+	// Code and Message are included in the error body. Message defaults to
+	// the status text of StatusCode.
+	Code    int
+	Message string
+
+	// RetryAfter sets the Retry-After header, rounded up to whole seconds.
+	RetryAfter time.Duration
+
+	// Truncate cuts the route's response body in half.
+	Truncate bool
+
+	// Malformed replaces the route's response body with invalid JSON.
+	Malformed bool
+
+	// Drop closes the connection without writing a response. Note that Go's
+	// transport retries idempotent requests once if a reused connection is
+	// closed, so a single dropped call may go unnoticed by the client.
+	Drop bool
+
+	// Times is the number of calls the fault applies to, after which it is
+	// removed. Zero applies the fault to every call.
+	Times int
+}
+
+// RateLimited returns a Fault which responds with 429 Too Many Requests and a
+// Retry-After header.
+func RateLimited(retryAfter time.Duration) Fault {
+	return Fault{
+		StatusCode: http.StatusTooManyRequests,
+		Code:       -1,
+		Message:    "Rate limit exceeded",
+		RetryAfter: retryAfter,
+	}
+}
+
+// delay returns how long the response should be delayed.
+func (f Fault) delay() time.Duration {
+	d := f.Latency
+	if f.Jitter > 0 {
+		d += time.Duration(rand.Int63n(int64(f.Jitter)))
+	}
+
+	return d
+}
+
+// faults holds the faults configured for each route and counts the calls made
+// to each route.
+type faults struct {
+	mu     sync.Mutex
+	calls  map[string]int
+	queues map[string][]*Fault
+}
+
+func newFaults() *faults {
+	return &faults{
+		calls:  make(map[string]int),
+		queues: make(map[string][]*Fault),
+	}
+}
+
+// add appends a fault to the route's queue. Faults are applied in the order
+// in which they were added, each once its predecessors have been used up.
+func (f *faults) add(route string, fault Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.queues[route] = append(f.queues[route], &fault)
+}
+
+// clear removes all faults and resets the call counts.
+func (f *faults) clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = make(map[string]int)
+	f.queues = make(map[string][]*Fault)
+}
+
+// count returns the number of calls made to a route.
+func (f *faults) count(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if route == AllRoutes {
+		var total int
+		for _, n := range f.calls {
+			total += n
+		}
+		return total
+	}
+
+	return f.calls[route]
+}
+
+// next records a call to the route and returns the fault to apply to it, if
+// any.
+func (f *faults) next(route string) (Fault, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls[route]++
+
+	for _, name := range []string{route, AllRoutes} {
+		queue := f.queues[name]
+		if len(queue) == 0 {
+			continue
+		}
+
+		fault := queue[0]
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				f.queues[name] = queue[1:]
+			}
+		}
+
+		return *fault, true
+	}
+
+	return Fault{}, false
+}
+
+// middleware applies the configured faults to every named route.
+func (f *faults) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var route string
+		if current := mux.CurrentRoute(r); current != nil {
+			route = current.GetName()
+		}
+
+		fault, ok := f.next(route)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if d := fault.delay(); d > 0 {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		switch {
+		case fault.Drop:
+			drop(w)
+		case fault.StatusCode != 0:
+			writeFault(w, fault)
+		case fault.Truncate || fault.Malformed:
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+
+			body := rec.Body.Bytes()
+			if fault.Truncate {
+				body = body[:len(body)/2]
+			} else {
+				body = []byte(`{"malformed": [`)
+			}
+
+			for k, v := range rec.Header() {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.Code)
+			w.Write(body)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// writeFault writes the VALR error response described by fault.
+func writeFault(w http.ResponseWriter, fault Fault) {
+	if fault.RetryAfter > 0 {
+		seconds := (fault.RetryAfter + time.Second - 1) / time.Second
+		w.Header().Set("Retry-After", strconv.Itoa(int(seconds)))
+	}
+
+	message := fault.Message
+	if message == "" {
+		message = http.StatusText(fault.StatusCode)
+	}
+
+	writeJSON(w, fault.StatusCode, &valr.Error{Code: fault.Code,
+		Message: message})
+}
+
+// drop closes the underlying connection without writing a response.
+func drop(w http.ResponseWriter) {
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		serverError(w, errors.New("connection cannot be hijacked"))
+		return
+	}
+
+	conn, _, err := hijacker.Hijack()
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+	conn.Close()
+}
@@ -0,0 +1,150 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestFaultsTestSuite(t *testing.T) {
+	suite.Run(t, new(faultsTestSuite))
+}
+
+type faultsTestSuite struct {
+	suite.Suite
+	client valr.Client
+	server *mock.Server
+}
+
+func (suite *faultsTestSuite) SetupTest() {
+	e := mock.NewExchange()
+	suite.Require().NoError(e.SetBalance("ZAR", "100"))
+
+	suite.server = mock.NewServerWithExchange(e)
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+}
+
+func (suite *faultsTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *faultsTestSuite) TestFailNext() {
+	suite.server.FailNext("Balances", 2, mock.Fault{
+		StatusCode: http.StatusInternalServerError,
+		Code:       -99,
+		Message:    "Something went wrong",
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.client.Balances(context.TODO())
+
+		var apiErr *valr.Error
+		suite.Require().True(errors.As(err, &apiErr))
+		suite.Require().Equal(http.StatusInternalServerError,
+			apiErr.StatusCode)
+		suite.Require().Equal(-99, apiErr.Code)
+		suite.Require().Equal("Something went wrong", apiErr.Message)
+	}
+
+	balances, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal("100", balances[0].Available)
+	suite.Require().Equal(3, suite.server.Calls("Balances"))
+}
+
+func (suite *faultsTestSuite) TestFailNextZero() {
+	// Failing the next zero calls injects nothing, rather than a fault on
+	// every call.
+	for _, n := range []int{0, -1} {
+		suite.server.FailNext("Balances", n, mock.Fault{
+			StatusCode: http.StatusInternalServerError,
+		})
+	}
+
+	_, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+}
+
+func (suite *faultsTestSuite) TestFaultsAreAppliedInTurn() {
+	suite.server.FailNext("Balances", 1, mock.Fault{
+		StatusCode: http.StatusBadGateway,
+	})
+	suite.server.AddFault("Balances", mock.Fault{
+		StatusCode: http.StatusServiceUnavailable,
+	})
+
+	var apiErr *valr.Error
+	for _, status := range []int{http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable} {
+		_, err := suite.client.Balances(context.TODO())
+		suite.Require().True(errors.As(err, &apiErr))
+		suite.Require().Equal(status, apiErr.StatusCode)
+	}
+
+	suite.server.ClearFaults()
+	_, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(1, suite.server.Calls("Balances"))
+}
+
+func (suite *faultsTestSuite) TestRateLimited() {
+	suite.server.AddFault(mock.AllRoutes,
+		mock.RateLimited(1500*time.Millisecond))
+
+	res, err := http.Get(suite.server.URL + "/orders/open")
+	suite.Require().NoError(err)
+	defer res.Body.Close()
+
+	suite.Require().Equal(http.StatusTooManyRequests, res.StatusCode)
+	suite.Require().Equal("2", res.Header.Get("Retry-After"))
+
+	_, err = suite.client.OpenOrders(context.TODO())
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal("Rate limit exceeded", apiErr.Message)
+	suite.Require().Equal(2, suite.server.Calls(mock.AllRoutes))
+}
+
+func (suite *faultsTestSuite) TestLatency() {
+	suite.server.AddFault("Balances", mock.Fault{
+		Latency: 50 * time.Millisecond,
+		Jitter:  10 * time.Millisecond,
+	})
+
+	start := time.Now()
+	_, err := suite.client.Balances(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().True(time.Since(start) >= 50*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+
+	_, err = suite.client.Balances(ctx)
+	suite.Require().True(errors.Is(err, context.DeadlineExceeded))
+}
+
+func (suite *faultsTestSuite) TestBrokenResponses() {
+	for _, fault := range []mock.Fault{
+		{Truncate: true},
+		{Malformed: true},
+		{Drop: true},
+	} {
+		// Go's transport retries idempotent requests once when a reused
+		// connection is dropped, so faults are applied to every call.
+		suite.server.ClearFaults()
+		suite.server.AddFault("Balances", fault)
+
+		_, err := suite.client.Balances(context.TODO())
+		suite.Require().Error(err)
+
+		var apiErr *valr.Error
+		suite.Require().False(errors.As(err, &apiErr))
+	}
+}
@@ -0,0 +1,63 @@
+package mock
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// embedded contains the default responses of the mock server. They are
+// embedded so that the server works regardless of the working directory.
+//
+//go:embed testdata/*.json
+var embedded embed.FS
+
+// defaultFixtures is the testdata directory of embedded.
+var defaultFixtures = mustSub(embedded, "testdata")
+
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(fmt.Sprintf("mock: failed to open %s: %v", dir, err))
+	}
+
+	return sub
+}
+
+// layers is a file system made up of other file systems. Files are opened from
+// the last layer which contains them.
+type layers []fs.FS
+
+// Open satisfies the fs.FS interface.
+func (l layers) Open(name string) (fs.File, error) {
+	for i := len(l) - 1; i >= 0; i-- {
+		f, err := l[i].Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		return f, err
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// readResponseFile returns the contents of a fixture.
+func readResponseFile(fsys fs.FS, name string) ([]byte, error) {
+	return fs.ReadFile(fsys, name)
+}
+
+// readFixture decodes a JSON fixture into v.
+func readFixture(fsys fs.FS, name string, v interface{}) error {
+	data, err := readResponseFile(fsys, name)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode fixture %s: %w", name, err)
+	}
+
+	return nil
+}
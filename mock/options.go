@@ -0,0 +1,141 @@
+package mock
+
+import (
+	"io/fs"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Option configures a mock server.
+type Option func(*options)
+
+type options struct {
+	fixtures   layers
+	listener   net.Listener
+	middleware []func(http.Handler) http.Handler
+	overrides  map[string]http.HandlerFunc
+}
+
+// WithFixtures serves fixtures from fsys in place of the defaults embedded in
+// the package. Files use the names of those in the mock's testdata directory,
+// e.g. "currencies.json", and any file missing from fsys falls back to the
+// default. If the server seeds its exchange, it is seeded from these fixtures.
+// When given more than once, later file systems take precedence.
+func WithFixtures(fsys fs.FS) Option {
+	return func(o *options) {
+		o.fixtures = append(o.fixtures, fsys)
+	}
+}
+
+// WithListener serves the mock on l instead of a random port on the loopback
+// interface.
+func WithListener(l net.Listener) Option {
+	return func(o *options) {
+		o.listener = l
+	}
+}
+
+// WithMiddleware wraps every request made to the server, including those to
+// unknown routes and those which are faulted, in mw. Middleware is applied in
+// the order given, the first being the outermost.
+func WithMiddleware(mw func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, mw)
+	}
+}
+
+// WithHandler serves a route using h instead of its default response. Routes
+// are named after the Endpoint of the valr.Call made by the client, e.g.
+// "Balances" or "OrderBook". Faults are still applied to overridden routes.
+func WithHandler(route string, h http.HandlerFunc) Option {
+	return func(o *options) {
+		if o.overrides == nil {
+			o.overrides = make(map[string]http.HandlerFunc)
+		}
+		o.overrides[route] = h
+	}
+}
+
+// WithJSON responds to a route with a fixed JSON body.
+func WithJSON(route string, body []byte) Option {
+	return WithHandler(route, JSON(http.StatusOK, body))
+}
+
+// WithValue responds to a route with v encoded as JSON. If v is a *valr.Error
+// it is written with its StatusCode.
+func WithValue(route string, v interface{}) Option {
+	return WithHandler(route, Value(v))
+}
+
+// JSON returns a handler which responds with a fixed JSON body and status code.
+func JSON(status int, body []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(body)
+	}
+}
+
+// Value returns a handler which responds with v encoded as JSON. If v is a
+// *valr.Error it is written with its StatusCode.
+func Value(v interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err, ok := v.(error); ok {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// overrides holds the handlers which replace the default response of a route.
+type overrides struct {
+	mu       sync.RWMutex
+	handlers map[string]http.HandlerFunc
+}
+
+func newOverrides(handlers map[string]http.HandlerFunc) *overrides {
+	o := &overrides{}
+	o.reset(handlers)
+	return o
+}
+
+// set overrides a route, or removes its override if h is nil.
+func (o *overrides) set(route string, h http.HandlerFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if h == nil {
+		delete(o.handlers, route)
+		return
+	}
+	o.handlers[route] = h
+}
+
+// reset replaces all overrides with handlers.
+func (o *overrides) reset(handlers map[string]http.HandlerFunc) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.handlers = make(map[string]http.HandlerFunc, len(handlers))
+	for route, h := range handlers {
+		o.handlers[route] = h
+	}
+}
+
+// wrap returns a handler which serves a route using its override, if it has
+// one at the time of the request, and h otherwise.
+func (o *overrides) wrap(route string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		o.mu.RLock()
+		override, ok := o.handlers[route]
+		o.mu.RUnlock()
+
+		if ok {
+			override(w, r)
+			return
+		}
+		h(w, r)
+	}
+}
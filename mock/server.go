@@ -1,60 +1,300 @@
 package mock
 
 import (
-	"io/ioutil"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
-	"path/filepath"
 
 	"github.com/gorilla/mux"
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/exchange"
 )
 
+// Server is a mock VALR API to be used for unit testing. Trading endpoints are
+// served by a simulated Exchange, all other endpoints return static response
+// data. Faults may be injected per route to exercise error handling, and the
+// response of any route may be overridden.
 type Server struct {
 	*httptest.Server
+
+	// Exchange is the simulated exchange backing the trading endpoints. It
+	// may be used to set up balances and liquidity or to inspect state.
+	Exchange *Exchange
+
+	faults    *faults
+	fixtures  fs.FS
+	options   options
+	overrides *overrides
+	seeded    bool
+}
+
+// NewServer returns a mock server to be used for unit testing. The exchange is
+// seeded from the fixtures embedded in the package, which also provide the
+// static responses of all other endpoints. Responses may be overridden per
+// route using opts. It panics if the exchange cannot be seeded, see
+// NewServerE.
+func NewServer(opts ...Option) *Server {
+	s, err := NewServerE(opts...)
+	if err != nil {
+		panic(fmt.Sprintf("mock: %v", err))
+	}
+
+	return s
+}
+
+// NewServerE is like NewServer, but returns an error if the exchange cannot be
+// seeded from the fixtures, e.g. when fixtures given using WithFixtures are
+// invalid. The server is not started if an error is returned.
+func NewServerE(opts ...Option) (*Server, error) {
+	s := newServer(NewExchange(), opts)
+	s.seeded = true
+	if err := s.seed(); err != nil {
+		return nil, fmt.Errorf("failed to seed exchange: %w", err)
+	}
+
+	s.start()
+	return s, nil
 }
 
-// NewServer returns a mock server to be used for unit testing. All enpoints
-// return static response data read from JSON files in the testdata directory.
-func NewServer() *Server {
+// NewServerWithExchange returns a mock server whose trading endpoints are
+// served by e.
+func NewServerWithExchange(e *Exchange, opts ...Option) *Server {
+	s := newServer(e, opts)
+	s.start()
+	return s
+}
+
+func newServer(e *Exchange, opts []Option) *Server {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Server{
+		Exchange:  e,
+		faults:    newFaults(),
+		fixtures:  append(layers{defaultFixtures}, o.fixtures...),
+		options:   o,
+		overrides: newOverrides(o.overrides),
+	}
+}
+
+// start registers the routes and starts serving.
+func (s *Server) start() {
 	r := mux.NewRouter()
-	registerRoutes(r)
+	s.registerRoutes(r)
+	r.Use(s.faults.middleware)
+
+	var h http.Handler = r
+	for i := len(s.options.middleware) - 1; i >= 0; i-- {
+		h = s.options.middleware[i](h)
+	}
+
+	s.Server = httptest.NewUnstartedServer(h)
+	if s.options.listener != nil {
+		s.Server.Listener.Close()
+		s.Server.Listener = s.options.listener
+	}
+	s.Server.Start()
+}
+
+// seed seeds the exchange from the server's fixtures.
+func (s *Server) seed() error {
+	return s.Exchange.SeedFrom(s.fixtures)
+}
+
+// Override serves a route using h instead of its default response, or removes
+// the route's override if h is nil. It may be called while the server is
+// running.
+func (s *Server) Override(route string, h http.HandlerFunc) {
+	s.overrides.set(route, h)
+}
+
+// ClearOverrides removes all overrides, including those given as options.
+func (s *Server) ClearOverrides() {
+	s.overrides.reset(nil)
+}
+
+// Reset returns the server to the state it was created in. Faults and call
+// counts are cleared, overrides are reverted to those given as options and, if
+// the server seeded its exchange, the exchange is reset and seeded again.
+func (s *Server) Reset() error {
+	s.faults.clear()
+	s.overrides.reset(s.options.overrides)
+
+	if !s.seeded {
+		return nil
+	}
+
+	s.Exchange.Reset()
+	if err := s.seed(); err != nil {
+		return fmt.Errorf("failed to seed exchange: %w", err)
+	}
+
+	return nil
+}
+
+// AddFault injects a fault into a route. Routes are named after the Endpoint
+// of the valr.Call made by the client, e.g. "OrderBook" or "LimitOrder", or
+// may be AllRoutes. Multiple faults on the same route are applied in turn, each
+// once the previous fault's Times have been used up.
+func (s *Server) AddFault(route string, f Fault) {
+	s.faults.add(route, f)
+}
+
+// FailNext injects a fault into the next n calls to a route. Nothing is
+// injected if n is not positive.
+func (s *Server) FailNext(route string, n int, f Fault) {
+	if n <= 0 {
+		return
+	}
 
-	s := httptest.NewServer(r)
-	return &Server{s}
+	f.Times = n
+	s.faults.add(route, f)
 }
 
-func registerRoutes(r *mux.Router) {
+// ClearFaults removes all injected faults and resets the call counts.
+func (s *Server) ClearFaults() {
+	s.faults.clear()
+}
+
+// Calls returns the number of calls made to a route, including those which
+// were faulted.
+func (s *Server) Calls(route string) int {
+	return s.faults.count(route)
+}
+
+func (s *Server) registerRoutes(r *mux.Router) {
+	e := s.Exchange
+
+	// route registers a named route, served by its override if there is one.
+	route := func(r *mux.Router, name, path string,
+		h http.HandlerFunc) *mux.Route {
+
+		return r.HandleFunc(path, s.overrides.wrap(name, h)).Name(name)
+	}
+
 	// Accounts.
-	r.HandleFunc("/account/balances", makeHandler("accountBalances.json"))
-	r.HandleFunc("/account/{pair}/tradehistory", makeHandler("tradehistory.json"))
-	r.HandleFunc("/account/transactionhistory",
-		makeHandler("transactionHistory.json"))
+	route(r, "Balances", "/account/balances",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, e.Balances())
+		})
+	route(r, "TradeHistory", "/account/{pair}/tradehistory",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, e.TradeHistory(mux.Vars(r)["pair"]))
+		})
+	route(r, "TransactionHistory", "/account/transactionhistory",
+		s.makeHandler("transactionHistory.json"))
+
+	// Orders.
+	route(r, "LimitOrder", "/orders/limit",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req valr.LimitOrderRequest
+			if !decode(w, r, &req) {
+				return
+			}
+
+			id, err := e.LimitOrder(&req)
+			writeOrder(w, id, err)
+		}).Methods(http.MethodPost)
+	route(r, "MarketOrder", "/orders/market",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req valr.MarketOrderRequest
+			if !decode(w, r, &req) {
+				return
+			}
+
+			id, err := e.MarketOrder(&req)
+			writeOrder(w, id, err)
+		}).Methods(http.MethodPost)
+	route(r, "CancelOrder", "/orders/order",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req valr.CancelOrderRequest
+			if !decode(w, r, &req) {
+				return
+			}
+
+			if err := e.CancelOrder(&req); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodDelete)
+	route(r, "OpenOrders", "/orders/open",
+		func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, http.StatusOK, e.OpenOrders())
+		}).Methods(http.MethodGet)
+	route(r, "OrderStatus", "/orders/{pair}/orderid/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			writeOrderStatus(w, e, &valr.OrderStatusRequest{
+				OrderID: vars["id"],
+				Pair:    vars["pair"],
+			})
+		}).Methods(http.MethodGet)
+	route(r, "OrderStatus", "/orders/{pair}/customerorderid/{id}",
+		func(w http.ResponseWriter, r *http.Request) {
+			vars := mux.Vars(r)
+			writeOrderStatus(w, e, &valr.OrderStatusRequest{
+				CustomerOrderID: vars["id"],
+				Pair:            vars["pair"],
+			})
+		}).Methods(http.MethodGet)
+
+	// Simple buy / sell.
+	route(r, "SimpleOrder", "/simple/{pair}/order",
+		func(w http.ResponseWriter, r *http.Request) {
+			var req valr.SimpleOrderRequest
+			if !decode(w, r, &req) {
+				return
+			}
+			req.Pair = mux.Vars(r)["pair"]
+
+			id, err := e.SimpleOrder(&req)
+			writeOrder(w, id, err)
+		}).Methods(http.MethodPost)
 
 	// Public.
-	r.HandleFunc("/public/currencies", makeHandler("currencies.json"))
-	r.HandleFunc("/public/pairs", makeHandler("currencyPairs.json"))
-	r.HandleFunc("/public/{pair}/orderbook", makeHandler("orderBook.json"))
-	r.HandleFunc("/public/marketsummary", makeHandler("marketSummaries.json"))
-	r.HandleFunc("/public/{pair}/marketsummary",
-		makeHandler("marketSummary.json"))
-	r.HandleFunc("/public/ordertypes", makeHandler("orderTypes.json"))
-	r.HandleFunc("/public/{pair}/ordertypes",
-		makeHandler("orderTypesForCurrency.json"))
-	r.HandleFunc("/public/status", makeHandler("status.json"))
-	r.HandleFunc("/public/time", makeHandler("serverTime.json"))
+	route(r, "Currencies", "/public/currencies",
+		s.makeHandler("currencies.json"))
+	route(r, "CurrencyPairs", "/public/pairs",
+		s.makeHandler("currencyPairs.json"))
+	route(r, "OrderBook", "/public/{pair}/orderbook",
+		func(w http.ResponseWriter, r *http.Request) {
+			book, err := e.OrderBook(mux.Vars(r)["pair"])
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusOK, book)
+		})
+	route(r, "MarketSummary", "/public/marketsummary",
+		s.makeHandler("marketSummaries.json"))
+	route(r, "MarketSummaryForCurrency", "/public/{pair}/marketsummary",
+		s.makeHandler("marketSummary.json"))
+	route(r, "OrderTypes", "/public/ordertypes",
+		s.makeHandler("orderTypes.json"))
+	route(r, "OrderTypesForCurrency", "/public/{pair}/ordertypes",
+		s.makeHandler("orderTypesForCurrency.json"))
+	route(r, "Status", "/public/status",
+		s.makeHandler("status.json"))
+	route(r, "ServerTime", "/public/time",
+		s.makeHandler("serverTime.json"))
 
 	// Crypto
-	r.HandleFunc("/wallet/crypto/{currency}/deposit/address",
-		makeHandler("depositaddress.json"))
-	r.HandleFunc("/wallet/crypto/{currency}/withdraw",
-		makeHandler("withdrawinfo.json"))
+	route(r, "DepositAddress", "/wallet/crypto/{currency}/deposit/address",
+		s.makeHandler("depositaddress.json"))
+	route(r, "WithdrawalInfo", "/wallet/crypto/{currency}/withdraw",
+		s.makeHandler("withdrawinfo.json"))
 }
 
-const testDir = "mock/testdata"
-
-func makeHandler(responseFile string) http.HandlerFunc {
+// makeHandler returns a handler which responds with a fixture.
+func (s *Server) makeHandler(responseFile string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		res, err := readResponseFile(filepath.Join(testDir, responseFile))
+		res, err := readResponseFile(s.fixtures, responseFile)
 		if err != nil {
 			serverError(w, err)
 			return
@@ -65,11 +305,67 @@ func makeHandler(responseFile string) http.HandlerFunc {
 	}
 }
 
-func readResponseFile(filepath string) ([]byte, error) {
-	return ioutil.ReadFile(filepath)
-}
-
 func serverError(w http.ResponseWriter, err error) {
 	w.WriteHeader(http.StatusInternalServerError)
 	w.Write([]byte(err.Error()))
 }
+
+// decode reads a JSON request body into v, writing an error response and
+// returning false if it cannot be decoded.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, exchange.BadRequest("Invalid request body: "+
+			err.Error()))
+		return false
+	}
+
+	return true
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		serverError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError writes a VALR error response for err.
+func writeError(w http.ResponseWriter, err error) {
+	var apiErr *valr.Error
+	if !errors.As(err, &apiErr) {
+		serverError(w, err)
+		return
+	}
+
+	writeJSON(w, apiErr.StatusCode, apiErr)
+}
+
+// writeOrder writes the response to placing an order, which VALR accepts for
+// asynchronous processing.
+func writeOrder(w http.ResponseWriter, id string, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"id": id})
+}
+
+// writeOrderStatus writes the status of the order matching req.
+func writeOrderStatus(w http.ResponseWriter, e *Exchange,
+	req *valr.OrderStatusRequest) {
+
+	info, err := e.OrderStatus(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
@@ -0,0 +1,141 @@
+package mock_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestServerTestSuite(t *testing.T) {
+	suite.Run(t, new(serverTestSuite))
+}
+
+type serverTestSuite struct {
+	suite.Suite
+}
+
+// TestEmbeddedFixtures runs from the mock directory, so it only passes if the
+// fixtures do not depend on the working directory.
+func (suite *serverTestSuite) TestEmbeddedFixtures() {
+	server := mock.NewServer()
+	defer server.Close()
+
+	client := valr.NewClientForTesting(suite.T(), server.URL)
+
+	currencies, err := client.Currencies(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(currencies)
+
+	book, err := client.OrderBook(context.TODO(), "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().NotEmpty(book.Asks)
+}
+
+func (suite *serverTestSuite) TestOverrides() {
+	server := mock.NewServer(
+		mock.WithJSON("ServerTime",
+			[]byte(`{"epochTime":1,"time":"1970-01-01T00:00:01Z"}`)),
+		mock.WithValue("Currencies", []valr.Currency{{Symbol: "XYZ"}}),
+		mock.WithValue("Balances", &valr.Error{
+			StatusCode: http.StatusUnauthorized,
+			Code:       -11,
+			Message:    "API key invalid",
+		}),
+		mock.WithHandler("Status",
+			func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"status":"read-only"}`))
+			}),
+	)
+	defer server.Close()
+
+	client := valr.NewClientForTesting(suite.T(), server.URL)
+
+	serverTime, err := client.ServerTime(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(int64(1), serverTime.Epoch)
+
+	currencies, err := client.Currencies(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(currencies, 1)
+	suite.Require().Equal("XYZ", currencies[0].Symbol)
+
+	_, err = client.Balances(context.TODO())
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusUnauthorized, apiErr.StatusCode)
+	suite.Require().Equal("API key invalid", apiErr.Message)
+
+	status, err := client.Status(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.StatusReadOnly, status)
+
+	// Routes without overrides are unaffected and faults still apply.
+	_, err = client.CurrencyPairs(context.TODO())
+	suite.Require().NoError(err)
+
+	server.FailNext("Currencies", 1, mock.Fault{
+		StatusCode: http.StatusBadGateway,
+	})
+	_, err = client.Currencies(context.TODO())
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusBadGateway, apiErr.StatusCode)
+}
+
+func (suite *serverTestSuite) TestFixturesOverrideAndReset() {
+	fixtures := fstest.MapFS{
+		"accountBalances.json": &fstest.MapFile{
+			Data: []byte(`[{"currency":"ZAR","available":"10",` +
+				`"reserved":"0","total":"10"}]`),
+		},
+		"status.json": &fstest.MapFile{Data: []byte(`{"status":"read-only"}`)},
+	}
+
+	server := mock.NewServer(mock.WithFixtures(fixtures))
+	defer server.Close()
+
+	client := valr.NewClientForTesting(suite.T(), server.URL)
+
+	status, err := client.Status(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.StatusReadOnly, status)
+
+	suite.Require().NoError(server.Exchange.SetBalance("ZAR", "99"))
+	server.Override("Status", mock.JSON(http.StatusOK,
+		[]byte(`{"status":"online"}`)))
+
+	status, err = client.Status(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.StatusOnline, status)
+
+	suite.Require().NoError(server.Reset())
+
+	status, err = client.Status(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.StatusReadOnly, status)
+
+	balances, err := client.Balances(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(balances, 1)
+	suite.Require().Equal("10", balances[0].Available)
+}
+
+func (suite *serverTestSuite) TestInvalidFixtures() {
+	fixtures := fstest.MapFS{
+		"orderBook.json": &fstest.MapFile{Data: []byte(`{`)},
+	}
+
+	server, err := mock.NewServerE(mock.WithFixtures(fixtures))
+	suite.Require().Error(err)
+	suite.Require().Nil(server)
+	suite.Require().Contains(err.Error(), "orderBook.json")
+
+	suite.Require().Panics(func() {
+		mock.NewServer(mock.WithFixtures(fixtures))
+	})
+}
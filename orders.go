@@ -0,0 +1,289 @@
+package valr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSide is returned when an order side is neither SideBuy nor
+// SideSell.
+var ErrInvalidSide = errors.New("invalid order side")
+
+// Side describes whether an order or trade is a buy or a sell.
+type Side string
+
+const (
+	// SideBuy buys the base currency of a pair using the quote currency.
+	SideBuy Side = "BUY"
+
+	// SideSell sells the base currency of a pair for the quote currency.
+	SideSell Side = "SELL"
+)
+
+// ParseSide returns the Side for s, ignoring case.
+func ParseSide(s string) (Side, error) {
+	switch {
+	case strings.EqualFold(s, string(SideBuy)):
+		return SideBuy, nil
+	case strings.EqualFold(s, string(SideSell)):
+		return SideSell, nil
+	default:
+		return Side(s), fmt.Errorf("%w: %q", ErrInvalidSide, s)
+	}
+}
+
+// Valid returns whether s is a known Side.
+func (s Side) Valid() bool {
+	return s == SideBuy || s == SideSell
+}
+
+// Validate returns an error if s is not a known Side.
+func (s Side) Validate() error {
+	if !s.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidSide, string(s))
+	}
+
+	return nil
+}
+
+// Opposite returns the other side of the book.
+func (s Side) Opposite() Side {
+	switch s {
+	case SideBuy:
+		return SideSell
+	case SideSell:
+		return SideBuy
+	default:
+		return s
+	}
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. VALR returns sides
+// in lower case in some responses, these are normalized to the Side
+// constants. Unknown values are preserved as is.
+func (s *Side) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s, _ = ParseSide(raw)
+	return nil
+}
+
+// OrderStatus describes the state of an order on the exchange.
+type OrderStatus string
+
+const (
+	// OrderStatusPlaced implies the order has been placed on the order book.
+	OrderStatusPlaced OrderStatus = "Placed"
+
+	// OrderStatusPartiallyFilled implies some of the order has been filled
+	// and the remainder is still on the order book.
+	OrderStatusPartiallyFilled OrderStatus = "Partially Filled"
+
+	// OrderStatusFilled implies the order has been completely filled.
+	OrderStatusFilled OrderStatus = "Filled"
+
+	// OrderStatusCancelled implies the order was cancelled before being
+	// completely filled.
+	OrderStatusCancelled OrderStatus = "Cancelled"
+
+	// OrderStatusFailed implies the order was rejected by the exchange.
+	OrderStatusFailed OrderStatus = "Failed"
+)
+
+var orderStatuses = []OrderStatus{
+	OrderStatusPlaced,
+	OrderStatusPartiallyFilled,
+	OrderStatusFilled,
+	OrderStatusCancelled,
+	OrderStatusFailed,
+}
+
+// ParseOrderStatus returns the OrderStatus for s, ignoring case. Unknown
+// statuses are returned as is along with false.
+func ParseOrderStatus(s string) (OrderStatus, bool) {
+	for _, status := range orderStatuses {
+		if strings.EqualFold(s, string(status)) {
+			return status, true
+		}
+	}
+
+	return OrderStatus(s), false
+}
+
+// Valid returns whether s is a known OrderStatus.
+func (s OrderStatus) Valid() bool {
+	for _, status := range orderStatuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Done returns whether the order has reached a final state and will not
+// change again.
+func (s OrderStatus) Done() bool {
+	return s == OrderStatusFilled || s == OrderStatusCancelled ||
+		s == OrderStatusFailed
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. Known statuses are
+// normalized to the OrderStatus constants, unknown values are preserved as is.
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s, _ = ParseOrderStatus(raw)
+	return nil
+}
+
+// OrderInfo contains the current state of an order placed on the exchange.
+type OrderInfo struct {
+	AveragePrice      string      `json:"averagePrice"`
+	CreatedAt         time.Time   `json:"orderCreatedAt"`
+	CurrencyPair      string      `json:"currencyPair"`
+	CustomerOrderID   string      `json:"customerOrderId,omitempty"`
+	FailedReason      string      `json:"failedReason,omitempty"`
+	FeeCurrency       string      `json:"feeCurrency,omitempty"`
+	ID                string      `json:"orderId"`
+	OriginalPrice     string      `json:"originalPrice"`
+	OriginalQuantity  string      `json:"originalQuantity"`
+	RemainingQuantity string      `json:"remainingQuantity"`
+	Side              Side        `json:"orderSide"`
+	Status            OrderStatus `json:"orderStatusType"`
+	TotalFee          string      `json:"totalFee"`
+	Type              OrderType   `json:"orderType"`
+	UpdatedAt         time.Time   `json:"orderUpdatedAt"`
+}
+
+// OpenOrder contains information about an order which has not yet been
+// filled, cancelled or failed.
+type OpenOrder struct {
+	CreatedAt         time.Time   `json:"createdAt"`
+	CurrencyPair      string      `json:"currencyPair"`
+	CustomerOrderID   string      `json:"customerOrderId,omitempty"`
+	FilledPercentage  string      `json:"filledPercentage"`
+	ID                string      `json:"orderId"`
+	OriginalQuantity  string      `json:"originalQuantity"`
+	Price             string      `json:"price"`
+	RemainingQuantity string      `json:"remainingQuantity"`
+	Side              Side        `json:"side"`
+	Status            OrderStatus `json:"status"`
+	Type              OrderType   `json:"type"`
+	UpdatedAt         time.Time   `json:"updatedAt"`
+}
+
+// orderResponse is the response to placing an order.
+type orderResponse struct {
+	ID string `json:"id"`
+}
+
+// CancelOrder satisfies the PrivateClient interface.
+func (c *client) CancelOrder(ctx context.Context,
+	req *CancelOrderRequest) error {
+
+	_, err := c.request(ctx, Call{Endpoint: "CancelOrder", Pair: req.Pair},
+		req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	return nil
+}
+
+// LimitOrder satisfies the PrivateClient interface.
+func (c *client) LimitOrder(ctx context.Context, req *LimitOrderRequest) (
+	string, error) {
+
+	res, err := c.request(ctx, Call{Endpoint: "LimitOrder", Pair: req.Pair},
+		req)
+	if err != nil {
+		return "", fmt.Errorf("failed to place limit order: %w", err)
+	}
+
+	var order orderResponse
+	if err = res.JSON(&order); err != nil {
+		return "", fmt.Errorf("failed to unmarshal order: %w", err)
+	}
+
+	return order.ID, nil
+}
+
+// MarketOrder satisfies the PrivateClient interface.
+func (c *client) MarketOrder(ctx context.Context, req *MarketOrderRequest) (
+	string, error) {
+
+	res, err := c.request(ctx, Call{Endpoint: "MarketOrder", Pair: req.Pair},
+		req)
+	if err != nil {
+		return "", fmt.Errorf("failed to place market order: %w", err)
+	}
+
+	var order orderResponse
+	if err = res.JSON(&order); err != nil {
+		return "", fmt.Errorf("failed to unmarshal order: %w", err)
+	}
+
+	return order.ID, nil
+}
+
+// OpenOrders satisfies the PrivateClient interface.
+func (c *client) OpenOrders(ctx context.Context) ([]OpenOrder, error) {
+	res, err := c.get(ctx, Call{Endpoint: "OpenOrders"}, "/orders/open", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch open orders: %w", err)
+	}
+
+	var orders []OpenOrder
+	if err = res.JSON(&orders); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal open orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// OrderStatus satisfies the PrivateClient interface.
+func (c *client) OrderStatus(ctx context.Context, req *OrderStatusRequest) (
+	*OrderInfo, error) {
+
+	res, err := c.request(ctx, Call{Endpoint: "OrderStatus", Pair: req.Pair},
+		req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order status: %w", err)
+	}
+
+	var info OrderInfo
+	if err = res.JSON(&info); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal order status: %w", err)
+	}
+
+	return &info, nil
+}
+
+// SimpleOrder satisfies the PrivateClient interface.
+func (c *client) SimpleOrder(ctx context.Context, req *SimpleOrderRequest) (
+	string, error) {
+
+	res, err := c.request(ctx, Call{Endpoint: "SimpleOrder", Pair: req.Pair},
+		req)
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w", err)
+	}
+
+	var order orderResponse
+	if err = res.JSON(&order); err != nil {
+		return "", fmt.Errorf("failed to unmarshal order: %w", err)
+	}
+
+	return order.ID, nil
+}
@@ -0,0 +1,227 @@
+package valr_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestOrdersTestSuite(t *testing.T) {
+	suite.Run(t, new(ordersTestSuite))
+}
+
+type ordersTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ordersTestSuite) TestParseSide() {
+	testcases := []struct {
+		input string
+		side  valr.Side
+		err   bool
+	}{
+		{input: "BUY", side: valr.SideBuy},
+		{input: "buy", side: valr.SideBuy},
+		{input: "Sell", side: valr.SideSell},
+		{input: "SELL", side: valr.SideSell},
+		{input: "HOLD", side: "HOLD", err: true},
+		{input: "", side: "", err: true},
+	}
+
+	for _, test := range testcases {
+		suite.T().Run(test.input, func(t *testing.T) {
+			side, err := valr.ParseSide(test.input)
+			suite.Require().Equal(test.side, side)
+			if test.err {
+				suite.Require().True(errors.Is(err, valr.ErrInvalidSide))
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *ordersTestSuite) TestSideValidate() {
+	suite.Require().NoError(valr.SideBuy.Validate())
+	suite.Require().NoError(valr.SideSell.Validate())
+
+	// Typos must be caught before the request is sent.
+	err := valr.Side("Sell").Validate()
+	suite.Require().True(errors.Is(err, valr.ErrInvalidSide))
+
+	suite.Require().Equal(valr.SideSell, valr.SideBuy.Opposite())
+	suite.Require().Equal(valr.SideBuy, valr.SideSell.Opposite())
+}
+
+func (suite *ordersTestSuite) TestSideJSON() {
+	var entry valr.OrderBookEntry
+	err := json.Unmarshal([]byte(`{"side":"sell"}`), &entry)
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.SideSell, entry.Side)
+
+	// Unknown values are preserved rather than rejected.
+	err = json.Unmarshal([]byte(`{"side":"short"}`), &entry)
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.Side("short"), entry.Side)
+	suite.Require().False(entry.Side.Valid())
+
+	err = json.Unmarshal([]byte(`{"side":5}`), &entry)
+	suite.Require().Error(err)
+
+	data, err := json.Marshal(valr.LimitOrderRequest{Side: valr.SideBuy})
+	suite.Require().NoError(err)
+	suite.Require().Contains(string(data), `"side":"BUY"`)
+
+	var req valr.LimitOrderRequest
+	suite.Require().NoError(json.Unmarshal(data, &req))
+	suite.Require().Equal(valr.SideBuy, req.Side)
+}
+
+func (suite *ordersTestSuite) TestOrderStatus() {
+	status, ok := valr.ParseOrderStatus("partially filled")
+	suite.Require().True(ok)
+	suite.Require().Equal(valr.OrderStatusPartiallyFilled, status)
+	suite.Require().True(status.Valid())
+	suite.Require().False(status.Done())
+
+	status, ok = valr.ParseOrderStatus("Expired")
+	suite.Require().False(ok)
+	suite.Require().Equal(valr.OrderStatus("Expired"), status)
+	suite.Require().False(status.Valid())
+
+	for _, status := range []valr.OrderStatus{valr.OrderStatusFilled,
+		valr.OrderStatusCancelled, valr.OrderStatusFailed} {
+		suite.Require().True(status.Done())
+	}
+}
+
+func (suite *ordersTestSuite) TestOrderStatusJSON() {
+	var statuses []valr.OrderStatus
+	err := json.Unmarshal([]byte(`["Placed","FILLED","Expired"]`), &statuses)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]valr.OrderStatus{valr.OrderStatusPlaced,
+		valr.OrderStatusFilled, "Expired"}, statuses)
+
+	data, err := json.Marshal(statuses)
+	suite.Require().NoError(err)
+	suite.Require().Equal(`["Placed","Filled","Expired"]`, string(data))
+}
+
+func TestOrderClientTestSuite(t *testing.T) {
+	suite.Run(t, new(orderClientTestSuite))
+}
+
+// orderClientTestSuite checks the requests sent by the order methods and the
+// decoding of their responses.
+type orderClientTestSuite struct {
+	suite.Suite
+	body      string
+	client    valr.Client
+	request   string
+	responses map[string]string
+	server    *httptest.Server
+}
+
+func (suite *orderClientTestSuite) SetupTest() {
+	suite.body, suite.request = "", ""
+	suite.responses = map[string]string{
+		"DELETE /orders/order": ``,
+		"GET /orders/open": `[{"orderId":"1","side":"buy","price":"9000",` +
+			`"status":"Placed","type":"limit"}]`,
+		"POST /orders/limit":  `{"id":"limit-id"}`,
+		"POST /orders/market": `{"id":"market-id"}`,
+		"GET /orders/BTCZAR/orderid/1": `{"orderId":"1","orderSide":"sell",` +
+			`"orderStatusType":"Filled","averagePrice":"9000"}`,
+	}
+
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			suite.Require().NoError(err)
+
+			suite.body = string(body)
+			suite.request = r.Method + " " + r.URL.Path
+
+			res, ok := suite.responses[suite.request]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(res))
+		}))
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL)
+}
+
+func (suite *orderClientTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *orderClientTestSuite) TestLimitOrder() {
+	id, err := suite.client.LimitOrder(context.TODO(),
+		&valr.LimitOrderRequest{
+			Pair:     "BTCZAR",
+			Price:    "9000",
+			Quantity: "0.1",
+			Side:     valr.SideBuy,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal("limit-id", id)
+	suite.Require().Equal("POST /orders/limit", suite.request)
+	suite.Require().Contains(suite.body, `"price":"9000"`)
+}
+
+func (suite *orderClientTestSuite) TestMarketOrder() {
+	id, err := suite.client.MarketOrder(context.TODO(),
+		&valr.MarketOrderRequest{
+			BaseAmount: "0.1",
+			Pair:       "BTCZAR",
+			Side:       valr.SideSell,
+		})
+	suite.Require().NoError(err)
+	suite.Require().Equal("market-id", id)
+	suite.Require().Equal("POST /orders/market", suite.request)
+	suite.Require().Contains(suite.body, `"baseAmount":"0.1"`)
+}
+
+func (suite *orderClientTestSuite) TestCancelOrder() {
+	err := suite.client.CancelOrder(context.TODO(), &valr.CancelOrderRequest{
+		OrderID: "1",
+		Pair:    "BTCZAR",
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal("DELETE /orders/order", suite.request)
+	suite.Require().Contains(suite.body, `"orderId":"1"`)
+}
+
+func (suite *orderClientTestSuite) TestOpenOrders() {
+	open, err := suite.client.OpenOrders(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(open, 1)
+	suite.Require().Equal("1", open[0].ID)
+	suite.Require().Equal(valr.SideBuy, open[0].Side)
+	suite.Require().Equal(valr.OrderStatusPlaced, open[0].Status)
+}
+
+func (suite *orderClientTestSuite) TestOrderStatus() {
+	info, err := suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{OrderID: "1", Pair: "BTCZAR"})
+	suite.Require().NoError(err)
+	suite.Require().Equal("1", info.ID)
+	suite.Require().Equal(valr.SideSell, info.Side)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal("9000", info.AveragePrice)
+
+	_, err = suite.client.OrderStatus(context.TODO(),
+		&valr.OrderStatusRequest{OrderID: "2", Pair: "BTCZAR"})
+
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusNotFound, apiErr.StatusCode)
+}
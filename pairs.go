@@ -0,0 +1,357 @@
+package valr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// Errors returned when validating orders against a PairRegistry. They are
+// wrapped with a description of the offending value.
+var (
+	ErrUnknownPair          = errors.New("unknown currency pair")
+	ErrPairInactive         = errors.New("currency pair is not active")
+	ErrOrderTypeUnsupported = errors.New("order type not supported for pair")
+	ErrInvalidAmount        = errors.New("invalid amount")
+	ErrAmountTooSmall       = errors.New("amount below pair minimum")
+	ErrAmountTooLarge       = errors.New("amount above pair maximum")
+	ErrPrecision            = errors.New("amount exceeds pair precision")
+)
+
+// Pair is a currency pair symbol split into its base and quote currencies.
+type Pair struct {
+	Base   CurrencyCode
+	Quote  CurrencyCode
+	Symbol string
+}
+
+// String returns the pair's symbol, e.g. "BTCZAR".
+func (p Pair) String() string {
+	return p.Symbol
+}
+
+// PairRegistry contains the currency pairs supported by VALR along with their
+// trading constraints and supported order types. A PairRegistry is safe for
+// concurrent use once constructed.
+type PairRegistry struct {
+	orderTypes map[string]map[OrderType]bool
+	pairs      map[string]CurrencyPair
+}
+
+// LoadPairRegistry returns a PairRegistry populated using the CurrencyPairs and
+// OrderTypes endpoints.
+func LoadPairRegistry(ctx context.Context, c PublicClient) (*PairRegistry,
+	error) {
+	pairs, err := c.CurrencyPairs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load currency pairs: %w", err)
+	}
+
+	orderTypes, err := c.OrderTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order types: %w", err)
+	}
+
+	return NewPairRegistry(pairs, orderTypes), nil
+}
+
+// NewPairRegistry returns a PairRegistry containing the given pairs and order
+// types, as returned by CurrencyPairs and OrderTypes.
+func NewPairRegistry(pairs []CurrencyPair,
+	orderTypes map[string]map[OrderType]bool) *PairRegistry {
+	r := PairRegistry{
+		orderTypes: make(map[string]map[OrderType]bool),
+		pairs:      make(map[string]CurrencyPair),
+	}
+
+	for _, pair := range pairs {
+		r.pairs[strings.ToUpper(pair.Symbol)] = pair
+	}
+
+	for symbol, types := range orderTypes {
+		typesCopy := make(map[OrderType]bool, len(types))
+		for typ, ok := range types {
+			typesCopy[typ] = ok
+		}
+		r.orderTypes[strings.ToUpper(symbol)] = typesCopy
+	}
+
+	return &r
+}
+
+// normalizeSymbol converts the common ways of writing a pair, e.g. "btc/zar",
+// "BTC-ZAR" or "BTC_ZAR", into VALR's symbol format "BTCZAR".
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "", "-", "", "_", "",
+		" ", "").Replace(symbol))
+}
+
+// Lookup returns the CurrencyPair for a symbol.
+func (r *PairRegistry) Lookup(symbol string) (CurrencyPair, error) {
+	pair, ok := r.pairs[normalizeSymbol(symbol)]
+	if !ok {
+		return CurrencyPair{}, fmt.Errorf("%w: %q", ErrUnknownPair, symbol)
+	}
+
+	return pair, nil
+}
+
+// Parse splits a symbol into its base and quote currencies. Symbols are case
+// insensitive and may optionally be separated by "/", "-" or "_".
+func (r *PairRegistry) Parse(symbol string) (Pair, error) {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	return Pair{
+		Base:   CurrencyCode(strings.ToUpper(pair.BaseCurrency)),
+		Quote:  CurrencyCode(strings.ToUpper(pair.QuoteCurrency)),
+		Symbol: pair.Symbol,
+	}, nil
+}
+
+// Pairs returns all the pairs in the registry.
+func (r *PairRegistry) Pairs() []CurrencyPair {
+	pairs := make([]CurrencyPair, 0, len(r.pairs))
+	for _, pair := range r.pairs {
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
+
+// OrderTypes returns the order types supported by a pair.
+func (r *PairRegistry) OrderTypes(symbol string) (map[OrderType]bool,
+	error) {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[OrderType]bool)
+	for typ, ok := range r.orderTypes[pair.Symbol] {
+		types[typ] = ok
+	}
+
+	return types, nil
+}
+
+// SupportsOrderType returns whether a pair supports the given order type.
+func (r *PairRegistry) SupportsOrderType(symbol string, typ OrderType) (bool,
+	error) {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return false, err
+	}
+
+	return r.orderTypes[pair.Symbol][typ], nil
+}
+
+// ValidateBaseAmount returns an error if amount, denominated in the pair's
+// base currency, falls outside of the pair's limits.
+func (r *PairRegistry) ValidateBaseAmount(symbol, amount string) error {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	return checkLimits(value, pair.MinBaseAmount, pair.MaxBaseAmount,
+		pair.BaseCurrency)
+}
+
+// ValidateQuoteAmount returns an error if amount, denominated in the pair's
+// quote currency, falls outside of the pair's limits.
+func (r *PairRegistry) ValidateQuoteAmount(symbol, amount string) error {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	return checkLimits(value, pair.MinQuoteAmount, pair.MaxQuoteAmount,
+		pair.QuoteCurrency)
+}
+
+// ValidateLimitOrder returns an error if the order would be rejected due to the
+// pair being unknown or inactive, the order type being unsupported, an invalid
+// side, or the quantity or total value falling outside of the pair's limits.
+func (r *PairRegistry) ValidateLimitOrder(req *LimitOrderRequest) error {
+	typ := OrderTypeLimit
+	if req.PostOnly {
+		typ = OrderTypePostOnly
+	}
+
+	if err := r.validateOrderType(req.Pair, typ); err != nil {
+		return err
+	}
+
+	if err := req.Side.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateBaseAmount(req.Pair, req.Quantity); err != nil {
+		return fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	price, err := parseAmount(req.Price)
+	if err != nil {
+		return fmt.Errorf("invalid price: %w", err)
+	}
+
+	pair, _ := r.Lookup(req.Pair)
+	quantity, _ := parseAmount(req.Quantity)
+	total := new(big.Rat).Mul(price, quantity)
+	if err = checkLimits(total, pair.MinQuoteAmount, pair.MaxQuoteAmount,
+		pair.QuoteCurrency); err != nil {
+		return fmt.Errorf("invalid order total: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateMarketOrder returns an error if the order would be rejected due to
+// the pair being unknown or inactive, market orders being unsupported, an
+// invalid side, or the amount falling outside of the pair's limits.
+func (r *PairRegistry) ValidateMarketOrder(req *MarketOrderRequest) error {
+	if err := r.validateOrderType(req.Pair, OrderTypeMarket); err != nil {
+		return err
+	}
+
+	if err := req.Side.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.ValidateBaseAmount(req.Pair, req.BaseAmount); err != nil {
+		return fmt.Errorf("invalid base amount: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateQuantity returns an error if quantity, denominated in the pair's base
+// currency, has more decimal places than the pair allows. Pairs which do not
+// specify their precision accept any positive quantity.
+func (r *PairRegistry) ValidateQuantity(symbol, quantity string) error {
+	value, err := parseAmount(quantity)
+	if err != nil {
+		return err
+	}
+
+	rounded, err := r.RoundQuantity(symbol, value)
+	if err != nil {
+		return err
+	}
+
+	if rounded.Cmp(value) != 0 {
+		pair, _ := r.Lookup(symbol)
+		return fmt.Errorf("%w: %s has more than %s decimal places",
+			ErrPrecision, quantity, pair.BaseDecimalPlaces)
+	}
+
+	return nil
+}
+
+// ValidatePrice returns an error if price is not a positive multiple of the
+// pair's tick size. Pairs which do not specify a tick size accept any positive
+// price.
+func (r *PairRegistry) ValidatePrice(symbol, price string) error {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return err
+	}
+
+	value, err := parseAmount(price)
+	if err != nil {
+		return err
+	}
+
+	tick, ok := new(big.Rat).SetString(pair.TickSize)
+	if !ok || tick.Sign() <= 0 {
+		return nil
+	}
+
+	if !new(big.Rat).Quo(value, tick).IsInt() {
+		return fmt.Errorf("%w: %s is not a multiple of the tick size %s",
+			ErrPrecision, price, pair.TickSize)
+	}
+
+	return nil
+}
+
+// RoundQuantity rounds a quantity of the pair's base currency down to the
+// number of decimal places the pair allows. Quantities of pairs which do not
+// specify their precision are returned unchanged.
+func (r *PairRegistry) RoundQuantity(symbol string, quantity *big.Rat) (
+	*big.Rat, error) {
+
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	places, err := strconv.Atoi(pair.BaseDecimalPlaces)
+	if err != nil || places < 0 {
+		return new(big.Rat).Set(quantity), nil
+	}
+
+	return decimal.Truncate(quantity, places), nil
+}
+
+func (r *PairRegistry) validateOrderType(symbol string, typ OrderType) error {
+	pair, err := r.Lookup(symbol)
+	if err != nil {
+		return err
+	}
+
+	if !pair.Active {
+		return fmt.Errorf("%w: %s", ErrPairInactive, pair.Symbol)
+	}
+
+	if !r.orderTypes[pair.Symbol][typ] {
+		return fmt.Errorf("%w: %q orders are not supported for %s",
+			ErrOrderTypeUnsupported, typ, pair.Symbol)
+	}
+
+	return nil
+}
+
+func parseAmount(amount string) (*big.Rat, error) {
+	value, ok := new(big.Rat).SetString(amount)
+	if !ok || value.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q must be a positive decimal",
+			ErrInvalidAmount, amount)
+	}
+
+	return value, nil
+}
+
+// checkLimits compares value against the pair's min and max limits. Empty
+// limits are not enforced.
+func checkLimits(value *big.Rat, min, max, currency string) error {
+	if limit, ok := new(big.Rat).SetString(min); ok && value.Cmp(limit) < 0 {
+		return fmt.Errorf("%w: %s %s is less than %s %s", ErrAmountTooSmall,
+			decimal.FormatPlaces(value, 8), currency, min, currency)
+	}
+
+	if limit, ok := new(big.Rat).SetString(max); ok && value.Cmp(limit) > 0 {
+		return fmt.Errorf("%w: %s %s is more than %s %s", ErrAmountTooLarge,
+			decimal.FormatPlaces(value, 8), currency, max, currency)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package valr_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPairRegistryTestSuite(t *testing.T) {
+	suite.Run(t, new(pairRegistryTestSuite))
+}
+
+type pairRegistryTestSuite struct {
+	suite.Suite
+	registry *valr.PairRegistry
+	server   *mock.Server
+}
+
+func (suite *pairRegistryTestSuite) SetupSuite() {
+	suite.server = mock.NewServer()
+
+	var err error
+	suite.registry, err = valr.LoadPairRegistry(context.TODO(),
+		valr.NewClientForTesting(suite.T(), suite.server.URL))
+	suite.Require().NoError(err)
+}
+
+func (suite *pairRegistryTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *pairRegistryTestSuite) TestParse() {
+	testcases := []struct {
+		symbol string
+		pair   valr.Pair
+		err    error
+	}{
+		{
+			symbol: "BTCZAR",
+			pair:   valr.Pair{Base: "BTC", Quote: "ZAR", Symbol: "BTCZAR"},
+		},
+		{
+			symbol: "eth/zar",
+			pair:   valr.Pair{Base: "ETH", Quote: "ZAR", Symbol: "ETHZAR"},
+		},
+		{
+			symbol: "GRS-BTC",
+			pair:   valr.Pair{Base: "GRS", Quote: "BTC", Symbol: "GRSBTC"},
+		},
+		{
+			symbol: "FOOBAR",
+			err:    valr.ErrUnknownPair,
+		},
+	}
+
+	for _, test := range testcases {
+		suite.T().Run(test.symbol, func(t *testing.T) {
+			pair, err := suite.registry.Parse(test.symbol)
+			if test.err != nil {
+				suite.Require().True(errors.Is(err, test.err))
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Require().Equal(test.pair, pair)
+		})
+	}
+}
+
+func (suite *pairRegistryTestSuite) TestLookup() {
+	pair, err := suite.registry.Lookup("BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Equal("0.0001", pair.MinBaseAmount)
+	suite.Require().Len(suite.registry.Pairs(), 91)
+}
+
+func (suite *pairRegistryTestSuite) TestOrderTypes() {
+	ok, err := suite.registry.SupportsOrderType("BTCZAR",
+		valr.OrderTypeMarket)
+	suite.Require().NoError(err)
+	suite.Require().True(ok)
+
+	ok, err = suite.registry.SupportsOrderType("ETHBTC",
+		valr.OrderTypeMarket)
+	suite.Require().NoError(err)
+	suite.Require().False(ok)
+
+	types, err := suite.registry.OrderTypes("ETHBTC")
+	suite.Require().NoError(err)
+	suite.Require().Equal(map[valr.OrderType]bool{
+		valr.OrderTypeSimple: true}, types)
+}
+
+func (suite *pairRegistryTestSuite) TestValidateLimitOrder() {
+	testcases := []struct {
+		name string
+		req  valr.LimitOrderRequest
+		err  error
+	}{
+		{
+			name: "valid",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "200000",
+				Quantity: "0.01"},
+		},
+		{
+			name: "post only",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "200000",
+				Quantity: "0.01", PostOnly: true},
+		},
+		{
+			name: "unknown pair",
+			req: valr.LimitOrderRequest{Pair: "FOOBAR", Price: "1",
+				Quantity: "1"},
+			err: valr.ErrUnknownPair,
+		},
+		{
+			name: "inactive pair",
+			req: valr.LimitOrderRequest{Pair: "GRSBTC", Price: "1",
+				Quantity: "20"},
+			err: valr.ErrPairInactive,
+		},
+		{
+			name: "unsupported order type",
+			req: valr.LimitOrderRequest{Pair: "ETHBTC", Price: "0.03",
+				Quantity: "1"},
+			err: valr.ErrOrderTypeUnsupported,
+		},
+		{
+			name: "quantity too small",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "200000",
+				Quantity: "0.00005"},
+			err: valr.ErrAmountTooSmall,
+		},
+		{
+			name: "quantity too large",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "1",
+				Quantity: "3"},
+			err: valr.ErrAmountTooLarge,
+		},
+		{
+			name: "total too large",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "200000",
+				Quantity: "1"},
+			err: valr.ErrAmountTooLarge,
+		},
+		{
+			name: "total too small",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "1",
+				Quantity: "0.001"},
+			err: valr.ErrAmountTooSmall,
+		},
+		{
+			name: "invalid side",
+			req: valr.LimitOrderRequest{Side: "Sell", Pair: "BTCZAR",
+				Price: "200000", Quantity: "0.01"},
+			err: valr.ErrInvalidSide,
+		},
+		{
+			name: "invalid price",
+			req: valr.LimitOrderRequest{Pair: "BTCZAR", Price: "abc",
+				Quantity: "0.01"},
+			err: valr.ErrInvalidAmount,
+		},
+	}
+
+	for _, test := range testcases {
+		suite.T().Run(test.name, func(t *testing.T) {
+			if test.req.Side == "" {
+				test.req.Side = valr.SideBuy
+			}
+
+			err := suite.registry.ValidateLimitOrder(&test.req)
+			if test.err == nil {
+				suite.Require().NoError(err)
+				return
+			}
+
+			suite.Require().True(errors.Is(err, test.err), err)
+		})
+	}
+}
+
+func (suite *pairRegistryTestSuite) TestValidateMarketOrder() {
+	err := suite.registry.ValidateMarketOrder(&valr.MarketOrderRequest{
+		Side: valr.SideSell, Pair: "BTCZAR", BaseAmount: "0.5"})
+	suite.Require().NoError(err)
+
+	err = suite.registry.ValidateMarketOrder(&valr.MarketOrderRequest{
+		Side: valr.SideSell, Pair: "ETHBTC", BaseAmount: "1"})
+	suite.Require().True(errors.Is(err, valr.ErrOrderTypeUnsupported))
+
+	err = suite.registry.ValidateMarketOrder(&valr.MarketOrderRequest{
+		Side: valr.SideSell, Pair: "BTCZAR", BaseAmount: "0.00001"})
+	suite.Require().True(errors.Is(err, valr.ErrAmountTooSmall))
+	suite.Require().Equal("invalid base amount: amount below pair minimum: "+
+		"0.00001 BTC is less than 0.0001 BTC", err.Error())
+}
+
+func (suite *pairRegistryTestSuite) TestPrecision() {
+	suite.Require().NoError(suite.registry.ValidateQuantity("BTCZAR",
+		"0.12345678"))
+	err := suite.registry.ValidateQuantity("BTCZAR", "0.123456789")
+	suite.Require().True(errors.Is(err, valr.ErrPrecision))
+	suite.Require().Equal("amount exceeds pair precision: 0.123456789 has "+
+		"more than 8 decimal places", err.Error())
+
+	suite.Require().NoError(suite.registry.ValidatePrice("BTCZAR", "9000"))
+	err = suite.registry.ValidatePrice("BTCZAR", "9000.5")
+	suite.Require().True(errors.Is(err, valr.ErrPrecision))
+	suite.Require().NoError(suite.registry.ValidatePrice("ETHBTC",
+		"0.031234"))
+	err = suite.registry.ValidatePrice("ETHBTC", "0.0312345")
+	suite.Require().True(errors.Is(err, valr.ErrPrecision))
+
+	quantity, err := suite.registry.RoundQuantity("btczar",
+		big.NewRat(1, 3))
+	suite.Require().NoError(err)
+	suite.Require().Equal("0.33333333", quantity.FloatString(8))
+	suite.Require().Equal("33333333/100000000", quantity.RatString())
+
+	// Pairs which do not specify their precision are not constrained.
+	quantity, err = suite.registry.RoundQuantity("LTCBTC", big.NewRat(1, 3))
+	suite.Require().NoError(err)
+	suite.Require().Equal("1/3", quantity.RatString())
+	suite.Require().NoError(suite.registry.ValidatePrice("LTCBTC", "0.1234567"))
+
+	_, err = suite.registry.RoundQuantity("XYZ", big.NewRat(1, 3))
+	suite.Require().True(errors.Is(err, valr.ErrUnknownPair))
+}
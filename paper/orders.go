@@ -0,0 +1,407 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+	"github.com/nickcorin/valr/internal/exchange"
+)
+
+// LimitOrder satisfies the valr.PrivateClient interface. The order is matched
+// against the live order book as a taker, and any remainder rests until the
+// book trades through its price.
+func (c *Client) LimitOrder(ctx context.Context, req *valr.LimitOrderRequest) (
+	string, error) {
+
+	typ := valr.OrderTypeLimit
+	if req.PostOnly {
+		typ = valr.OrderTypePostOnly
+	}
+
+	o, book, err := c.newOrder(ctx, req.Pair, req.Side, typ, req.Price,
+		req.Quantity, req.CustomerOrderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to place limit order: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = c.account.Register(o); err != nil {
+		return "", fmt.Errorf("failed to place limit order: %w", err)
+	}
+
+	levels := c.levels(o.Pair.Symbol, book, o.Side)
+	if req.PostOnly && len(levels) > 0 && o.Crosses(levels[0].Price) {
+		c.account.Fail(o, exchange.ReasonPostOnly)
+		return o.ID, nil
+	}
+
+	if !c.account.Reserve(o) {
+		c.account.Fail(o, exchange.ReasonInsufficientBalance)
+		return o.ID, nil
+	}
+
+	c.account.Take(o, levels, c.takerFee)
+	return o.ID, nil
+}
+
+// MarketOrder satisfies the valr.PrivateClient interface. The order is filled
+// against the live order book, and any quantity which cannot be filled or
+// afforded is cancelled.
+func (c *Client) MarketOrder(ctx context.Context,
+	req *valr.MarketOrderRequest) (string, error) {
+
+	o, book, err := c.newOrder(ctx, req.Pair, req.Side, valr.OrderTypeMarket,
+		"", req.BaseAmount, req.CustomerOrderID)
+	if err != nil {
+		return "", fmt.Errorf("failed to place market order: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err = c.account.Register(o); err != nil {
+		return "", fmt.Errorf("failed to place market order: %w", err)
+	}
+
+	c.account.Execute(o, c.levels(o.Pair.Symbol, book, o.Side), c.takerFee)
+	return o.ID, nil
+}
+
+// SimpleOrder satisfies the valr.PrivateClient interface. Buys pay the amount
+// in the pair's quote currency and sells pay it in the base currency. Simple
+// orders are filled like market orders.
+func (c *Client) SimpleOrder(ctx context.Context,
+	req *valr.SimpleOrderRequest) (string, error) {
+
+	registry, err := c.pairs(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w", err)
+	}
+
+	pair, err := registry.Parse(req.Pair)
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w",
+			exchange.BadRequest(err.Error()))
+	}
+
+	pay := string(pair.Base)
+	if req.Side == valr.SideBuy {
+		pay = string(pair.Quote)
+	}
+
+	if !strings.EqualFold(req.QuoteCurrency, pay) {
+		return "", fmt.Errorf("failed to place simple order: %w",
+			exchange.BadRequest("Pay in currency must be "+pay))
+	}
+
+	book, err := c.OrderBook(ctx, pair.Symbol)
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w", err)
+	}
+
+	amount, err := decimal.ParsePositive(req.Amount)
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w",
+			exchange.BadRequest("Invalid amount: "+err.Error()))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	levels := c.levels(pair.Symbol, book, req.Side)
+
+	// Buys are converted into the quantity the amount buys from the book,
+	// falling back to the amount itself if there is no liquidity so that the
+	// order fails.
+	quantity := req.Amount
+	if req.Side == valr.SideBuy {
+		if base := exchange.Purchasable(levels, amount); base.Sign() > 0 {
+			quantity = decimal.Format(base)
+		}
+	}
+
+	o, err := exchange.NewOrder(pair, req.Side, valr.OrderTypeSimple, "",
+		quantity, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w", err)
+	}
+
+	if err = c.account.Register(o); err != nil {
+		return "", fmt.Errorf("failed to place simple order: %w", err)
+	}
+
+	c.account.Execute(o, levels, c.takerFee)
+	return o.ID, nil
+}
+
+// CancelOrder satisfies the valr.PrivateClient interface. Cancellation takes
+// effect immediately. Cancelling an order which is already done has no
+// effect.
+func (c *Client) CancelOrder(ctx context.Context,
+	req *valr.CancelOrderRequest) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, err := c.account.Lookup(req.Pair, req.OrderID, req.CustomerOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
+	}
+
+	c.account.Cancel(o)
+	return nil
+}
+
+// OpenOrders satisfies the valr.PrivateClient interface. Orders are sorted by
+// creation time.
+func (c *Client) OpenOrders(ctx context.Context) ([]valr.OpenOrder, error) {
+	if err := c.sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch open orders: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	orders := []valr.OpenOrder{}
+	for _, o := range c.account.Open("") {
+		orders = append(orders, o.OpenOrder())
+	}
+
+	return orders, nil
+}
+
+// OrderStatus satisfies the valr.PrivateClient interface.
+func (c *Client) OrderStatus(ctx context.Context,
+	req *valr.OrderStatusRequest) (*valr.OrderInfo, error) {
+
+	if err := c.sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch order status: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	o, err := c.account.Lookup(req.Pair, req.OrderID, req.CustomerOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch order status: %w", err)
+	}
+
+	return o.Info(), nil
+}
+
+// newOrder validates the parameters of an order and fetches the order book it
+// will be matched against.
+func (c *Client) newOrder(ctx context.Context, symbol string, side valr.Side,
+	typ valr.OrderType, price, quantity, customerID string) (*exchange.Order,
+	*valr.OrderBook, error) {
+
+	registry, err := c.pairs(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pair, err := registry.Parse(symbol)
+	if err != nil {
+		return nil, nil, exchange.BadRequest(err.Error())
+	}
+
+	o, err := exchange.NewOrder(pair, side, typ, price, quantity, customerID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	book, err := c.OrderBook(ctx, pair.Symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return o, book, nil
+}
+
+// liquidity identifies a price level on one side of a pair's order book.
+type liquidity struct {
+	pair  string
+	price string
+	side  valr.Side
+}
+
+// levels returns the levels of a pair's book which an order on side takes
+// liquidity from, less any quantity the account has already taken from them.
+//
+// The live order book does not reflect the account's own trades, so taken
+// liquidity is remembered until the level shrinks below it or leaves the book.
+func (c *Client) levels(pair string, book *valr.OrderBook,
+	side valr.Side) []*exchange.Level {
+
+	entries := book.Bids
+	if side == valr.SideBuy {
+		entries = book.Asks
+	}
+
+	live := make(map[liquidity]bool)
+
+	var levels []*exchange.Level
+	for _, entry := range entries {
+		price, ok := new(big.Rat).SetString(entry.Price)
+		if !ok || price.Sign() <= 0 {
+			continue
+		}
+
+		quantity, ok := new(big.Rat).SetString(entry.Quantity)
+		if !ok || quantity.Sign() <= 0 {
+			continue
+		}
+
+		key := liquidity{pair: pair, price: price.RatString(), side: side}
+		live[key] = true
+
+		if taken, ok := c.taken[key]; ok {
+			if taken.Cmp(quantity) > 0 {
+				taken.Set(quantity)
+			}
+			quantity.Sub(quantity, taken)
+		}
+
+		if quantity.Sign() > 0 {
+			levels = append(levels, &exchange.Level{
+				Price:    price,
+				Quantity: quantity,
+				Taken: func(quantity *big.Rat) {
+					c.consume(key, quantity)
+				},
+			})
+		}
+	}
+
+	for key := range c.taken {
+		if key.pair == pair && key.side == side && !live[key] {
+			delete(c.taken, key)
+		}
+	}
+
+	return levels
+}
+
+// consume remembers that quantity has been taken from a level.
+func (c *Client) consume(key liquidity, quantity *big.Rat) {
+	taken, ok := c.taken[key]
+	if !ok {
+		taken = new(big.Rat)
+		c.taken[key] = taken
+	}
+	taken.Add(taken, quantity)
+}
+
+// recordFill records the transaction of a fill of one of the account's
+// orders.
+func (c *Client) recordFill(f exchange.Fill) {
+	o := f.Order
+
+	tx := valr.Transaction{
+		AdditionalInfo: &valr.TransactionInfo{
+			CostPerCoinSymbol:  string(o.Pair.Quote),
+			CurrencyPairSymbol: o.Pair.Symbol,
+			OrderID:            o.ID,
+		},
+		EventAt:     f.Time,
+		FeeCurrency: o.FeeCurrency(),
+		FeeValue:    decimal.Format(f.Fee),
+		TypeInfo:    &valr.TransactionTypeInfo{Type: transactionType(o)},
+	}
+	tx.AdditionalInfo.CostPerCoin, _ = f.Price.Float64()
+
+	if o.Side == valr.SideBuy {
+		tx.DebitCurrency = string(o.Pair.Quote)
+		tx.DebitValue = decimal.Format(f.Cost)
+		tx.CreditCurrency, tx.CreditValue = string(o.Pair.Base),
+			decimal.Format(f.Received)
+	} else {
+		tx.DebitCurrency, tx.DebitValue = string(o.Pair.Base),
+			decimal.Format(f.Quantity)
+		tx.CreditCurrency, tx.CreditValue = string(o.Pair.Quote),
+			decimal.Format(f.Received)
+	}
+
+	c.record(tx)
+}
+
+// transactionType returns the type of the transactions recorded when an order
+// is filled.
+func transactionType(o *exchange.Order) valr.TransactionType {
+	switch {
+	case o.Type == valr.OrderTypeMarket && o.Side == valr.SideBuy:
+		return valr.TransactionTypeMarketBuy
+	case o.Type == valr.OrderTypeMarket:
+		return valr.TransactionTypeMarketSell
+	case o.Type == valr.OrderTypeSimple && o.Side == valr.SideBuy:
+		return valr.TransactionTypeSimpleBuy
+	case o.Type == valr.OrderTypeSimple:
+		return valr.TransactionTypeSimpleSell
+	case o.Side == valr.SideBuy:
+		return valr.TransactionTypeLimitBuy
+	default:
+		return valr.TransactionTypeLimitSell
+	}
+}
+
+// sync fills resting orders as makers against the live order book of their
+// pairs. An order is filled at its own price when the opposite side of the
+// book trades through it, with the liquidity of each level shared between
+// orders by price and then time priority.
+func (c *Client) sync(ctx context.Context) error {
+	c.mu.Lock()
+	pairs := make(map[string]bool)
+	for _, o := range c.account.Open("") {
+		pairs[o.Pair.Symbol] = true
+	}
+	c.mu.Unlock()
+
+	for pair := range pairs {
+		book, err := c.OrderBook(ctx, pair)
+		if err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		c.match(pair, book)
+		c.mu.Unlock()
+	}
+
+	return nil
+}
+
+// match fills the resting orders of a pair against book.
+func (c *Client) match(pair string, book *valr.OrderBook) {
+	for _, side := range []valr.Side{valr.SideBuy, valr.SideSell} {
+		var orders []*exchange.Order
+		for _, o := range c.account.Open(pair) {
+			if o.Side == side {
+				orders = append(orders, o)
+			}
+		}
+
+		// Better prices have priority, followed by earlier orders.
+		sort.SliceStable(orders, func(i, j int) bool {
+			cmp := orders[i].Price.Cmp(orders[j].Price)
+			if side == valr.SideSell {
+				cmp = -cmp
+			}
+			return cmp > 0
+		})
+
+		levels := c.levels(pair, book, side)
+		for _, o := range orders {
+			o := o
+			exchange.Match(o, levels, func(quantity, _ *big.Rat) {
+				c.account.Fill(o, quantity, o.Price, c.makerFee)
+			})
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package paper provides a paper trading implementation of valr.Client, which
+// allows strategies to run against live market data without risking funds.
+//
+// Public methods are delegated to a real valr.PublicClient. Balances, orders,
+// trades and transactions are simulated in memory, with orders filled against
+// the live order book at the time they are placed. Limit orders which do not
+// fill immediately rest until the live order book trades through their price,
+// which is checked whenever the account is queried. Liquidity taken from the
+// live order book is not available to later orders until it is replenished.
+package paper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+	"github.com/nickcorin/valr/internal/exchange"
+)
+
+// Default fee rates, as a fraction of the amount received.
+const (
+	DefaultMakerFee = "0"
+	DefaultTakerFee = "0.001"
+)
+
+// ErrNotSupported is returned by methods which cannot be simulated.
+var ErrNotSupported = errors.New("paper: not supported when paper trading")
+
+// Client is a paper trading valr.Client. It is safe for concurrent use.
+type Client struct {
+	valr.PublicClient
+
+	mu sync.Mutex
+
+	account      *exchange.Account
+	deposits     []deposit
+	makerFee     *big.Rat
+	now          func() time.Time
+	registry     *valr.PairRegistry
+	taken        map[liquidity]*big.Rat
+	takerFee     *big.Rat
+	transactions []valr.Transaction
+}
+
+var _ valr.Client = (*Client)(nil)
+
+type deposit struct {
+	amount   string
+	currency string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBalance deposits an amount of a currency into the account when it is
+// created.
+func WithBalance(currency, amount string) Option {
+	return func(c *Client) {
+		c.deposits = append(c.deposits, deposit{amount: amount,
+			currency: currency})
+	}
+}
+
+// WithFees sets the maker and taker fee rates, as a fraction of the amount
+// received, e.g. "0.001" for 0.1%. A negative maker fee is a rebate.
+func WithFees(maker, taker string) Option {
+	return func(c *Client) {
+		c.makerFee, _ = new(big.Rat).SetString(maker)
+		c.takerFee, _ = new(big.Rat).SetString(taker)
+	}
+}
+
+// WithClock sets the function used to timestamp orders, trades and
+// transactions.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.now = now
+	}
+}
+
+// New returns a paper trading Client which fetches market data from public.
+func New(public valr.PublicClient, opts ...Option) (*Client, error) {
+	c := Client{
+		PublicClient: public,
+		now:          time.Now,
+		taken:        make(map[liquidity]*big.Rat),
+	}
+	c.makerFee, _ = new(big.Rat).SetString(DefaultMakerFee)
+	c.takerFee, _ = new(big.Rat).SetString(DefaultTakerFee)
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	if c.makerFee == nil || c.takerFee == nil {
+		return nil, errors.New("paper: invalid fee rate")
+	}
+
+	c.account = exchange.NewAccount(c.now)
+	c.account.OnFill = c.recordFill
+
+	for _, d := range c.deposits {
+		if err := c.Deposit(d.currency, d.amount); err != nil {
+			return nil, err
+		}
+	}
+	c.deposits = nil
+
+	return &c, nil
+}
+
+// Deposit credits an amount of a currency to the account, recording a deposit
+// transaction.
+func (c *Client) Deposit(currency, amount string) error {
+	value, err := decimal.ParsePositive(amount)
+	if err != nil {
+		return fmt.Errorf("paper: invalid deposit amount: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	currency = strings.ToUpper(currency)
+	now := c.account.Now()
+
+	b := c.account.Balance(currency)
+	b.Available.Add(b.Available, value)
+	b.UpdatedAt = now
+
+	typ := valr.TransactionTypeBlockchainReceive
+	if currency == "ZAR" {
+		typ = valr.TransactionTypeFiatDeposit
+	}
+
+	c.record(valr.Transaction{
+		CreditCurrency: currency,
+		CreditValue:    decimal.Format(value),
+		EventAt:        now,
+		TypeInfo:       &valr.TransactionTypeInfo{Type: typ},
+	})
+
+	return nil
+}
+
+// Balances satisfies the valr.PrivateClient interface. Balances are sorted by
+// currency.
+func (c *Client) Balances(ctx context.Context) ([]valr.Balance, error) {
+	if err := c.sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch balances: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	balances := c.account.Balances()
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Currency < balances[j].Currency
+	})
+
+	return balances, nil
+}
+
+// TradeHistory satisfies the valr.PrivateClient interface.
+func (c *Client) TradeHistory(ctx context.Context, pair string) ([]valr.Trade,
+	error) {
+
+	if err := c.sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch trade history: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.account.TradeHistory(pair), nil
+}
+
+// TransactionHistory satisfies the valr.PrivateClient interface.
+// Transactions are returned most recent first, filtered and paged by req.
+func (c *Client) TransactionHistory(ctx context.Context,
+	req *valr.TransactionHistoryRequest) ([]valr.Transaction, error) {
+
+	if err := c.sync(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch transaction history: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var r valr.TransactionHistoryRequest
+	if req != nil {
+		r = *req
+	}
+
+	limit := r.Limit
+	if limit <= 0 || limit > valr.MaxTransactionHistoryLimit {
+		limit = valr.MaxTransactionHistoryLimit
+	}
+
+	types := make(map[valr.TransactionType]bool)
+	for _, typ := range r.Types {
+		types[typ] = true
+	}
+
+	i := len(c.transactions) - 1
+	if r.BeforeID != "" {
+		for i >= 0 && c.transactions[i].ID != r.BeforeID {
+			i--
+		}
+		i--
+	}
+
+	transactions := []valr.Transaction{}
+	for skipped := 0; i >= 0 && len(transactions) < limit; i-- {
+		tx := c.transactions[i]
+
+		switch {
+		case r.Currency != "" && !strings.EqualFold(r.Currency,
+			tx.CreditCurrency) && !strings.EqualFold(r.Currency,
+			tx.DebitCurrency) && !strings.EqualFold(r.Currency,
+			tx.FeeCurrency):
+			continue
+		case len(types) > 0 && !types[tx.TypeInfo.Type]:
+			continue
+		case !r.StartTime.IsZero() && tx.EventAt.Before(r.StartTime):
+			continue
+		case !r.EndTime.IsZero() && !tx.EventAt.Before(r.EndTime):
+			continue
+		case skipped < r.Offset:
+			skipped++
+			continue
+		}
+
+		transactions = append(transactions, tx)
+	}
+
+	return transactions, nil
+}
+
+// DepositAddress satisfies the valr.PrivateClient interface. It always returns
+// ErrNotSupported.
+func (c *Client) DepositAddress(ctx context.Context, currency string) (
+	*valr.DepositAddress, error) {
+	return nil, ErrNotSupported
+}
+
+// WithdrawalInfo satisfies the valr.PrivateClient interface. It always returns
+// ErrNotSupported.
+func (c *Client) WithdrawalInfo(ctx context.Context, currency string) (
+	*valr.WithdrawalInfo, error) {
+	return nil, ErrNotSupported
+}
+
+// record appends a transaction to the account's history, assigning it an ID.
+func (c *Client) record(tx valr.Transaction) {
+	tx.ID = fmt.Sprintf("%d", len(c.transactions)+1)
+	tx.TypeInfo.Description = describe(tx.TypeInfo.Type)
+	c.transactions = append(c.transactions, tx)
+}
+
+// describe returns the description VALR gives a transaction type, e.g. "Limit
+// Buy" for LIMIT_BUY.
+func describe(typ valr.TransactionType) string {
+	words := strings.Split(strings.ToLower(string(typ)), "_")
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// pairs returns the registry of currency pairs, loading it on first use.
+func (c *Client) pairs(ctx context.Context) (*valr.PairRegistry, error) {
+	c.mu.Lock()
+	registry := c.registry
+	c.mu.Unlock()
+
+	if registry != nil {
+		return registry, nil
+	}
+
+	pairs, err := c.CurrencyPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry = valr.NewPairRegistry(pairs, nil)
+
+	c.mu.Lock()
+	c.registry = registry
+	c.mu.Unlock()
+
+	return registry, nil
+}
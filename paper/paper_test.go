@@ -0,0 +1,353 @@
+package paper_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/mock"
+	"github.com/nickcorin/valr/paper"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestPaperTestSuite(t *testing.T) {
+	suite.Run(t, new(paperTestSuite))
+}
+
+type paperTestSuite struct {
+	suite.Suite
+	now    time.Time
+	server *mock.Server
+}
+
+func (suite *paperTestSuite) SetupTest() {
+	suite.now = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	suite.server = mock.NewServer()
+}
+
+func (suite *paperTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+// newClient returns a paper trading client backed by the mock server's public
+// endpoints.
+func (suite *paperTestSuite) newClient(opts ...paper.Option) *paper.Client {
+	opts = append([]paper.Option{paper.WithClock(func() time.Time {
+		return suite.now
+	})}, opts...)
+
+	c, err := paper.New(valr.NewPublicClient(
+		valr.WithBaseURL(suite.server.URL)), opts...)
+	suite.Require().NoError(err)
+
+	return c
+}
+
+// balances returns the account's available and reserved balances by currency.
+func (suite *paperTestSuite) balances(c *paper.Client) (map[string]string,
+	map[string]string) {
+
+	balances, err := c.Balances(context.TODO())
+	suite.Require().NoError(err)
+
+	available, reserved := make(map[string]string), make(map[string]string)
+	for _, b := range balances {
+		available[b.Currency] = b.Available
+		reserved[b.Currency] = b.Reserved
+	}
+
+	return available, reserved
+}
+
+func (suite *paperTestSuite) status(c *paper.Client,
+	id string) *valr.OrderInfo {
+
+	info, err := c.OrderStatus(context.TODO(), &valr.OrderStatusRequest{
+		OrderID: id,
+		Pair:    "BTCZAR",
+	})
+	suite.Require().NoError(err)
+
+	return info
+}
+
+func (suite *paperTestSuite) TestPublicMethodsAreDelegated() {
+	c := suite.newClient()
+
+	book, err := c.OrderBook(context.TODO(), "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Equal("9000", book.Asks[0].Price)
+	suite.Require().Equal(1, suite.server.Calls("OrderBook"))
+}
+
+func (suite *paperTestSuite) TestDeposit() {
+	c := suite.newClient(paper.WithBalance("zar", "1000"))
+	suite.Require().NoError(c.Deposit("BTC", "0.5"))
+	suite.Require().Error(c.Deposit("BTC", "-1"))
+
+	available, _ := suite.balances(c)
+	suite.Require().Equal(map[string]string{"BTC": "0.5", "ZAR": "1000"},
+		available)
+
+	txs, err := c.TransactionHistory(context.TODO(), nil)
+	suite.Require().NoError(err)
+	suite.Require().Len(txs, 2)
+	suite.Require().Equal(valr.TransactionTypeBlockchainReceive,
+		txs[0].TypeInfo.Type)
+	suite.Require().Equal(valr.TransactionTypeFiatDeposit,
+		txs[1].TypeInfo.Type)
+	suite.Require().Equal("Fiat Deposit", txs[1].TypeInfo.Description)
+}
+
+func (suite *paperTestSuite) TestLimitOrderRestsAndFills() {
+	c := suite.newClient(paper.WithBalance("ZAR", "10000"))
+
+	id, err := c.LimitOrder(context.TODO(), &valr.LimitOrderRequest{
+		CustomerOrderID: "order-1",
+		Pair:            "BTCZAR",
+		Price:           "9500",
+		Quantity:        "0.2",
+		Side:            valr.SideBuy,
+	})
+	suite.Require().NoError(err)
+
+	// 0.101 BTC is taken from the book at 9000, with the taker fee charged in
+	// BTC, and the remaining 0.099 BTC rests at 9500.
+	info := suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusPartiallyFilled, info.Status)
+	suite.Require().Equal("0.099", info.RemainingQuantity)
+	suite.Require().Equal("9000", info.AveragePrice)
+	suite.Require().Equal("0.000101", info.TotalFee)
+	suite.Require().Equal("BTC", info.FeeCurrency)
+
+	available, reserved := suite.balances(c)
+	suite.Require().Equal("0.100899", available["BTC"])
+	suite.Require().Equal("8150.5", available["ZAR"])
+	suite.Require().Equal("940.5", reserved["ZAR"])
+
+	orders, err := c.OpenOrders(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Len(orders, 1)
+	suite.Require().Equal("order-1", orders[0].CustomerOrderID)
+	suite.Require().Equal("50.50", orders[0].FilledPercentage)
+
+	// The resting order fills at its own price once the book trades through
+	// it, with no maker fee.
+	suite.server.Exchange.AddLiquidity("BTCZAR", valr.SideSell, "9400", "1")
+
+	info = suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal("0", info.RemainingQuantity)
+
+	available, reserved = suite.balances(c)
+	suite.Require().Equal("0.199899", available["BTC"])
+	suite.Require().Equal("8150.5", available["ZAR"])
+	suite.Require().Equal("0", reserved["ZAR"])
+
+	trades, err := c.TradeHistory(context.TODO(), "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Len(trades, 2)
+	suite.Require().Equal("9500", trades[0].Price)
+	suite.Require().Equal("0.099", trades[0].Quantity)
+	suite.Require().Equal("9000", trades[1].Price)
+
+	txs, err := c.TransactionHistory(context.TODO(),
+		&valr.TransactionHistoryRequest{
+			Types: []valr.TransactionType{valr.TransactionTypeLimitBuy},
+		})
+	suite.Require().NoError(err)
+	suite.Require().Len(txs, 2)
+	suite.Require().Equal("940.5", txs[0].DebitValue)
+	suite.Require().Equal("0.099", txs[0].CreditValue)
+	suite.Require().Equal("0", txs[0].FeeValue)
+	suite.Require().Equal("909", txs[1].DebitValue)
+	suite.Require().Equal("0.100899", txs[1].CreditValue)
+	suite.Require().Equal(id, txs[1].AdditionalInfo.OrderID)
+}
+
+func (suite *paperTestSuite) TestLimitOrderFailures() {
+	c := suite.newClient(paper.WithBalance("ZAR", "100"))
+
+	id, err := c.LimitOrder(context.TODO(), &valr.LimitOrderRequest{
+		Pair:     "BTCZAR",
+		PostOnly: true,
+		Price:    "9000",
+		Quantity: "0.01",
+		Side:     valr.SideBuy,
+	})
+	suite.Require().NoError(err)
+
+	info := suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusFailed, info.Status)
+	suite.Require().Contains(info.FailedReason, "Post only")
+
+	id, err = c.LimitOrder(context.TODO(), &valr.LimitOrderRequest{
+		Pair:     "BTCZAR",
+		Price:    "8000",
+		Quantity: "0.1",
+		Side:     valr.SideBuy,
+	})
+	suite.Require().NoError(err)
+
+	info = suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusFailed, info.Status)
+	suite.Require().Equal("Insufficient Balance", info.FailedReason)
+
+	_, err = c.LimitOrder(context.TODO(), &valr.LimitOrderRequest{
+		Pair:     "BTCZAR",
+		Price:    "-1",
+		Quantity: "0.1",
+		Side:     valr.SideBuy,
+	})
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusBadRequest, apiErr.StatusCode)
+}
+
+func (suite *paperTestSuite) TestCancelOrder() {
+	c := suite.newClient(paper.WithBalance("BTC", "1"))
+
+	_, err := c.LimitOrder(context.TODO(), &valr.LimitOrderRequest{
+		CustomerOrderID: "ask",
+		Pair:            "BTCZAR",
+		Price:           "20000",
+		Quantity:        "0.4",
+		Side:            valr.SideSell,
+	})
+	suite.Require().NoError(err)
+
+	available, reserved := suite.balances(c)
+	suite.Require().Equal("0.6", available["BTC"])
+	suite.Require().Equal("0.4", reserved["BTC"])
+
+	err = c.CancelOrder(context.TODO(), &valr.CancelOrderRequest{
+		CustomerOrderID: "ask",
+		Pair:            "BTCZAR",
+	})
+	suite.Require().NoError(err)
+
+	available, reserved = suite.balances(c)
+	suite.Require().Equal("1", available["BTC"])
+	suite.Require().Equal("0", reserved["BTC"])
+
+	orders, err := c.OpenOrders(context.TODO())
+	suite.Require().NoError(err)
+	suite.Require().Empty(orders)
+
+	_, err = c.OrderStatus(context.TODO(), &valr.OrderStatusRequest{
+		OrderID: "unknown",
+		Pair:    "BTCZAR",
+	})
+	var apiErr *valr.Error
+	suite.Require().True(errors.As(err, &apiErr))
+	suite.Require().Equal(http.StatusNotFound, apiErr.StatusCode)
+}
+
+func (suite *paperTestSuite) TestMarketOrder() {
+	c := suite.newClient(paper.WithBalance("BTC", "0.25"))
+
+	id, err := c.MarketOrder(context.TODO(), &valr.MarketOrderRequest{
+		BaseAmount: "0.25",
+		Pair:       "BTCZAR",
+		Side:       valr.SideSell,
+	})
+	suite.Require().NoError(err)
+
+	// 0.1 BTC fills at 8802 and 0.15 BTC at 8801, with the taker fee charged
+	// in ZAR.
+	info := suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusFilled, info.Status)
+	suite.Require().Equal("2.20035", info.TotalFee)
+	suite.Require().Equal("ZAR", info.FeeCurrency)
+
+	available, _ := suite.balances(c)
+	suite.Require().Equal("0", available["BTC"])
+	suite.Require().Equal("2198.14965", available["ZAR"])
+
+	id, err = c.MarketOrder(context.TODO(), &valr.MarketOrderRequest{
+		BaseAmount: "0.1",
+		Pair:       "BTCZAR",
+		Side:       valr.SideSell,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderStatusFailed, suite.status(c, id).Status)
+}
+
+func (suite *paperTestSuite) TestMarketBuyIsLimitedByBalance() {
+	c := suite.newClient(paper.WithBalance("ZAR", "450"),
+		paper.WithFees("0", "0"))
+
+	id, err := c.MarketOrder(context.TODO(), &valr.MarketOrderRequest{
+		BaseAmount: "1",
+		Pair:       "BTCZAR",
+		Side:       valr.SideBuy,
+	})
+	suite.Require().NoError(err)
+
+	info := suite.status(c, id)
+	suite.Require().Equal(valr.OrderStatusCancelled, info.Status)
+	suite.Require().Equal("0.95", info.RemainingQuantity)
+
+	available, _ := suite.balances(c)
+	suite.Require().Equal("0.05", available["BTC"])
+	suite.Require().Equal("0", available["ZAR"])
+}
+
+func (suite *paperTestSuite) TestTakenLiquidityIsNotReused() {
+	c := suite.newClient(paper.WithBalance("ZAR", "10000"),
+		paper.WithFees("0", "0"))
+
+	for _, price := range []string{"9000", "10000"} {
+		_, err := c.MarketOrder(context.TODO(), &valr.MarketOrderRequest{
+			BaseAmount: "0.101",
+			Pair:       "BTCZAR",
+			Side:       valr.SideBuy,
+		})
+		suite.Require().NoError(err)
+
+		trades, err := c.TradeHistory(context.TODO(), "BTCZAR")
+		suite.Require().NoError(err)
+		suite.Require().Equal(price, trades[0].Price)
+	}
+}
+
+func (suite *paperTestSuite) TestSimpleOrder() {
+	c := suite.newClient(paper.WithBalance("ZAR", "100"))
+
+	id, err := c.SimpleOrder(context.TODO(), &valr.SimpleOrderRequest{
+		Amount:        "45",
+		Pair:          "BTCZAR",
+		QuoteCurrency: "ZAR",
+		Side:          valr.SideBuy,
+	})
+	suite.Require().NoError(err)
+	suite.Require().Equal(valr.OrderStatusFilled, suite.status(c, id).Status)
+
+	available, _ := suite.balances(c)
+	suite.Require().Equal("0.004995", available["BTC"])
+	suite.Require().Equal("55", available["ZAR"])
+
+	txs, err := c.TransactionHistory(context.TODO(),
+		&valr.TransactionHistoryRequest{Currency: "BTC"})
+	suite.Require().NoError(err)
+	suite.Require().Len(txs, 1)
+	suite.Require().Equal(valr.TransactionTypeSimpleBuy, txs[0].TypeInfo.Type)
+
+	_, err = c.SimpleOrder(context.TODO(), &valr.SimpleOrderRequest{
+		Amount:        "45",
+		Pair:          "BTCZAR",
+		QuoteCurrency: "BTC",
+		Side:          valr.SideBuy,
+	})
+	suite.Require().Error(err)
+}
+
+func (suite *paperTestSuite) TestNotSupported() {
+	c := suite.newClient()
+
+	_, err := c.DepositAddress(context.TODO(), "BTC")
+	suite.Require().True(errors.Is(err, paper.ErrNotSupported))
+}
@@ -0,0 +1,391 @@
+// Package pnl tracks the realized and unrealized profit and loss of trading
+// on VALR per currency pair, along with the fees paid.
+package pnl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// ZAR is the currency into which all results are converted.
+const ZAR = "ZAR"
+
+// ErrNoPrice is returned when a price required to mark a position or convert
+// a result into ZAR is not available.
+var ErrNoPrice = errors.New("no price available")
+
+// PnL contains the profit and loss for a single currency pair. Amounts are in
+// the pair's quote currency unless suffixed with ZAR. ZAR amounts are
+// converted at the current price of the quote currency.
+type PnL struct {
+	// AverageEntry is the average price at which the open position was
+	// entered.
+	AverageEntry *big.Rat
+
+	// Fees is the total fees paid, which are not deducted from Realized.
+	Fees    *big.Rat
+	FeesZAR *big.Rat
+
+	// MarkPrice is the last traded price used to value the open position. It
+	// is zero when the position is flat and no price is available.
+	MarkPrice *big.Rat
+
+	Pair valr.Pair
+
+	// Position is the quantity of the base currency held, which is negative
+	// when short.
+	Position *big.Rat
+
+	Realized      *big.Rat
+	RealizedZAR   *big.Rat
+	Unrealized    *big.Rat
+	UnrealizedZAR *big.Rat
+}
+
+// Net returns the realized and unrealized PnL less fees.
+func (p PnL) Net() *big.Rat {
+	net := new(big.Rat).Add(p.Realized, p.Unrealized)
+	return net.Sub(net, p.Fees)
+}
+
+// NetZAR returns the realized and unrealized PnL less fees in ZAR.
+func (p PnL) NetZAR() *big.Rat {
+	net := new(big.Rat).Add(p.RealizedZAR, p.UnrealizedZAR)
+	return net.Sub(net, p.FeesZAR)
+}
+
+// position is the running state of a single pair.
+type position struct {
+	entry    *big.Rat
+	fees     *big.Rat
+	pair     valr.Pair
+	quantity *big.Rat
+	realized *big.Rat
+}
+
+// Tracker accumulates trades and fees into a position per pair. Trades must be
+// added in the order in which they were executed. A Tracker only knows about
+// the trades it is given, so a strategy's PnL can be tracked by adding only
+// that strategy's trades. A Tracker is not safe for concurrent use.
+type Tracker struct {
+	positions map[string]*position
+	registry  *valr.PairRegistry
+}
+
+// NewTracker returns an empty Tracker which uses registry to split pair
+// symbols into their base and quote currencies.
+func NewTracker(registry *valr.PairRegistry) *Tracker {
+	return &Tracker{
+		positions: make(map[string]*position),
+		registry:  registry,
+	}
+}
+
+// Load returns a Tracker populated with the account's recent trades for each
+// of the given pairs, along with the fees paid on each pair since its earliest
+// trade.
+func Load(ctx context.Context, c valr.Client, registry *valr.PairRegistry,
+	pairs ...string) (*Tracker, error) {
+
+	var trades []valr.Trade
+	for _, pair := range pairs {
+		pairTrades, err := c.TradeHistory(ctx, pair)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch trades for %s: %w", pair,
+				err)
+		}
+		trades = append(trades, pairTrades...)
+	}
+
+	// Trade history is returned most recent first.
+	sort.SliceStable(trades, func(i, j int) bool {
+		return trades[i].TradedAt.Before(trades[j].TradedAt)
+	})
+
+	t := NewTracker(registry)
+	for _, trade := range trades {
+		if err := t.AddTrade(trade); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(trades) == 0 {
+		return t, nil
+	}
+
+	// Fees are only counted from each pair's earliest trade, as older trades
+	// are not part of the position.
+	starts := make(map[string]time.Time)
+	for _, trade := range trades {
+		if _, ok := starts[trade.CurrencyPair]; !ok {
+			starts[trade.CurrencyPair] = trade.TradedAt
+		}
+	}
+
+	transactions, err := valr.AllTransactions(ctx, c,
+		&valr.TransactionHistoryRequest{
+			StartTime: trades[0].TradedAt.Add(-time.Second),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	for _, tx := range transactions {
+		if tx.AdditionalInfo == nil {
+			continue
+		}
+
+		start, ok := starts[tx.AdditionalInfo.CurrencyPairSymbol]
+		if !ok || tx.EventAt.Before(start) {
+			continue
+		}
+
+		if err := t.AddFee(tx); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// position returns the position for a pair, creating it if required.
+func (t *Tracker) position(symbol string) (*position, error) {
+	pair, err := t.registry.Parse(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := t.positions[pair.Symbol]
+	if !ok {
+		p = &position{
+			entry:    new(big.Rat),
+			fees:     new(big.Rat),
+			pair:     pair,
+			quantity: new(big.Rat),
+			realized: new(big.Rat),
+		}
+		t.positions[pair.Symbol] = p
+	}
+
+	return p, nil
+}
+
+// AddTrade applies a trade to its pair's position. Trades which reduce the
+// position realize PnL against the average entry price. Trades which flip the
+// position open the remainder at the trade price.
+func (t *Tracker) AddTrade(trade valr.Trade) error {
+	p, err := t.position(trade.CurrencyPair)
+	if err != nil {
+		return err
+	}
+
+	if err = trade.Side.Validate(); err != nil {
+		return fmt.Errorf("invalid trade %d: %w", trade.ID, err)
+	}
+
+	price, err := decimal.ParsePositive(trade.Price)
+	if err != nil {
+		return fmt.Errorf("invalid trade %d price: %w", trade.ID, err)
+	}
+
+	quantity, err := decimal.ParsePositive(trade.Quantity)
+	if err != nil {
+		return fmt.Errorf("invalid trade %d quantity: %w", trade.ID, err)
+	}
+
+	// Work with a signed quantity so that buys and sells are symmetric.
+	if trade.Side == valr.SideSell {
+		quantity.Neg(quantity)
+	}
+
+	// Any part of the trade in the opposite direction to the position closes
+	// it.
+	if p.quantity.Sign() != 0 && p.quantity.Sign() != quantity.Sign() {
+		closed := new(big.Rat).Abs(quantity)
+		if held := new(big.Rat).Abs(p.quantity); closed.Cmp(held) > 0 {
+			closed = held
+		}
+
+		gain := new(big.Rat).Sub(price, p.entry)
+		gain.Mul(gain, closed)
+		if p.quantity.Sign() < 0 {
+			gain.Neg(gain)
+		}
+		p.realized.Add(p.realized, gain)
+
+		if quantity.Sign() < 0 {
+			closed.Neg(closed)
+		}
+		p.quantity.Add(p.quantity, closed)
+		quantity.Sub(quantity, closed)
+	}
+
+	if quantity.Sign() == 0 {
+		if p.quantity.Sign() == 0 {
+			p.entry.SetInt64(0)
+		}
+		return nil
+	}
+
+	// The remainder opens or adds to the position at a weighted average entry.
+	held := new(big.Rat).Abs(p.quantity)
+	added := new(big.Rat).Abs(quantity)
+
+	cost := new(big.Rat).Mul(p.entry, held)
+	cost.Add(cost, new(big.Rat).Mul(price, added))
+
+	p.quantity.Add(p.quantity, quantity)
+	p.entry.Quo(cost, held.Add(held, added))
+
+	return nil
+}
+
+// AddFee adds the fee paid on a trade transaction to its pair. Fees charged in
+// the base currency are converted to the quote currency at the transaction's
+// cost per coin, or else at the price of the trade. Transactions without a fee
+// are ignored.
+func (t *Tracker) AddFee(tx valr.Transaction) error {
+	if tx.FeeValue == "" || tx.AdditionalInfo == nil ||
+		tx.AdditionalInfo.CurrencyPairSymbol == "" {
+		return nil
+	}
+
+	p, err := t.position(tx.AdditionalInfo.CurrencyPairSymbol)
+	if err != nil {
+		return err
+	}
+
+	fee, err := decimal.Parse(tx.FeeValue)
+	if err != nil {
+		return fmt.Errorf("invalid transaction %s fee: %w", tx.ID, err)
+	}
+
+	switch tx.FeeCurrency {
+	case string(p.pair.Quote):
+	case string(p.pair.Base):
+		price, err := tradePrice(tx, p.pair)
+		if err != nil {
+			return fmt.Errorf("failed to value transaction %s fee: %w",
+				tx.ID, err)
+		}
+		fee.Mul(fee, price)
+	default:
+		return fmt.Errorf("transaction %s fee currency %q is not part of %s",
+			tx.ID, tx.FeeCurrency, p.pair)
+	}
+	p.fees.Add(p.fees, fee)
+
+	return nil
+}
+
+// Report returns the PnL of every pair, sorted by symbol. Open positions are
+// marked to the last traded price in summaries, which must also contain the
+// ZAR pair of any quote currency other than ZAR.
+func (t *Tracker) Report(summaries []valr.MarketSummary) ([]PnL, error) {
+	prices := make(map[string]*big.Rat)
+	for _, s := range summaries {
+		if price, ok := new(big.Rat).SetString(s.LastTradedPrice); ok {
+			prices[s.CurrencyPair] = price
+		}
+	}
+
+	var report []PnL
+	for _, p := range t.positions {
+		mark, ok := prices[p.pair.Symbol]
+		if !ok {
+			if p.quantity.Sign() != 0 {
+				return nil, fmt.Errorf("%w for %s", ErrNoPrice, p.pair)
+			}
+			mark = new(big.Rat)
+		}
+
+		rate, err := t.rate(string(p.pair.Quote), prices)
+		if err != nil {
+			return nil, err
+		}
+
+		unrealized := new(big.Rat).Sub(mark, p.entry)
+		unrealized.Mul(unrealized, p.quantity)
+
+		report = append(report, PnL{
+			AverageEntry:  new(big.Rat).Set(p.entry),
+			Fees:          new(big.Rat).Set(p.fees),
+			FeesZAR:       new(big.Rat).Mul(p.fees, rate),
+			MarkPrice:     mark,
+			Pair:          p.pair,
+			Position:      new(big.Rat).Set(p.quantity),
+			Realized:      new(big.Rat).Set(p.realized),
+			RealizedZAR:   new(big.Rat).Mul(p.realized, rate),
+			Unrealized:    unrealized,
+			UnrealizedZAR: new(big.Rat).Mul(unrealized, rate),
+		})
+	}
+
+	sort.Slice(report, func(i, j int) bool {
+		return report[i].Pair.Symbol < report[j].Pair.Symbol
+	})
+
+	return report, nil
+}
+
+// rate returns the ZAR price of currency.
+func (t *Tracker) rate(currency string, prices map[string]*big.Rat) (
+	*big.Rat, error) {
+
+	if currency == ZAR {
+		return big.NewRat(1, 1), nil
+	}
+
+	pair, err := t.registry.Parse(currency + ZAR)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s in ZAR: %v", ErrNoPrice, currency,
+			err)
+	}
+
+	price, ok := prices[pair.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoPrice, pair)
+	}
+
+	return price, nil
+}
+
+// tradePrice returns the price of a trade transaction in the pair's quote
+// currency, which is its cost per coin if VALR provides one or else the ratio
+// of the amounts traded.
+func tradePrice(tx valr.Transaction, pair valr.Pair) (*big.Rat, error) {
+	if cost := tx.AdditionalInfo.CostPerCoin; cost > 0 {
+		// Format the float so that its binary representation does not leak
+		// into the price.
+		return decimal.Parse(strconv.FormatFloat(cost, 'f', -1, 64))
+	}
+
+	base, quote := tx.DebitValue, tx.CreditValue
+	if tx.DebitCurrency == string(pair.Quote) &&
+		tx.CreditCurrency == string(pair.Base) {
+		base, quote = tx.CreditValue, tx.DebitValue
+	} else if tx.DebitCurrency != string(pair.Base) ||
+		tx.CreditCurrency != string(pair.Quote) {
+		return nil, fmt.Errorf("%w for %s", ErrNoPrice, pair)
+	}
+
+	quantity, err := decimal.ParsePositive(base)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	total, err := decimal.ParsePositive(quote)
+	if err != nil {
+		return nil, fmt.Errorf("invalid total: %w", err)
+	}
+
+	return total.Quo(total, quantity), nil
+}
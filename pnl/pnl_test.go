@@ -0,0 +1,240 @@
+package pnl_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/pnl"
+	"github.com/stretchr/testify/suite"
+)
+
+type pnlTestSuite struct {
+	suite.Suite
+	registry  *valr.PairRegistry
+	summaries []valr.MarketSummary
+}
+
+func TestPnLTestSuite(t *testing.T) {
+	suite.Run(t, new(pnlTestSuite))
+}
+
+func (suite *pnlTestSuite) SetupSuite() {
+	suite.registry = valr.NewPairRegistry([]valr.CurrencyPair{
+		{BaseCurrency: "BTC", QuoteCurrency: "ZAR", Symbol: "BTCZAR"},
+		{BaseCurrency: "ETH", QuoteCurrency: "BTC", Symbol: "ETHBTC"},
+	}, nil)
+
+	suite.summaries = []valr.MarketSummary{
+		{CurrencyPair: "BTCZAR", LastTradedPrice: "350"},
+		{CurrencyPair: "ETHBTC", LastTradedPrice: "0.06"},
+	}
+}
+
+func trade(id int64, pair string, side valr.Side, price,
+	quantity string) valr.Trade {
+	return valr.Trade{
+		CurrencyPair: pair,
+		ID:           id,
+		Price:        price,
+		Quantity:     quantity,
+		Side:         side,
+		TradedAt:     time.Date(2021, 1, 1, 0, int(id), 0, 0, time.UTC),
+	}
+}
+
+func fee(id, pair, currency, value string,
+	costPerCoin float64) valr.Transaction {
+	return valr.Transaction{
+		AdditionalInfo: &valr.TransactionInfo{
+			CostPerCoin:        costPerCoin,
+			CurrencyPairSymbol: pair,
+		},
+		EventAt:     time.Date(2021, 1, 1, 1, 0, 0, 0, time.UTC),
+		FeeCurrency: currency,
+		FeeValue:    value,
+		ID:          id,
+	}
+}
+
+func (suite *pnlTestSuite) btcTrades() []valr.Trade {
+	return []valr.Trade{
+		trade(1, "BTCZAR", valr.SideBuy, "100", "1"),
+		trade(2, "BTCZAR", valr.SideBuy, "200", "1"),
+		trade(3, "BTCZAR", valr.SideSell, "300", "1.5"),
+		trade(4, "BTCZAR", valr.SideSell, "400", "1"),
+	}
+}
+
+func (suite *pnlTestSuite) TestPositionAndPnL() {
+	t := pnl.NewTracker(suite.registry)
+	for _, tr := range suite.btcTrades() {
+		suite.Require().NoError(t.AddTrade(tr))
+	}
+	suite.Require().NoError(t.AddFee(fee("a", "BTCZAR", "BTC", "0.01", 100)))
+	suite.Require().NoError(t.AddFee(fee("b", "BTCZAR", "ZAR", "2", 300)))
+
+	report, err := t.Report(suite.summaries)
+	suite.Require().NoError(err)
+	suite.Require().Len(report, 1)
+
+	p := report[0]
+	suite.Require().Equal("BTCZAR", p.Pair.Symbol)
+	suite.Require().Equal("-1/2", p.Position.RatString())
+	suite.Require().Equal("400", p.AverageEntry.RatString())
+	suite.Require().Equal("350", p.MarkPrice.RatString())
+	suite.Require().Equal("350", p.Realized.RatString())
+	suite.Require().Equal("25", p.Unrealized.RatString())
+	suite.Require().Equal("3", p.Fees.RatString())
+	suite.Require().Equal("372", p.Net().RatString())
+	suite.Require().Equal("372", p.NetZAR().RatString())
+}
+
+func (suite *pnlTestSuite) TestClosedPositionResetsEntry() {
+	t := pnl.NewTracker(suite.registry)
+	suite.Require().NoError(t.AddTrade(trade(1, "BTCZAR", valr.SideBuy,
+		"100", "1")))
+	suite.Require().NoError(t.AddTrade(trade(2, "BTCZAR", valr.SideSell,
+		"150", "1")))
+
+	// A flat position needs no mark price.
+	report, err := t.Report(nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal("0", report[0].Position.RatString())
+	suite.Require().Equal("0", report[0].AverageEntry.RatString())
+	suite.Require().Equal("50", report[0].Realized.RatString())
+	suite.Require().Equal("0", report[0].Unrealized.RatString())
+}
+
+func (suite *pnlTestSuite) TestConvertsToZAR() {
+	t := pnl.NewTracker(suite.registry)
+	suite.Require().NoError(t.AddTrade(trade(1, "ETHBTC", valr.SideBuy,
+		"0.05", "10")))
+
+	report, err := t.Report(suite.summaries)
+	suite.Require().NoError(err)
+	suite.Require().Equal("1/10", report[0].Unrealized.RatString())
+	suite.Require().Equal("35", report[0].UnrealizedZAR.RatString())
+
+	_, err = t.Report(suite.summaries[1:])
+	suite.Require().True(errors.Is(err, pnl.ErrNoPrice))
+}
+
+func (suite *pnlTestSuite) TestInvalidInput() {
+	t := pnl.NewTracker(suite.registry)
+	suite.Require().True(errors.Is(t.AddTrade(trade(1, "XRPZAR",
+		valr.SideBuy, "1", "1")), valr.ErrUnknownPair))
+	suite.Require().True(errors.Is(t.AddTrade(trade(1, "BTCZAR",
+		valr.Side("HOLD"), "1", "1")), valr.ErrInvalidSide))
+	suite.Require().Error(t.AddFee(fee("a", "BTCZAR", "ETH", "1", 1)))
+}
+
+func (suite *pnlTestSuite) TestBaseFeeWithoutCostPerCoin() {
+	t := pnl.NewTracker(suite.registry)
+
+	// Without a cost per coin the fee is valued at the price of the trade.
+	tx := fee("a", "BTCZAR", "BTC", "0.01", 0)
+	tx.CreditCurrency, tx.CreditValue = "BTC", "2"
+	tx.DebitCurrency, tx.DebitValue = "ZAR", "500"
+	suite.Require().NoError(t.AddFee(tx))
+
+	report, err := t.Report(nil)
+	suite.Require().NoError(err)
+	suite.Require().Equal("5/2", report[0].Fees.RatString())
+
+	// A fee which cannot be valued is not counted as zero.
+	err = t.AddFee(fee("b", "BTCZAR", "BTC", "0.01", 0))
+	suite.Require().True(errors.Is(err, pnl.ErrNoPrice))
+}
+
+func (suite *pnlTestSuite) TestLoad() {
+	var startTime string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		switch r.URL.Path {
+		case "/account/BTCZAR/tradehistory":
+			// Most recent first, as VALR returns them.
+			trades := suite.btcTrades()
+			for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+				trades[i], trades[j] = trades[j], trades[i]
+			}
+			json.NewEncoder(w).Encode(trades)
+		case "/account/transactionhistory":
+			startTime = r.URL.Query().Get("startTime")
+			if r.URL.Query().Get("skip") != "" {
+				w.Write([]byte("[]"))
+				return
+			}
+			json.NewEncoder(w).Encode([]valr.Transaction{
+				fee("a", "BTCZAR", "ZAR", "2", 300),
+				fee("b", "ETHBTC", "BTC", "1", 0),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := valr.NewClientForTesting(suite.T(), srv.URL)
+	t, err := pnl.Load(context.Background(), c, suite.registry, "BTCZAR")
+	suite.Require().NoError(err)
+	suite.Require().Equal("2021-01-01T00:00:59.000Z", startTime)
+
+	report, err := t.Report(suite.summaries)
+	suite.Require().NoError(err)
+	suite.Require().Len(report, 1)
+	suite.Require().Equal("350", report[0].Realized.RatString())
+	suite.Require().Equal("2", report[0].Fees.RatString())
+}
+
+func (suite *pnlTestSuite) TestLoadFeesFromEachPairsFirstTrade() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		switch r.URL.Path {
+		case "/account/BTCZAR/tradehistory":
+			json.NewEncoder(w).Encode(suite.btcTrades())
+		case "/account/ETHBTC/tradehistory":
+			// The ETHBTC window starts after the first fee below.
+			json.NewEncoder(w).Encode([]valr.Trade{
+				trade(90, "ETHBTC", valr.SideBuy, "0.05", "1"),
+			})
+		case "/account/transactionhistory":
+			if r.URL.Query().Get("skip") != "" {
+				w.Write([]byte("[]"))
+				return
+			}
+			late := fee("c", "ETHBTC", "BTC", "0.5", 350)
+			late.EventAt = late.EventAt.Add(time.Hour)
+			json.NewEncoder(w).Encode([]valr.Transaction{
+				late,
+				fee("a", "BTCZAR", "ZAR", "2", 300),
+				fee("b", "ETHBTC", "BTC", "1", 350),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := valr.NewClientForTesting(suite.T(), srv.URL)
+	t, err := pnl.Load(context.Background(), c, suite.registry, "BTCZAR",
+		"ETHBTC")
+	suite.Require().NoError(err)
+
+	report, err := t.Report(suite.summaries)
+	suite.Require().NoError(err)
+	suite.Require().Len(report, 2)
+	for _, p := range report {
+		switch p.Pair.Symbol {
+		case "BTCZAR":
+			suite.Require().Equal("2", p.Fees.RatString())
+		case "ETHBTC":
+			suite.Require().Equal("1/2", p.Fees.RatString())
+		}
+	}
+}
@@ -2,10 +2,17 @@ package valr
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
+// ErrInvalidCurrency is returned when a currency code is not made up of 2 to
+// 10 letters and digits.
+var ErrInvalidCurrency = errors.New("invalid currency code")
+
 // Currency is a fiat or crypto currency supported by VALR.
 type Currency struct {
 	IsActive  bool   `json:"isActive"`
@@ -14,18 +21,74 @@ type Currency struct {
 	Symbol    string `json:"symbol"`
 }
 
+// CurrencyCode is the short name of a currency, e.g. "BTC" or "ZAR". VALR
+// lists new currencies regularly, so any well formed code is valid and the
+// constants only cover the most common currencies.
+type CurrencyCode string
+
+// Codes of the most commonly traded currencies.
+const (
+	CurrencyBTC  CurrencyCode = "BTC"
+	CurrencyETH  CurrencyCode = "ETH"
+	CurrencyUSDC CurrencyCode = "USDC"
+	CurrencyXRP  CurrencyCode = "XRP"
+	CurrencyZAR  CurrencyCode = "ZAR"
+)
+
+// ParseCurrencyCode returns the CurrencyCode for s, ignoring case.
+func ParseCurrencyCode(s string) (CurrencyCode, error) {
+	code := CurrencyCode(strings.ToUpper(strings.TrimSpace(s)))
+	if err := code.Validate(); err != nil {
+		return CurrencyCode(s), err
+	}
+
+	return code, nil
+}
+
+// Valid returns whether c is a well formed, upper case currency code.
+func (c CurrencyCode) Valid() bool {
+	if len(c) < 2 || len(c) > 10 {
+		return false
+	}
+
+	for _, r := range c {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate returns an error if c is not a well formed currency code.
+func (c CurrencyCode) Validate() error {
+	if !c.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, string(c))
+	}
+
+	return nil
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. Codes are
+// normalized to upper case, malformed values are preserved as is.
+func (c *CurrencyCode) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*c, _ = ParseCurrencyCode(raw)
+	return nil
+}
+
 // Currencies satisfies the PublicClient interface.
 func (c *client) Currencies(ctx context.Context) ([]Currency, error) {
-	res, err := c.httpClient.Get(ctx, "/public/currencies", nil)
+	res, err := c.get(ctx, Call{Endpoint: "Currencies"}, "/public/currencies",
+		nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch currencies: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch currencies: %d status code "+
-			"received", res.StatusCode)
-	}
-
 	var currencies []Currency
 	if err = res.JSON(&currencies); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal currencies: %w", err)
@@ -36,29 +99,27 @@ func (c *client) Currencies(ctx context.Context) ([]Currency, error) {
 
 // CurrencyPair is a fiat/crypto or crypto/crypto pair supported by VALR.
 type CurrencyPair struct {
-	Active         bool   `json:"active"`
-	BaseCurrency   string `json:"baseCurrency"`
-	MinBaseAmount  string `json:"minBaseAmount"`
-	MaxBaseAmount  string `json:"maxBaseAmount"`
-	MinQuoteAmount string `json:"minQuoteAmount"`
-	MaxQuoteAmount string `json:"maxQuoteAmount"`
-	QuoteCurrency  string `json:"quoteCurrency"`
-	ShortName      string `json:"shortName"`
-	Symbol         string `json:"symbol"`
+	Active            bool   `json:"active"`
+	BaseCurrency      string `json:"baseCurrency"`
+	BaseDecimalPlaces string `json:"baseDecimalPlaces"`
+	MinBaseAmount     string `json:"minBaseAmount"`
+	MaxBaseAmount     string `json:"maxBaseAmount"`
+	MinQuoteAmount    string `json:"minQuoteAmount"`
+	MaxQuoteAmount    string `json:"maxQuoteAmount"`
+	QuoteCurrency     string `json:"quoteCurrency"`
+	ShortName         string `json:"shortName"`
+	Symbol            string `json:"symbol"`
+	TickSize          string `json:"tickSize"`
 }
 
 // CurrencyPairs satisfies the PublicClient interface.
 func (c *client) CurrencyPairs(ctx context.Context) ([]CurrencyPair, error) {
-	res, err := c.httpClient.Get(ctx, "/public/pairs", nil)
+	res, err := c.get(ctx, Call{Endpoint: "CurrencyPairs"}, "/public/pairs",
+		nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch currency pairs: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch currency pairs: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	var pairs []CurrencyPair
 	if err = res.JSON(&pairs); err != nil {
 		return nil, fmt.Errorf("failed to fetch currency pairs: %w", err)
@@ -84,16 +145,12 @@ type MarketSummary struct {
 
 // MarketSummary satisfies the PublicClient interface.
 func (c *client) MarketSummary(ctx context.Context) ([]MarketSummary, error) {
-	res, err := c.httpClient.Get(ctx, "/public/marketsummary", nil)
+	res, err := c.get(ctx, Call{Endpoint: "MarketSummary"},
+		"/public/marketsummary", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch the market summaries: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch market summary: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	var summaries []MarketSummary
 	if err = res.JSON(&summaries); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal market summaries: %w", err)
@@ -105,17 +162,13 @@ func (c *client) MarketSummary(ctx context.Context) ([]MarketSummary, error) {
 // MarketSummaryForCurrency satisfies the PublicClient interface.
 func (c *client) MarketSummaryForCurrency(ctx context.Context, pair string) (
 	*MarketSummary, error) {
-	res, err := c.httpClient.Get(ctx, fmt.Sprintf("/public/%s/marketsummary",
-		pair), nil)
+	res, err := c.request(ctx,
+		Call{Endpoint: "MarketSummaryForCurrency", Pair: pair},
+		&MarketSummaryRequest{Pair: pair})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch the market summaries: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch market summaries: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	var summary MarketSummary
 	if err = res.JSON(&summary); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal market summaries: %w", err)
@@ -138,23 +191,18 @@ type OrderBookEntry struct {
 	OrderCount   int    `json:"orderCount"`
 	Price        string `json:"price"`
 	Quantity     string `json:"quantity"`
-	Side         string `json:"side"`
+	Side         Side   `json:"side"`
 }
 
 // OrderBook satisfies the PublicClient interface.
 func (c *client) OrderBook(ctx context.Context, pair string) (*OrderBook,
 	error) {
-	res, err := c.httpClient.Get(ctx, fmt.Sprintf("/public/%s/orderbook", pair),
-		nil)
+	res, err := c.request(ctx, Call{Endpoint: "OrderBook", Pair: pair},
+		&OrderBookRequest{Pair: pair})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch order book: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch order book: %d status code "+
-			"received", res.StatusCode)
-	}
-
 	var book OrderBook
 	if err = res.JSON(&book); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal order book: %w", err)
@@ -184,16 +232,12 @@ const (
 // OrderTypes satisfies the PublicClient interface.
 func (c *client) OrderTypes(ctx context.Context) (map[string]map[OrderType]bool,
 	error) {
-	res, err := c.httpClient.Get(ctx, "/public/ordertypes", nil)
+	res, err := c.get(ctx, Call{Endpoint: "OrderTypes"}, "/public/ordertypes",
+		nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch order types: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch order types: %d status code "+
-			"received", res.StatusCode)
-	}
-
 	types := []struct {
 		Pair       string      `json:"currencyPair"`
 		OrderTypes []OrderType `json:"orderTypes"`
@@ -217,17 +261,13 @@ func (c *client) OrderTypes(ctx context.Context) (map[string]map[OrderType]bool,
 // OrderTypesForCurrency satisfies the PublicClient interface.
 func (c *client) OrderTypesForCurrency(ctx context.Context, pair string) (
 	map[OrderType]bool, error) {
-	res, err := c.httpClient.Get(ctx, fmt.Sprintf("/public/%s/ordertypes",
-		pair), nil)
+	res, err := c.request(ctx,
+		Call{Endpoint: "OrderTypesForCurrency", Pair: pair},
+		&OrderTypesRequest{Pair: pair})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch order types for pair: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch order types: %d status code "+
-			"received", res.StatusCode)
-	}
-
 	var orderTypes []OrderType
 	if err = res.JSON(&orderTypes); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal order types: %w", err)
@@ -249,16 +289,11 @@ type ServerTime struct {
 
 // ServerTime satisfies the PublicClient interface.
 func (c *client) ServerTime(ctx context.Context) (*ServerTime, error) {
-	res, err := c.httpClient.Get(ctx, "/public/time", nil)
+	res, err := c.get(ctx, Call{Endpoint: "ServerTime"}, "/public/time", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch server time: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return nil, fmt.Errorf("failed to fetch server time: %d status code "+
-			"received", res.StatusCode)
-	}
-
 	var serverTime ServerTime
 	if err = res.JSON(&serverTime); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal server time: %w", err)
@@ -285,16 +320,11 @@ const (
 
 // Status satisfies the PublicClient interface.
 func (c *client) Status(ctx context.Context) (Status, error) {
-	res, err := c.httpClient.Get(ctx, "/public/status", nil)
+	res, err := c.get(ctx, Call{Endpoint: "Status"}, "/public/status", nil)
 	if err != nil {
 		return StatusUnknown, fmt.Errorf("failed to fetch status: %w", err)
 	}
 
-	if !res.IsSuccess() {
-		return StatusUnknown, fmt.Errorf("failed to fetch status: %d status "+
-			"code received", res.StatusCode)
-	}
-
 	statusObj := struct {
 		Status Status
 	}{}
@@ -2,6 +2,8 @@ package valr_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
@@ -52,15 +54,17 @@ func (suite *PublicTestSuite) TestPublicClient_CurrencyPairs() {
 	suite.Require().Len(pairs, 91)
 
 	btczar := valr.CurrencyPair{
-		Active:         true,
-		BaseCurrency:   "BTC",
-		MaxBaseAmount:  "2",
-		MaxQuoteAmount: "100000",
-		MinBaseAmount:  "0.0001",
-		MinQuoteAmount: "10",
-		ShortName:      "BTC/ZAR",
-		Symbol:         "BTCZAR",
-		QuoteCurrency:  "ZAR",
+		Active:            true,
+		BaseCurrency:      "BTC",
+		BaseDecimalPlaces: "8",
+		MaxBaseAmount:     "2",
+		MaxQuoteAmount:    "100000",
+		MinBaseAmount:     "0.0001",
+		MinQuoteAmount:    "10",
+		ShortName:         "BTC/ZAR",
+		Symbol:            "BTCZAR",
+		QuoteCurrency:     "ZAR",
+		TickSize:          "1",
 	}
 
 	suite.Require().EqualValues(btczar, pairs[0])
@@ -121,7 +125,7 @@ func (suite *PublicTestSuite) TestPublicClient_OrderBook() {
 		OrderCount:   1,
 		Price:        "9000",
 		Quantity:     "0.101",
-		Side:         "sell",
+		Side:         valr.SideSell,
 	}
 
 	bid := valr.OrderBookEntry{
@@ -129,7 +133,7 @@ func (suite *PublicTestSuite) TestPublicClient_OrderBook() {
 		OrderCount:   1,
 		Price:        "8802",
 		Quantity:     "0.1",
-		Side:         "buy",
+		Side:         valr.SideBuy,
 	}
 
 	suite.Require().Equal(ask, book.Asks[0])
@@ -188,3 +192,43 @@ func (suite *PublicTestSuite) TestPublicClient_Status() {
 	suite.Require().NoError(err)
 	suite.Require().Equal(valr.StatusOnline, status)
 }
+
+func (suite *PublicTestSuite) TestParseCurrencyCode() {
+	testcases := []struct {
+		input string
+		code  valr.CurrencyCode
+		err   bool
+	}{
+		{input: "ZAR", code: valr.CurrencyZAR},
+		{input: "btc", code: valr.CurrencyBTC},
+		{input: " usdc ", code: valr.CurrencyUSDC},
+		{input: "1INCH", code: "1INCH"},
+		{input: "B", code: "B", err: true},
+		{input: "BTC/ZAR", code: "BTC/ZAR", err: true},
+		{input: "", code: "", err: true},
+	}
+
+	for _, test := range testcases {
+		suite.T().Run(test.input, func(t *testing.T) {
+			code, err := valr.ParseCurrencyCode(test.input)
+			suite.Require().Equal(test.code, code)
+			if test.err {
+				suite.Require().True(errors.Is(err, valr.ErrInvalidCurrency))
+			} else {
+				suite.Require().NoError(err)
+			}
+		})
+	}
+}
+
+func (suite *PublicTestSuite) TestCurrencyCodeJSON() {
+	var codes []valr.CurrencyCode
+	suite.Require().NoError(json.Unmarshal([]byte(`["zar","Eth","??"]`),
+		&codes))
+	suite.Require().Equal([]valr.CurrencyCode{valr.CurrencyZAR,
+		valr.CurrencyETH, "??"}, codes)
+
+	data, err := json.Marshal(codes)
+	suite.Require().NoError(err)
+	suite.Require().Equal(`["ZAR","ETH","??"]`, string(data))
+}
@@ -0,0 +1,81 @@
+package valr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gorilla/schema"
+)
+
+// queryTimeFormat is the layout VALR expects for timestamps in query strings.
+const queryTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// request is implemented by every request type so that it can be encoded into
+// an HTTP request by encodeRequest.
+type request interface {
+	// route returns the HTTP method and the path of the endpoint, with any
+	// path parameters already escaped.
+	route() (method, path string)
+}
+
+// newEncoder returns a schema encoder which encodes query parameters the way
+// the VALR API expects them. Times are formatted in UTC with millisecond
+// precision and transaction type filters are joined into a single
+// comma-separated value.
+func newEncoder() *schema.Encoder {
+	enc := schema.NewEncoder()
+
+	enc.RegisterEncoder(time.Time{}, func(v reflect.Value) string {
+		return v.Interface().(time.Time).UTC().Format(queryTimeFormat)
+	})
+
+	enc.RegisterEncoder([]TransactionType{}, func(v reflect.Value) string {
+		types := v.Interface().([]TransactionType)
+
+		values := make([]string, 0, len(types))
+		for _, t := range types {
+			values = append(values, string(t))
+		}
+
+		return strings.Join(values, ",")
+	})
+
+	return enc
+}
+
+// encodeRequest returns the method, path, query and body for req. Requests
+// made with GET have their fields encoded into the query, all other requests
+// have their fields encoded as a JSON body.
+func encodeRequest(enc *schema.Encoder, req request) (method, path string,
+	query url.Values, body []byte, err error) {
+
+	method, path = req.route()
+
+	if method == http.MethodGet {
+		query = make(url.Values)
+		if err = enc.Encode(req, query); err != nil {
+			return "", "", nil, nil, fmt.Errorf("failed to encode query: %w",
+				err)
+		}
+
+		return method, path, query, nil, nil
+	}
+
+	body, err = json.Marshal(req)
+	if err != nil {
+		return "", "", nil, nil, fmt.Errorf("failed to encode body: %w", err)
+	}
+
+	return method, path, nil, body, nil
+}
+
+// escape escapes a path parameter so that it may be safely placed inside a
+// URL path.
+func escape(s string) string {
+	return url.PathEscape(s)
+}
@@ -0,0 +1,264 @@
+package valr
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type queryTestSuite struct {
+	suite.Suite
+}
+
+func TestQueryTestSuite(t *testing.T) {
+	suite.Run(t, new(queryTestSuite))
+}
+
+func (suite *queryTestSuite) TestEncodeRequest() {
+	start := time.Date(2021, 3, 1, 12, 30, 0, 0, time.UTC)
+	end := time.Date(2021, 3, 2, 14, 30, 0, 500e6,
+		time.FixedZone("SAST", 2*60*60))
+
+	testcases := []struct {
+		name   string
+		req    request
+		method string
+		url    string
+		body   string
+	}{
+		{
+			name:   "order book",
+			req:    &OrderBookRequest{Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/public/BTCZAR/orderbook",
+		},
+		{
+			name:   "market summary",
+			req:    &MarketSummaryRequest{Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/public/BTCZAR/marketsummary",
+		},
+		{
+			name:   "order types",
+			req:    &OrderTypesRequest{Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/public/BTCZAR/ordertypes",
+		},
+		{
+			name:   "trade history",
+			req:    &TradeHistoryRequest{Pair: "BTCZAR", Limit: 10},
+			method: http.MethodGet,
+			url:    "/account/BTCZAR/tradehistory?limit=10",
+		},
+		{
+			name:   "transaction history empty",
+			req:    &TransactionHistoryRequest{},
+			method: http.MethodGet,
+			url:    "/account/transactionhistory",
+		},
+		{
+			name: "transaction history full",
+			req: &TransactionHistoryRequest{
+				BeforeID:  "abc",
+				Currency:  "ZAR",
+				EndTime:   end,
+				Limit:     100,
+				Offset:    200,
+				StartTime: start,
+				Types: []TransactionType{
+					TransactionTypeLimitBuy,
+					TransactionTypeFiatDeposit,
+				},
+			},
+			method: http.MethodGet,
+			url: "/account/transactionhistory?beforeId=abc&currency=ZAR" +
+				"&endTime=2021-03-02T12%3A30%3A00.500Z&limit=100&skip=200" +
+				"&startTime=2021-03-01T12%3A30%3A00.000Z" +
+				"&transactionTypes=LIMIT_BUY%2CFIAT_DEPOSIT",
+		},
+		{
+			name:   "crypto deposit address",
+			req:    &CryptoDepositAddressRequest{Currency: "BTC"},
+			method: http.MethodGet,
+			url:    "/wallet/crypto/BTC/deposit/address",
+		},
+		{
+			name: "crypto deposit history",
+			req: &CryptoDepositHistoryRequest{Currency: "BTC", Limit: 5,
+				Offset: 10},
+			method: http.MethodGet,
+			url:    "/wallet/crypto/BTC/deposit/history?limit=5&skip=10",
+		},
+		{
+			name:   "crypto withdrawal history",
+			req:    &CryptoWithdrawalHistoryRequest{Currency: "BTC"},
+			method: http.MethodGet,
+			url:    "/wallet/crypto/BTC/withdraw/history",
+		},
+		{
+			name:   "crypto withdrawal info",
+			req:    &CryptoWithdrawalInfoRequest{Currency: "BTC"},
+			method: http.MethodGet,
+			url:    "/wallet/crypto/BTC/withdraw",
+		},
+		{
+			name: "crypto withdrawal",
+			req: &CryptoWithdrawalRequest{Amount: "0.1", Address: "addr",
+				Currency: "BTC"},
+			method: http.MethodPost,
+			url:    "/wallet/crypto/BTC/withdrawal",
+			body:   `{"amount":"0.1","address":"addr"}`,
+		},
+		{
+			name: "crypto withdrawal status",
+			req: &CryptoWithdrawalStatusRequest{Currency: "BTC",
+				ID: "a/b"},
+			method: http.MethodGet,
+			url:    "/wallet/crypto/BTC/withdrawal/a%2Fb",
+		},
+		{
+			name:   "cancel order",
+			req:    &CancelOrderRequest{OrderID: "123", Pair: "BTCZAR"},
+			method: http.MethodDelete,
+			url:    "/orders/order",
+			body:   `{"orderId":"123","pair":"BTCZAR"}`,
+		},
+		{
+			name: "limit order",
+			req: &LimitOrderRequest{Pair: "BTCZAR", Price: "100",
+				Quantity: "1", Side: SideBuy},
+			method: http.MethodPost,
+			url:    "/orders/limit",
+			body: `{"pair":"BTCZAR","postOnly":false,"price":"100",` +
+				`"quantity":"1","side":"BUY"}`,
+		},
+		{
+			name: "market order",
+			req: &MarketOrderRequest{BaseAmount: "1",
+				CustomerOrderID: "c1", Pair: "BTCZAR", Side: SideSell},
+			method: http.MethodPost,
+			url:    "/orders/market",
+			body: `{"baseAmount":"1","customerOrderId":"c1",` +
+				`"pair":"BTCZAR","side":"SELL"}`,
+		},
+		{
+			name:   "order history",
+			req:    &OrderHistoryRequest{Limit: 20, Offset: 40},
+			method: http.MethodGet,
+			url:    "/orders/history?limit=20&skip=40",
+		},
+		{
+			name:   "order history detail by order id",
+			req:    &OrderHistoryDetailRequest{OrderID: "123"},
+			method: http.MethodGet,
+			url:    "/orders/history/detail/orderid/123",
+		},
+		{
+			name:   "order history detail by customer order id",
+			req:    &OrderHistoryDetailRequest{CustomerOrderID: "c 1"},
+			method: http.MethodGet,
+			url:    "/orders/history/detail/customerorderid/c%201",
+		},
+		{
+			name:   "order history summary by order id",
+			req:    &OrderHistorySummaryRequest{OrderID: "123"},
+			method: http.MethodGet,
+			url:    "/orders/history/summary/orderid/123",
+		},
+		{
+			name:   "order history summary by customer order id",
+			req:    &OrderHistorySummaryRequest{CustomerOrderID: "c1"},
+			method: http.MethodGet,
+			url:    "/orders/history/summary/customerorderid/c1",
+		},
+		{
+			name:   "order status by order id",
+			req:    &OrderStatusRequest{OrderID: "123", Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/orders/BTCZAR/orderid/123",
+		},
+		{
+			name: "order status by customer order id",
+			req: &OrderStatusRequest{CustomerOrderID: "c1",
+				Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/orders/BTCZAR/customerorderid/c1",
+		},
+		{
+			name:   "bank accounts",
+			req:    &BankAccountsRequest{Currency: "ZAR"},
+			method: http.MethodGet,
+			url:    "/wallet/fiat/ZAR/accounts",
+		},
+		{
+			name: "fiat withdrawal",
+			req: &FiatWithdrawalRequest{Amount: "100", BankAccount: "b1",
+				Currency: "ZAR", Fast: true},
+			method: http.MethodPost,
+			url:    "/wallet/fiat/ZAR/withdraw",
+			body:   `{"amount":"100","linkedBankAccountId":"b1","fast":true}`,
+		},
+		{
+			name: "simple quote",
+			req: &SimpleQuoteRequest{Amount: "100", Pair: "BTCZAR",
+				QuoteCurrency: "ZAR", Side: SideBuy},
+			method: http.MethodPost,
+			url:    "/simple/BTCZAR/quote",
+			body: `{"payAmount":"100","payInCurrency":"ZAR",` +
+				`"side":"BUY"}`,
+		},
+		{
+			name: "simple order",
+			req: &SimpleOrderRequest{Amount: "100", Pair: "BTCZAR",
+				QuoteCurrency: "ZAR", Side: SideSell},
+			method: http.MethodPost,
+			url:    "/simple/BTCZAR/order",
+			body: `{"payAmount":"100","payInCurrency":"ZAR",` +
+				`"side":"SELL"}`,
+		},
+		{
+			name: "simple order status",
+			req: &SimpleOrderStatusRequest{OrderID: "123",
+				Pair: "BTCZAR"},
+			method: http.MethodGet,
+			url:    "/simple/BTCZAR/order/123",
+		},
+	}
+
+	enc := newEncoder()
+	for _, test := range testcases {
+		suite.Run(test.name, func() {
+			method, path, query, body, err := encodeRequest(enc, test.req)
+			suite.Require().NoError(err)
+			suite.Require().Equal(test.method, method)
+
+			url := path
+			if len(query) > 0 {
+				url += "?" + query.Encode()
+			}
+			suite.Require().Equal(test.url, url)
+
+			if test.body == "" {
+				suite.Require().Nil(body)
+				return
+			}
+			suite.Require().JSONEq(test.body, string(body))
+		})
+	}
+}
+
+func (suite *queryTestSuite) TestSignatureIncludesQuery() {
+	r, err := http.NewRequest(http.MethodGet,
+		"https://api.valr.com/v1/account/transactionhistory?limit=10", nil)
+	suite.Require().NoError(err)
+
+	creds := Credentials{Key: "key", Secret: "secret"}
+	suite.Require().NoError(authenticationHook(creds)(r))
+
+	timestamp := r.Header.Get("X-VALR-TIMESTAMP")
+	suite.Require().Equal(generateAuthSignature(creds.Secret, timestamp,
+		http.MethodGet, "/v1/account/transactionhistory?limit=10", nil, ""),
+		r.Header.Get("X-VALR-SIGNATURE"))
+}
@@ -1,6 +1,8 @@
 package valr
 
 import (
+	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -13,7 +15,11 @@ import (
 // GET /public/{pair}/orderbook
 // GET /marketdata/{pair}/orderbook/full
 type OrderBookRequest struct {
-	Pair string
+	Pair string `schema:"-"`
+}
+
+func (r OrderBookRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/public/%s/orderbook", escape(r.Pair))
 }
 
 // MarketSummaryRequest contains the request parameters for obtaining a market
@@ -21,7 +27,12 @@ type OrderBookRequest struct {
 //
 // GET /public/{pair}/marketsummary
 type MarketSummaryRequest struct {
-	Pair string
+	Pair string `schema:"-"`
+}
+
+func (r MarketSummaryRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/public/%s/marketsummary",
+		escape(r.Pair))
 }
 
 // OrderTypesRequest contains the request parameters for obtaining a list of
@@ -29,7 +40,11 @@ type MarketSummaryRequest struct {
 //
 // GET /public/{pair}/ordertypes
 type OrderTypesRequest struct {
-	Pair string
+	Pair string `schema:"-"`
+}
+
+func (r OrderTypesRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/public/%s/ordertypes", escape(r.Pair))
 }
 
 // Accounts
@@ -42,8 +57,13 @@ type OrderTypesRequest struct {
 // GET /account/{pair}/tradehistory
 // GET /marketdata/{pair}/tradehistory
 type TradeHistoryRequest struct {
-	Pair  string
-	Limit int `schema:"limit,omitempty"`
+	Pair  string `schema:"-"`
+	Limit int    `schema:"limit,omitempty"`
+}
+
+func (r TradeHistoryRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/account/%s/tradehistory",
+		escape(r.Pair))
 }
 
 // TransactionType defines the kind of a transaction.
@@ -77,12 +97,16 @@ type TransactionHistoryRequest struct {
 	BeforeID  string            `schema:"beforeId,omitempty"`
 	Currency  string            `schema:"currency,omitempty"`
 	EndTime   time.Time         `schema:"endTime,omitempty"`
-	Limit     int               `schema:"skip,omitempty"`
-	Offset    int               `schema:"limit,omitempty"`
+	Limit     int               `schema:"limit,omitempty"`
+	Offset    int               `schema:"skip,omitempty"`
 	StartTime time.Time         `schema:"startTime,omitempty"`
 	Types     []TransactionType `schema:"transactionTypes,omitempty"`
 }
 
+func (r TransactionHistoryRequest) route() (string, string) {
+	return http.MethodGet, "/account/transactionhistory"
+}
+
 // Crypto
 // -----------------------------------------------------------------------------
 
@@ -91,7 +115,12 @@ type TransactionHistoryRequest struct {
 //
 // GET /wallet/crypto/{currency}/deposit/address
 type CryptoDepositAddressRequest struct {
-	Currency string
+	Currency string `schema:"-"`
+}
+
+func (r CryptoDepositAddressRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/crypto/%s/deposit/address",
+		escape(r.Currency))
 }
 
 // CryptoDepositHistoryRequest contains the request parameters for getting the
@@ -99,9 +128,14 @@ type CryptoDepositAddressRequest struct {
 //
 // GET /wallet/crypto/{currency}/deposit/history
 type CryptoDepositHistoryRequest struct {
-	Currency string
-	Limit    int `schema:"limit,omitempty"`
-	Offset   int `schema:"skip,omitempty"`
+	Currency string `schema:"-"`
+	Limit    int    `schema:"limit,omitempty"`
+	Offset   int    `schema:"skip,omitempty"`
+}
+
+func (r CryptoDepositHistoryRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/crypto/%s/deposit/history",
+		escape(r.Currency))
 }
 
 // CryptoWithdrawalHistory contains the request parameters for getting the
@@ -109,9 +143,14 @@ type CryptoDepositHistoryRequest struct {
 //
 // GET /wallet/crypto/{currency}/withdraw/history
 type CryptoWithdrawalHistoryRequest struct {
-	Currency string
-	Limit    int `schema:"limit,omitempty"`
-	Offset   int `schema:"skip,omitempty"`
+	Currency string `schema:"-"`
+	Limit    int    `schema:"limit,omitempty"`
+	Offset   int    `schema:"skip,omitempty"`
+}
+
+func (r CryptoWithdrawalHistoryRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/crypto/%s/withdraw/history",
+		escape(r.Currency))
 }
 
 // CryptoWithdrawalInfoRequest contains the request parameters for getting
@@ -119,7 +158,12 @@ type CryptoWithdrawalHistoryRequest struct {
 //
 // GET /wallet/crypto/{currency}/withdraw
 type CryptoWithdrawalInfoRequest struct {
-	Currency string
+	Currency string `schema:"-"`
+}
+
+func (r CryptoWithdrawalInfoRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/crypto/%s/withdraw",
+		escape(r.Currency))
 }
 
 // CryptoWithdrawalRequest contains the request parameters for creating a
@@ -129,7 +173,12 @@ type CryptoWithdrawalInfoRequest struct {
 type CryptoWithdrawalRequest struct {
 	Amount   string `json:"amount"`
 	Address  string `json:"address"`
-	Currency string
+	Currency string `json:"-"`
+}
+
+func (r CryptoWithdrawalRequest) route() (string, string) {
+	return http.MethodPost, fmt.Sprintf("/wallet/crypto/%s/withdrawal",
+		escape(r.Currency))
 }
 
 // CryptoWithdrawalStatusRequest contains the request paremeters for getting the
@@ -137,8 +186,13 @@ type CryptoWithdrawalRequest struct {
 //
 // GET /wallet/crypto/{currency}/withdrawal/{id}
 type CryptoWithdrawalStatusRequest struct {
-	Currency string
-	ID       string
+	Currency string `schema:"-"`
+	ID       string `schema:"-"`
+}
+
+func (r CryptoWithdrawalStatusRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/crypto/%s/withdrawal/%s",
+		escape(r.Currency), escape(r.ID))
 }
 
 // Exchange
@@ -149,22 +203,30 @@ type CryptoWithdrawalStatusRequest struct {
 //
 // DELETE /orders/order
 type CancelOrderRequest struct {
-	CustomerOrderID string `json:"customerOrderId"`
-	OrderID         string `json:"orderId"`
+	CustomerOrderID string `json:"customerOrderId,omitempty"`
+	OrderID         string `json:"orderId,omitempty"`
 	Pair            string `json:"pair"`
 }
 
+func (r CancelOrderRequest) route() (string, string) {
+	return http.MethodDelete, "/orders/order"
+}
+
 // LimitOrderRequest contains the request parameters for placing a limit order
 // on the exchange.
 //
 // POST /orders/limit
 type LimitOrderRequest struct {
-	CustomerOrderID string `json:"customerOrderId"`
+	CustomerOrderID string `json:"customerOrderId,omitempty"`
 	Pair            string `json:"pair"`
 	PostOnly        bool   `json:"postOnly"`
 	Price           string `json:"price"`
 	Quantity        string `json:"quantity"`
-	Side            string `json:"side"`
+	Side            Side   `json:"side"`
+}
+
+func (r LimitOrderRequest) route() (string, string) {
+	return http.MethodPost, "/orders/limit"
 }
 
 // MarketOrderRequest contains the request parameters for placing a market
@@ -173,9 +235,13 @@ type LimitOrderRequest struct {
 // POST /orders/market
 type MarketOrderRequest struct {
 	BaseAmount      string `json:"baseAmount"`
-	CustomerOrderID string `json:"customerOrderId"`
+	CustomerOrderID string `json:"customerOrderId,omitempty"`
 	Pair            string `json:"pair"`
-	Side            string `json:"side"`
+	Side            Side   `json:"side"`
+}
+
+func (r MarketOrderRequest) route() (string, string) {
+	return http.MethodPost, "/orders/market"
 }
 
 // OrderHistoryRequest contains the request parameters for getting your order
@@ -183,8 +249,12 @@ type MarketOrderRequest struct {
 //
 // GET /orders/history
 type OrderHistoryRequest struct {
-	Limit  int
-	Offset int
+	Limit  int `schema:"limit,omitempty"`
+	Offset int `schema:"skip,omitempty"`
+}
+
+func (r OrderHistoryRequest) route() (string, string) {
+	return http.MethodGet, "/orders/history"
 }
 
 // OrderHistoryDetailRequest contains the request parameters for getting the
@@ -194,8 +264,18 @@ type OrderHistoryRequest struct {
 // GET /orders/history/detail/customerorderid/{orderId}
 // GET /orders/history/detail/orderid/{orderId}
 type OrderHistoryDetailRequest struct {
-	CustomerOrderID string
-	OrderID         string
+	CustomerOrderID string `schema:"-"`
+	OrderID         string `schema:"-"`
+}
+
+func (r OrderHistoryDetailRequest) route() (string, string) {
+	if r.CustomerOrderID != "" {
+		return http.MethodGet, "/orders/history/detail/customerorderid/" +
+			escape(r.CustomerOrderID)
+	}
+
+	return http.MethodGet, "/orders/history/detail/orderid/" +
+		escape(r.OrderID)
 }
 
 // OrderHistorySummaryRequest contains the request parameters for getting a
@@ -205,8 +285,18 @@ type OrderHistoryDetailRequest struct {
 // GET /orders/history/summary/customerorderid/{orderId}
 // GET /orders/history/summary/orderid/{orderId}
 type OrderHistorySummaryRequest struct {
-	CustomerOrderID string
-	OrderID         string
+	CustomerOrderID string `schema:"-"`
+	OrderID         string `schema:"-"`
+}
+
+func (r OrderHistorySummaryRequest) route() (string, string) {
+	if r.CustomerOrderID != "" {
+		return http.MethodGet, "/orders/history/summary/customerorderid/" +
+			escape(r.CustomerOrderID)
+	}
+
+	return http.MethodGet, "/orders/history/summary/orderid/" +
+		escape(r.OrderID)
 }
 
 // OrderStatusRequest contains the request parameters for getting the status of
@@ -214,11 +304,21 @@ type OrderHistorySummaryRequest struct {
 // types should be provided.
 //
 // GET /orders/{pair}/customerorderid/{orderId}
-// GET /orders/{pair}/{orderId}
+// GET /orders/{pair}/orderid/{orderId}
 type OrderStatusRequest struct {
-	CustomerOrderID string
-	OrderID         string
-	Pair            string
+	CustomerOrderID string `schema:"-"`
+	OrderID         string `schema:"-"`
+	Pair            string `schema:"-"`
+}
+
+func (r OrderStatusRequest) route() (string, string) {
+	if r.CustomerOrderID != "" {
+		return http.MethodGet, fmt.Sprintf("/orders/%s/customerorderid/%s",
+			escape(r.Pair), escape(r.CustomerOrderID))
+	}
+
+	return http.MethodGet, fmt.Sprintf("/orders/%s/orderid/%s",
+		escape(r.Pair), escape(r.OrderID))
 }
 
 // Fiat
@@ -229,7 +329,12 @@ type OrderStatusRequest struct {
 //
 // GET /wallet/fiat/{currency}/accounts
 type BankAccountsRequest struct {
-	Currency string
+	Currency string `schema:"-"`
+}
+
+func (r BankAccountsRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/wallet/fiat/%s/accounts",
+		escape(r.Currency))
 }
 
 // FiatWithdrawalRequest contains the request parameters for withdrawing your
@@ -239,8 +344,13 @@ type BankAccountsRequest struct {
 type FiatWithdrawalRequest struct {
 	Amount      string `json:"amount"`
 	BankAccount string `json:"linkedBankAccountId"`
-	Currency    string
-	Fast        bool `json:"fast"`
+	Currency    string `json:"-"`
+	Fast        bool   `json:"fast"`
+}
+
+func (r FiatWithdrawalRequest) route() (string, string) {
+	return http.MethodPost, fmt.Sprintf("/wallet/fiat/%s/withdraw",
+		escape(r.Currency))
 }
 
 // Simple Buy / Sell
@@ -252,9 +362,13 @@ type FiatWithdrawalRequest struct {
 // POST /simple/{pair}/quote
 type SimpleQuoteRequest struct {
 	Amount        string `json:"payAmount"`
-	Pair          string
+	Pair          string `json:"-"`
 	QuoteCurrency string `json:"payInCurrency"`
-	Side          string `json:"side"`
+	Side          Side   `json:"side"`
+}
+
+func (r SimpleQuoteRequest) route() (string, string) {
+	return http.MethodPost, fmt.Sprintf("/simple/%s/quote", escape(r.Pair))
 }
 
 // SimpleOrderRequest contains the request parameters for placing a simple buy
@@ -263,9 +377,13 @@ type SimpleQuoteRequest struct {
 // POST /simple/{pair}/order
 type SimpleOrderRequest struct {
 	Amount        string `json:"payAmount"`
-	Pair          string
+	Pair          string `json:"-"`
 	QuoteCurrency string `json:"payInCurrency"`
-	Side          string `json:"side"`
+	Side          Side   `json:"side"`
+}
+
+func (r SimpleOrderRequest) route() (string, string) {
+	return http.MethodPost, fmt.Sprintf("/simple/%s/order", escape(r.Pair))
 }
 
 // SimpleOrderStatusRequest contains the request parameters for getting the
@@ -273,6 +391,11 @@ type SimpleOrderRequest struct {
 //
 // GET /simple/{pair}/order/{id}
 type SimpleOrderStatusRequest struct {
-	OrderID string `json:"orderId"`
-	Pair    string
+	OrderID string `schema:"-"`
+	Pair    string `schema:"-"`
+}
+
+func (r SimpleOrderStatusRequest) route() (string, string) {
+	return http.MethodGet, fmt.Sprintf("/simple/%s/order/%s", escape(r.Pair),
+		escape(r.OrderID))
 }
@@ -0,0 +1,103 @@
+package tax
+
+import "math/big"
+
+// ledger tracks the quantity and total ZAR cost of each currency held.
+type ledger interface {
+	// add records the acquisition of quantity units of currency at the given
+	// total cost.
+	add(currency string, quantity, cost *big.Rat)
+
+	// remove removes quantity units of currency and returns their cost.
+	// Quantities beyond what is held have no cost.
+	remove(currency string, quantity *big.Rat) *big.Rat
+}
+
+// lot is a quantity of a currency acquired at a total cost.
+type lot struct {
+	quantity *big.Rat
+	cost     *big.Rat
+}
+
+// take removes up to quantity units from the lot, returning the number of
+// units and the cost removed.
+func (l *lot) take(quantity *big.Rat) (taken, cost *big.Rat) {
+	taken = new(big.Rat).Set(quantity)
+	if taken.Cmp(l.quantity) > 0 {
+		taken.Set(l.quantity)
+	}
+
+	cost = new(big.Rat)
+	if l.quantity.Sign() > 0 {
+		cost.Mul(l.cost, taken)
+		cost.Quo(cost, l.quantity)
+	}
+
+	l.quantity = new(big.Rat).Sub(l.quantity, taken)
+	l.cost = new(big.Rat).Sub(l.cost, cost)
+
+	return taken, cost
+}
+
+// fifoLedger keeps a queue of lots per currency, consuming the oldest first.
+type fifoLedger map[string][]*lot
+
+func (f fifoLedger) add(currency string, quantity, cost *big.Rat) {
+	if quantity.Sign() <= 0 {
+		return
+	}
+
+	f[currency] = append(f[currency], &lot{
+		quantity: new(big.Rat).Set(quantity),
+		cost:     new(big.Rat).Set(cost),
+	})
+}
+
+func (f fifoLedger) remove(currency string, quantity *big.Rat) *big.Rat {
+	remaining := new(big.Rat).Set(quantity)
+	total := new(big.Rat)
+
+	lots := f[currency]
+	for len(lots) > 0 && remaining.Sign() > 0 {
+		taken, cost := lots[0].take(remaining)
+		remaining.Sub(remaining, taken)
+		total.Add(total, cost)
+
+		if lots[0].quantity.Sign() == 0 {
+			lots = lots[1:]
+		}
+	}
+	f[currency] = lots
+
+	return total
+}
+
+// averageLedger keeps a single pooled lot per currency, so that every unit
+// held has the same cost.
+type averageLedger map[string]*lot
+
+func (a averageLedger) add(currency string, quantity, cost *big.Rat) {
+	if quantity.Sign() <= 0 {
+		return
+	}
+
+	pool, ok := a[currency]
+	if !ok {
+		pool = &lot{quantity: new(big.Rat), cost: new(big.Rat)}
+		a[currency] = pool
+	}
+
+	pool.quantity = new(big.Rat).Add(pool.quantity, quantity)
+	pool.cost = new(big.Rat).Add(pool.cost, cost)
+}
+
+func (a averageLedger) remove(currency string, quantity *big.Rat) *big.Rat {
+	pool, ok := a[currency]
+	if !ok {
+		return new(big.Rat)
+	}
+
+	_, cost := pool.take(quantity)
+
+	return cost
+}
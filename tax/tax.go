@@ -0,0 +1,505 @@
+// Package tax computes capital gains in ZAR from a VALR transaction history
+// and writes them as a CSV report per South African tax year.
+package tax
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/internal/decimal"
+)
+
+// Currency is the currency in which all gains are reported.
+const Currency = "ZAR"
+
+// ErrNoPrice is returned when a transaction cannot be valued in ZAR.
+var ErrNoPrice = errors.New("no ZAR price available")
+
+// incomeTypes are the transaction types which are taxed as income when they
+// are received.
+var incomeTypes = map[valr.TransactionType]bool{
+	valr.TransactionTypeMakerReward:       true,
+	valr.TransactionTypePromotionalRebate: true,
+	valr.TransactionTypeReferralRebate:    true,
+	valr.TransactionTypeReferralReward:    true,
+}
+
+// sast is South African Standard Time, which is used to determine the tax year
+// in which a transaction took place.
+var sast = time.FixedZone("SAST", 2*60*60)
+
+// Method is the method used to determine the cost basis of a disposal.
+type Method int
+
+// Method constants.
+const (
+	// FIFO matches each disposal against the oldest acquisitions first.
+	FIFO Method = iota
+
+	// WeightedAverage values each disposal at the average cost of all units
+	// held at the time.
+	WeightedAverage
+)
+
+// String returns the name of the method.
+func (m Method) String() string {
+	switch m {
+	case FIFO:
+		return "FIFO"
+	case WeightedAverage:
+		return "Weighted Average"
+	default:
+		return fmt.Sprintf("Method(%d)", int(m))
+	}
+}
+
+// PriceFunc returns the ZAR price of a single unit of currency at the given
+// time.
+type PriceFunc func(currency string, at time.Time) (*big.Rat, error)
+
+type config struct {
+	prices PriceFunc
+}
+
+// Option configures how a report is computed.
+type Option func(c *config)
+
+// WithPrices sets the function used to value transactions which do not
+// involve ZAR, such as crypto to crypto trades, deposits and fees charged in a
+// third currency. Without it such trades and fees fail with ErrNoPrice and
+// deposits are given a cost of zero.
+func WithPrices(p PriceFunc) Option {
+	return func(c *config) {
+		c.prices = p
+	}
+}
+
+// Disposal is a taxable disposal of a currency.
+type Disposal struct {
+	CostBasis     *big.Rat
+	Currency      string
+	Date          time.Time
+	Gain          *big.Rat
+	Proceeds      *big.Rat
+	Quantity      *big.Rat
+	TransactionID string
+	Type          valr.TransactionType
+}
+
+// Income is a reward or rebate received, valued in ZAR when it was received.
+type Income struct {
+	Currency      string
+	Date          time.Time
+	Quantity      *big.Rat
+	TransactionID string
+	Type          valr.TransactionType
+	Value         *big.Rat
+}
+
+// Report contains every disposal and income event in a transaction history.
+type Report struct {
+	Disposals []Disposal
+	Income    []Income
+	Method    Method
+}
+
+// Generate fetches the full transaction history for the account and computes
+// a Report using the given method.
+func Generate(ctx context.Context, c valr.PrivateClient, method Method,
+	opts ...Option) (*Report, error) {
+
+	transactions, err := valr.AllTransactions(ctx, c,
+		&valr.TransactionHistoryRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+	}
+
+	return Compute(transactions, method, opts...)
+}
+
+// Compute returns a Report for the given transactions, which may be in any
+// order. Disposals of more than the quantity held are given a cost of zero
+// for the difference. Trading fees are valued in ZAR, and are added to the
+// cost basis of purchases and subtracted from the proceeds of disposals.
+func Compute(transactions []valr.Transaction, method Method,
+	opts ...Option) (*Report, error) {
+
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var l ledger
+	switch method {
+	case FIFO:
+		l = make(fifoLedger)
+	case WeightedAverage:
+		l = make(averageLedger)
+	default:
+		return nil, fmt.Errorf("unknown method: %v", method)
+	}
+
+	sorted := append([]valr.Transaction(nil), transactions...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EventAt.Before(sorted[j].EventAt)
+	})
+
+	r := Report{Method: method}
+	for _, tx := range sorted {
+		if err := r.apply(tx, l, cfg); err != nil {
+			return nil, fmt.Errorf("failed to process transaction %s: %w",
+				tx.ID, err)
+		}
+	}
+
+	return &r, nil
+}
+
+// apply records the effect of a single transaction.
+func (r *Report) apply(tx valr.Transaction, l ledger, cfg config) error {
+	debit, err := decimal.Parse(tx.DebitValue)
+	if err != nil {
+		return err
+	}
+
+	credit, err := decimal.Parse(tx.CreditValue)
+	if err != nil {
+		return err
+	}
+
+	var typ valr.TransactionType
+	if tx.TypeInfo != nil {
+		typ = tx.TypeInfo.Type
+	}
+
+	isCrypto := func(currency string) bool {
+		return currency != "" && currency != Currency
+	}
+
+	switch {
+	// Buying crypto with ZAR.
+	case tx.DebitCurrency == Currency && isCrypto(tx.CreditCurrency):
+		fee, err := feeValue(tx, cfg.prices, debit, debit, credit)
+		if err != nil {
+			return err
+		}
+
+		l.add(tx.CreditCurrency, credit, new(big.Rat).Add(debit, fee))
+
+	// Selling crypto for ZAR.
+	case isCrypto(tx.DebitCurrency) && tx.CreditCurrency == Currency:
+		fee, err := feeValue(tx, cfg.prices, credit, debit, credit)
+		if err != nil {
+			return err
+		}
+
+		r.dispose(tx, typ, l, debit, new(big.Rat).Sub(credit, fee))
+
+	// Trading one crypto currency for another is a disposal of the debited
+	// currency at its ZAR value.
+	case isCrypto(tx.DebitCurrency) && isCrypto(tx.CreditCurrency):
+		if cfg.prices == nil {
+			return fmt.Errorf("%w for %s", ErrNoPrice, tx.DebitCurrency)
+		}
+
+		value, err := price(cfg.prices, tx.DebitCurrency, tx.EventAt, debit)
+		if err != nil {
+			return err
+		}
+
+		fee, err := feeValue(tx, cfg.prices, value, debit, credit)
+		if err != nil {
+			return err
+		}
+
+		r.dispose(tx, typ, l, debit, new(big.Rat).Sub(value, fee))
+		l.add(tx.CreditCurrency, credit, value)
+
+	// Rewards and rebates are income, valued at their cost per coin where
+	// VALR provides it.
+	case incomeTypes[typ] && tx.CreditCurrency != "":
+		value := credit
+		if isCrypto(tx.CreditCurrency) {
+			if value, err = rewardValue(tx, cfg.prices, credit); err != nil {
+				return err
+			}
+			l.add(tx.CreditCurrency, credit, value)
+		}
+
+		r.Income = append(r.Income, Income{
+			Currency:      tx.CreditCurrency,
+			Date:          tx.EventAt,
+			Quantity:      credit,
+			TransactionID: tx.ID,
+			Type:          typ,
+			Value:         value,
+		})
+
+	// Deposits and internal transfers move currency already owned into the
+	// account, so they carry no income.
+	case isCrypto(tx.CreditCurrency) && tx.DebitCurrency == "":
+		cost := new(big.Rat)
+		if cfg.prices != nil {
+			if cost, err = price(cfg.prices, tx.CreditCurrency, tx.EventAt,
+				credit); err != nil {
+				return err
+			}
+		}
+
+		l.add(tx.CreditCurrency, credit, cost)
+
+	// Withdrawals move currency out of the account without disposing of it.
+	case isCrypto(tx.DebitCurrency) && tx.CreditCurrency == "":
+		l.remove(tx.DebitCurrency, debit)
+	}
+
+	return nil
+}
+
+// dispose records the disposal of quantity units of the debited currency for
+// the given proceeds.
+func (r *Report) dispose(tx valr.Transaction, typ valr.TransactionType,
+	l ledger, quantity, proceeds *big.Rat) {
+
+	basis := l.remove(tx.DebitCurrency, quantity)
+
+	r.Disposals = append(r.Disposals, Disposal{
+		CostBasis:     basis,
+		Currency:      tx.DebitCurrency,
+		Date:          tx.EventAt,
+		Gain:          new(big.Rat).Sub(proceeds, basis),
+		Proceeds:      proceeds,
+		Quantity:      quantity,
+		TransactionID: tx.ID,
+		Type:          typ,
+	})
+}
+
+// rewardValue returns the ZAR value of a reward.
+func rewardValue(tx valr.Transaction, prices PriceFunc,
+	quantity *big.Rat) (*big.Rat, error) {
+
+	info := tx.AdditionalInfo
+	if info != nil && info.CostPerCoin > 0 {
+		switch info.CostPerCoinSymbol {
+		case "", "R", Currency:
+			cost := new(big.Rat).SetFloat64(info.CostPerCoin)
+			return cost.Mul(cost, quantity), nil
+		}
+	}
+
+	if prices == nil {
+		return new(big.Rat), nil
+	}
+
+	return price(prices, tx.CreditCurrency, tx.EventAt, quantity)
+}
+
+// feeValue returns the ZAR value of a trade's fee, where value is the ZAR
+// value of the trade. Fees in either currency of the trade are valued at the
+// trade's price, fees in any other currency require prices.
+func feeValue(tx valr.Transaction, prices PriceFunc, value, debit,
+	credit *big.Rat) (*big.Rat, error) {
+
+	fee, err := decimal.Parse(tx.FeeValue)
+	if err != nil {
+		return nil, err
+	}
+
+	var quantity *big.Rat
+	switch {
+	case fee.Sign() == 0 || tx.FeeCurrency == Currency:
+		return fee, nil
+	case tx.FeeCurrency == tx.DebitCurrency:
+		quantity = debit
+	case tx.FeeCurrency == tx.CreditCurrency:
+		quantity = credit
+	case prices == nil:
+		return nil, fmt.Errorf("%w for %s", ErrNoPrice, tx.FeeCurrency)
+	default:
+		return price(prices, tx.FeeCurrency, tx.EventAt, fee)
+	}
+
+	if quantity.Sign() == 0 {
+		return new(big.Rat), nil
+	}
+
+	fee.Mul(fee, value)
+	return fee.Quo(fee, quantity), nil
+}
+
+// price returns the ZAR value of quantity units of currency.
+func price(prices PriceFunc, currency string, at time.Time,
+	quantity *big.Rat) (*big.Rat, error) {
+
+	p, err := prices(currency, at)
+	if err != nil {
+		return nil, fmt.Errorf("%w for %s: %v", ErrNoPrice, currency, err)
+	}
+
+	return new(big.Rat).Mul(p, quantity), nil
+}
+
+// TaxYear returns the South African tax year in which t falls. A tax year runs
+// from 1 March to the end of February and is named after the year in which it
+// ends.
+func TaxYear(t time.Time) int {
+	t = t.In(sast)
+	if t.Month() >= time.March {
+		return t.Year() + 1
+	}
+
+	return t.Year()
+}
+
+// TaxYears returns the tax years in which disposals took place or income was
+// received, in ascending order.
+func (r *Report) TaxYears() []int {
+	seen := make(map[int]bool)
+
+	var years []int
+	add := func(t time.Time) {
+		year := TaxYear(t)
+		if !seen[year] {
+			seen[year] = true
+			years = append(years, year)
+		}
+	}
+
+	for _, d := range r.Disposals {
+		add(d.Date)
+	}
+
+	for _, i := range r.Income {
+		add(i.Date)
+	}
+	sort.Ints(years)
+
+	return years
+}
+
+// DisposalsIn returns the disposals which took place in the given tax year.
+func (r *Report) DisposalsIn(year int) []Disposal {
+	var disposals []Disposal
+	for _, d := range r.Disposals {
+		if TaxYear(d.Date) == year {
+			disposals = append(disposals, d)
+		}
+	}
+
+	return disposals
+}
+
+// IncomeIn returns the income received in the given tax year.
+func (r *Report) IncomeIn(year int) []Income {
+	var income []Income
+	for _, i := range r.Income {
+		if TaxYear(i.Date) == year {
+			income = append(income, i)
+		}
+	}
+
+	return income
+}
+
+// csvHeader is the header row of the capital gains report.
+var csvHeader = []string{"Date", "Transaction ID", "Type", "Currency",
+	"Quantity", "Proceeds (ZAR)", "Cost Basis (ZAR)", "Gain (ZAR)"}
+
+// csvIncomeHeader is the header row of the income section of the report.
+var csvIncomeHeader = []string{"Date", "Transaction ID", "Type", "Currency",
+	"Quantity", "Value (ZAR)"}
+
+// csvTime is the format of the dates in the report.
+const csvTime = "2006-01-02 15:04:05"
+
+// WriteCSV writes the capital gains report for the given tax year to w. Each
+// disposal is written as a row followed by a row containing the totals. If
+// any income was received in the year it follows in its own section, with a
+// header row, a row per reward or rebate and a row containing the total.
+func (r *Report) WriteCSV(w io.Writer, year int) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	proceeds, basis, gain := new(big.Rat), new(big.Rat), new(big.Rat)
+	for _, d := range r.DisposalsIn(year) {
+		proceeds.Add(proceeds, d.Proceeds)
+		basis.Add(basis, d.CostBasis)
+		gain.Add(gain, d.Gain)
+
+		if err := cw.Write([]string{
+			d.Date.In(sast).Format(csvTime),
+			d.TransactionID,
+			string(d.Type),
+			d.Currency,
+			decimal.FormatPlaces(d.Quantity, 8),
+			d.Proceeds.FloatString(2),
+			d.CostBasis.FloatString(2),
+			d.Gain.FloatString(2),
+		}); err != nil {
+			return fmt.Errorf("failed to write disposal: %w", err)
+		}
+	}
+
+	if err := cw.Write([]string{"Total", "", "", "", "",
+		proceeds.FloatString(2), basis.FloatString(2),
+		gain.FloatString(2)}); err != nil {
+		return fmt.Errorf("failed to write totals: %w", err)
+	}
+
+	if err := r.writeIncome(cw, year); err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to flush report: %w", err)
+	}
+
+	return nil
+}
+
+// writeIncome writes the income section of the report for the given tax year,
+// if any income was received in it.
+func (r *Report) writeIncome(cw *csv.Writer, year int) error {
+	income := r.IncomeIn(year)
+	if len(income) == 0 {
+		return nil
+	}
+
+	if err := cw.Write(csvIncomeHeader); err != nil {
+		return fmt.Errorf("failed to write income header: %w", err)
+	}
+
+	total := new(big.Rat)
+	for _, i := range income {
+		total.Add(total, i.Value)
+
+		if err := cw.Write([]string{
+			i.Date.In(sast).Format(csvTime),
+			i.TransactionID,
+			string(i.Type),
+			i.Currency,
+			decimal.FormatPlaces(i.Quantity, 8),
+			i.Value.FloatString(2),
+		}); err != nil {
+			return fmt.Errorf("failed to write income: %w", err)
+		}
+	}
+
+	if err := cw.Write([]string{"Total Income", "", "", "", "",
+		total.FloatString(2)}); err != nil {
+		return fmt.Errorf("failed to write income total: %w", err)
+	}
+
+	return nil
+}
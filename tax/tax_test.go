@@ -0,0 +1,294 @@
+package tax_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/tax"
+	"github.com/stretchr/testify/suite"
+)
+
+type taxTestSuite struct {
+	suite.Suite
+	transactions []valr.Transaction
+}
+
+func TestTaxTestSuite(t *testing.T) {
+	suite.Run(t, new(taxTestSuite))
+}
+
+func transaction(id string, typ valr.TransactionType, at string,
+	debitCurrency, debitValue, creditCurrency,
+	creditValue string) valr.Transaction {
+
+	eventAt, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		panic(err)
+	}
+
+	return valr.Transaction{
+		CreditCurrency: creditCurrency,
+		CreditValue:    creditValue,
+		DebitCurrency:  debitCurrency,
+		DebitValue:     debitValue,
+		EventAt:        eventAt,
+		ID:             id,
+		TypeInfo:       &valr.TransactionTypeInfo{Type: typ},
+	}
+}
+
+func (suite *taxTestSuite) SetupTest() {
+	reward := transaction("4", valr.TransactionTypeMakerReward,
+		"2021-02-15T10:00:00Z", "", "", "BTC", "0.5")
+	reward.AdditionalInfo = &valr.TransactionInfo{CostPerCoin: 200,
+		CostPerCoinSymbol: "R"}
+
+	// Transactions are listed most recent first, as returned by VALR.
+	suite.transactions = []valr.Transaction{
+		transaction("7", valr.TransactionTypeFiatWithdrawal,
+			"2021-03-11T10:00:00Z", "ZAR", "500", "", ""),
+		transaction("6", valr.TransactionTypeLimitSell,
+			"2021-03-10T10:00:00Z", "BTC", "1.5", "ZAR", "600"),
+		transaction("5", valr.TransactionTypeMarketSell,
+			"2021-02-20T10:00:00Z", "BTC", "0.5", "ZAR", "150"),
+		reward,
+		transaction("3", valr.TransactionTypeSimpleBuy,
+			"2021-02-10T10:00:00Z", "ZAR", "300", "BTC", "1"),
+		transaction("2", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"),
+		transaction("1", valr.TransactionTypeFiatDeposit,
+			"2021-01-01T10:00:00Z", "", "", "ZAR", "1000"),
+	}
+}
+
+func (suite *taxTestSuite) requireGains(r *tax.Report, gains ...string) {
+	suite.Require().Len(r.Disposals, len(gains))
+	for i, gain := range gains {
+		suite.Require().Equal(gain, r.Disposals[i].Gain.FloatString(2))
+	}
+}
+
+func (suite *taxTestSuite) TestFIFO() {
+	r, err := tax.Compute(suite.transactions, tax.FIFO)
+	suite.Require().NoError(err)
+	suite.requireGains(r, "100.00", "250.00")
+
+	suite.Require().Equal("50.00", r.Disposals[0].CostBasis.FloatString(2))
+	suite.Require().Equal("350.00", r.Disposals[1].CostBasis.FloatString(2))
+}
+
+func (suite *taxTestSuite) TestWeightedAverage() {
+	r, err := tax.Compute(suite.transactions, tax.WeightedAverage)
+	suite.Require().NoError(err)
+	suite.requireGains(r, "50.00", "300.00")
+
+	suite.Require().Equal("100.00", r.Disposals[0].CostBasis.FloatString(2))
+	suite.Require().Equal("300.00", r.Disposals[1].CostBasis.FloatString(2))
+}
+
+func (suite *taxTestSuite) TestRewardsAreIncome() {
+	r, err := tax.Compute(suite.transactions, tax.FIFO)
+	suite.Require().NoError(err)
+
+	suite.Require().Len(r.Income, 1)
+	suite.Require().Equal("BTC", r.Income[0].Currency)
+	suite.Require().Equal("100.00", r.Income[0].Value.FloatString(2))
+	suite.Require().Len(r.IncomeIn(2021), 1)
+	suite.Require().Empty(r.IncomeIn(2022))
+}
+
+func (suite *taxTestSuite) TestOversoldHasZeroCost() {
+	r, err := tax.Compute([]valr.Transaction{
+		transaction("1", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"),
+		transaction("2", valr.TransactionTypeLimitSell,
+			"2021-01-11T10:00:00Z", "BTC", "2", "ZAR", "400"),
+	}, tax.FIFO)
+	suite.Require().NoError(err)
+	suite.requireGains(r, "300.00")
+}
+
+func (suite *taxTestSuite) TestWithdrawalsAreNotDisposals() {
+	r, err := tax.Compute([]valr.Transaction{
+		transaction("1", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"),
+		transaction("2", valr.TransactionTypeLimitBuy,
+			"2021-01-11T10:00:00Z", "ZAR", "300", "BTC", "1"),
+		transaction("3", valr.TransactionTypeBlockchainSend,
+			"2021-01-12T10:00:00Z", "BTC", "1", "", ""),
+		transaction("4", valr.TransactionTypeLimitSell,
+			"2021-01-13T10:00:00Z", "BTC", "1", "ZAR", "500"),
+	}, tax.FIFO)
+	suite.Require().NoError(err)
+	suite.requireGains(r, "200.00")
+}
+
+// withFee adds a trading fee to a transaction.
+func withFee(tx valr.Transaction, currency, value string) valr.Transaction {
+	tx.FeeCurrency, tx.FeeValue = currency, value
+	return tx
+}
+
+func (suite *taxTestSuite) TestBuyFeesAddToCostBasis() {
+	r, err := tax.Compute([]valr.Transaction{
+		withFee(transaction("1", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"), "BTC", "0.01"),
+		transaction("2", valr.TransactionTypeLimitSell,
+			"2021-01-11T10:00:00Z", "BTC", "1", "ZAR", "200"),
+	}, tax.FIFO)
+	suite.Require().NoError(err)
+
+	// The fee of 0.01 BTC is valued at the purchase price of 100.
+	suite.requireGains(r, "99.00")
+	suite.Require().Equal("101.00", r.Disposals[0].CostBasis.FloatString(2))
+}
+
+func (suite *taxTestSuite) TestSellFeesReduceProceeds() {
+	r, err := tax.Compute([]valr.Transaction{
+		transaction("1", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"),
+		withFee(transaction("2", valr.TransactionTypeLimitSell,
+			"2021-01-11T10:00:00Z", "BTC", "1", "ZAR", "200"), "ZAR", "2"),
+	}, tax.FIFO)
+	suite.Require().NoError(err)
+	suite.requireGains(r, "98.00")
+	suite.Require().Equal("198.00", r.Disposals[0].Proceeds.FloatString(2))
+
+	// Fees in a currency outside the trade must be priced.
+	_, err = tax.Compute([]valr.Transaction{
+		withFee(transaction("1", valr.TransactionTypeLimitSell,
+			"2021-01-11T10:00:00Z", "BTC", "1", "ZAR", "200"), "ETH", "1"),
+	}, tax.FIFO)
+	suite.Require().True(errors.Is(err, tax.ErrNoPrice))
+}
+
+func (suite *taxTestSuite) TestTransfersAreNotIncome() {
+	r, err := tax.Compute([]valr.Transaction{
+		transaction("1", valr.TransactionTypeInternalTransfer,
+			"2021-01-10T10:00:00Z", "", "", "BTC", "1"),
+		transaction("2", valr.TransactionTypeReferralRebate,
+			"2021-01-11T10:00:00Z", "", "", "ZAR", "5"),
+		transaction("3", valr.TransactionTypeLimitSell,
+			"2021-01-12T10:00:00Z", "BTC", "1", "ZAR", "200"),
+	}, tax.FIFO)
+	suite.Require().NoError(err)
+
+	// The transfer is treated like a deposit with no known cost.
+	suite.requireGains(r, "200.00")
+	suite.Require().Len(r.Income, 1)
+	suite.Require().Equal(valr.TransactionTypeReferralRebate,
+		r.Income[0].Type)
+	suite.Require().Equal("5.00", r.Income[0].Value.FloatString(2))
+}
+
+func (suite *taxTestSuite) TestCryptoTrades() {
+	transactions := []valr.Transaction{
+		transaction("1", valr.TransactionTypeLimitBuy,
+			"2021-01-10T10:00:00Z", "ZAR", "100", "BTC", "1"),
+		transaction("2", valr.TransactionTypeLimitBuy,
+			"2021-01-11T10:00:00Z", "BTC", "1", "ETH", "10"),
+		transaction("3", valr.TransactionTypeLimitSell,
+			"2021-01-12T10:00:00Z", "ETH", "10", "ZAR", "1000"),
+	}
+
+	_, err := tax.Compute(transactions, tax.FIFO)
+	suite.Require().True(errors.Is(err, tax.ErrNoPrice))
+
+	prices := func(currency string, _ time.Time) (*big.Rat, error) {
+		suite.Require().Equal("BTC", currency)
+		return big.NewRat(800, 1), nil
+	}
+
+	r, err := tax.Compute(transactions, tax.FIFO, tax.WithPrices(prices))
+	suite.Require().NoError(err)
+	suite.requireGains(r, "700.00", "200.00")
+	suite.Require().Equal("ETH", r.Disposals[1].Currency)
+}
+
+func (suite *taxTestSuite) TestTaxYear() {
+	testcases := []struct {
+		at   string
+		year int
+	}{
+		{at: "2021-02-28T21:59:59Z", year: 2021},
+		{at: "2021-02-28T22:00:00Z", year: 2022},
+		{at: "2021-12-31T12:00:00Z", year: 2022},
+		{at: "2020-02-29T12:00:00Z", year: 2020},
+	}
+
+	for _, test := range testcases {
+		at, err := time.Parse(time.RFC3339, test.at)
+		suite.Require().NoError(err)
+		suite.Require().Equal(test.year, tax.TaxYear(at), test.at)
+	}
+}
+
+func (suite *taxTestSuite) TestWriteCSV() {
+	r, err := tax.Compute(suite.transactions, tax.FIFO)
+	suite.Require().NoError(err)
+	suite.Require().Equal([]int{2021, 2022}, r.TaxYears())
+
+	var buf bytes.Buffer
+	suite.Require().NoError(r.WriteCSV(&buf, 2022))
+	suite.Require().Equal("Date,Transaction ID,Type,Currency,Quantity,"+
+		"Proceeds (ZAR),Cost Basis (ZAR),Gain (ZAR)\n"+
+		"2021-03-10 12:00:00,6,LIMIT_SELL,BTC,1.5,600.00,350.00,250.00\n"+
+		"Total,,,,,600.00,350.00,250.00\n", buf.String())
+}
+
+func (suite *taxTestSuite) TestWriteCSVIncome() {
+	referral := transaction("8", valr.TransactionTypeReferralReward,
+		"2022-04-01T10:00:00Z", "", "", "ZAR", "25")
+	r, err := tax.Compute(append(suite.transactions, referral), tax.FIFO)
+	suite.Require().NoError(err)
+
+	// A year with only income is still reported.
+	suite.Require().Equal([]int{2021, 2022, 2023}, r.TaxYears())
+
+	var buf bytes.Buffer
+	suite.Require().NoError(r.WriteCSV(&buf, 2021))
+	suite.Require().Equal("Date,Transaction ID,Type,Currency,Quantity,"+
+		"Proceeds (ZAR),Cost Basis (ZAR),Gain (ZAR)\n"+
+		"2021-02-20 12:00:00,5,MARKET_SELL,BTC,0.5,150.00,50.00,100.00\n"+
+		"Total,,,,,150.00,50.00,100.00\n"+
+		"Date,Transaction ID,Type,Currency,Quantity,Value (ZAR)\n"+
+		"2021-02-15 12:00:00,4,MAKER_REWARD,BTC,0.5,100.00\n"+
+		"Total Income,,,,,100.00\n", buf.String())
+
+	buf.Reset()
+	suite.Require().NoError(r.WriteCSV(&buf, 2023))
+	suite.Require().Equal("Date,Transaction ID,Type,Currency,Quantity,"+
+		"Proceeds (ZAR),Cost Basis (ZAR),Gain (ZAR)\n"+
+		"Total,,,,,0.00,0.00,0.00\n"+
+		"Date,Transaction ID,Type,Currency,Quantity,Value (ZAR)\n"+
+		"2022-04-01 12:00:00,8,REFERRAL_REWARD,ZAR,25,25.00\n"+
+		"Total Income,,,,,25.00\n", buf.String())
+}
+
+func (suite *taxTestSuite) TestGenerate() {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+		r *http.Request) {
+		// Serve everything in a single short page.
+		if r.URL.Query().Get("skip") != "" ||
+			r.URL.Query().Get("beforeId") != "" {
+			w.Write([]byte("[]"))
+			return
+		}
+		json.NewEncoder(w).Encode(suite.transactions)
+	}))
+	defer srv.Close()
+
+	c := valr.NewClientForTesting(suite.T(), srv.URL)
+	r, err := tax.Generate(context.Background(), c, tax.WeightedAverage)
+	suite.Require().NoError(err)
+	suite.Require().Equal(tax.WeightedAverage, r.Method)
+	suite.requireGains(r, "50.00", "300.00")
+}
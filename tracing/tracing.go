@@ -0,0 +1,76 @@
+// Package tracing provides an OpenTelemetry integration for the VALR client,
+// wrapping every call in a span named after the endpoint.
+package tracing
+
+import (
+	"errors"
+
+	"github.com/nickcorin/valr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/nickcorin/valr/tracing"
+
+// Middleware returns a valr.Middleware which records a span for every call
+// made by the client. Spans are children of the span in the caller's context
+// and are named after the endpoint, e.g. "valr.OrderBook". The span's context
+// is injected into the outgoing request's headers using the global
+// TextMapPropagator. If tp is nil, the global TracerProvider is used.
+func Middleware(tp trace.TracerProvider) valr.Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(instrumentationName)
+
+	return func(next valr.Handler) valr.Handler {
+		return func(call *valr.Call) {
+			ctx, span := tracer.Start(call.Request.Context(),
+				"valr."+call.Endpoint, trace.WithSpanKind(trace.SpanKindClient))
+			defer span.End()
+
+			span.SetAttributes(
+				attribute.String("valr.endpoint", call.Endpoint),
+				attribute.String("http.method", call.Request.Method),
+				attribute.String("http.url", call.Request.URL.String()),
+			)
+
+			if call.Pair != "" {
+				span.SetAttributes(attribute.String("valr.pair", call.Pair))
+			}
+
+			if call.Currency != "" {
+				span.SetAttributes(attribute.String("valr.currency",
+					call.Currency))
+			}
+
+			call.Request = call.Request.WithContext(ctx)
+			otel.GetTextMapPropagator().Inject(ctx,
+				propagation.HeaderCarrier(call.Request.Header))
+			next(call)
+
+			if call.Response != nil {
+				span.SetAttributes(attribute.Int("http.status_code",
+					call.Response.StatusCode))
+			}
+
+			if call.Err == nil {
+				return
+			}
+
+			var apiErr *valr.Error
+			if errors.As(call.Err, &apiErr) {
+				span.SetAttributes(
+					attribute.Int("valr.error.code", apiErr.Code),
+					attribute.String("valr.error.message", apiErr.Message),
+				)
+			}
+
+			span.RecordError(call.Err)
+			span.SetStatus(codes.Error, call.Err.Error())
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nickcorin/valr"
+	"github.com/nickcorin/valr/tracing"
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingTestSuite(t *testing.T) {
+	suite.Run(t, new(tracingTestSuite))
+}
+
+type tracingTestSuite struct {
+	suite.Suite
+	client   valr.Client
+	headers  http.Header
+	provider *sdktrace.TracerProvider
+	recorder *tracetest.SpanRecorder
+	server   *httptest.Server
+}
+
+func (suite *tracingTestSuite) SetupTest() {
+	suite.server = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			suite.headers = r.Header
+			switch r.URL.Path {
+			case "/public/BTCZAR/orderbook":
+				w.Write([]byte(`{"Asks":[],"Bids":[]}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":-11,"message":"Invalid currency"}`))
+			}
+		}))
+
+	suite.recorder = tracetest.NewSpanRecorder()
+	suite.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(suite.recorder))
+
+	suite.client = valr.NewClientForTesting(suite.T(), suite.server.URL,
+		valr.WithMiddleware(tracing.Middleware(suite.provider)))
+}
+
+func (suite *tracingTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *tracingTestSuite) attribute(span sdktrace.ReadOnlySpan,
+	key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+
+	return attribute.Value{}
+}
+
+func (suite *tracingTestSuite) TestSpan() {
+	ctx, parent := suite.provider.Tracer("test").Start(context.Background(),
+		"parent")
+	_, err := suite.client.OrderBook(ctx, "BTCZAR")
+	suite.Require().NoError(err)
+	parent.End()
+
+	spans := suite.recorder.Ended()
+	suite.Require().Len(spans, 2)
+
+	span := spans[0]
+	suite.Require().Equal("valr.OrderBook", span.Name())
+	suite.Require().Equal(trace.SpanKindClient, span.SpanKind())
+	suite.Require().Equal(parent.SpanContext().SpanID(),
+		span.Parent().SpanID())
+	suite.Require().Equal(codes.Unset, span.Status().Code)
+
+	suite.Require().Equal("BTCZAR",
+		suite.attribute(span, "valr.pair").AsString())
+	suite.Require().Equal(int64(http.StatusOK),
+		suite.attribute(span, "http.status_code").AsInt64())
+}
+
+func (suite *tracingTestSuite) TestErrorSpan() {
+	_, err := suite.client.DepositAddress(context.Background(), "FOO")
+	suite.Require().Error(err)
+
+	spans := suite.recorder.Ended()
+	suite.Require().Len(spans, 1)
+
+	span := spans[0]
+	suite.Require().Equal("valr.DepositAddress", span.Name())
+	suite.Require().Equal(codes.Error, span.Status().Code)
+
+	suite.Require().Equal("FOO",
+		suite.attribute(span, "valr.currency").AsString())
+	suite.Require().Equal(int64(http.StatusBadRequest),
+		suite.attribute(span, "http.status_code").AsInt64())
+	suite.Require().Equal(int64(-11),
+		suite.attribute(span, "valr.error.code").AsInt64())
+	suite.Require().Equal("Invalid currency",
+		suite.attribute(span, "valr.error.message").AsString())
+}
+
+func (suite *tracingTestSuite) TestPropagation() {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previous)
+
+	_, err := suite.client.OrderBook(context.Background(), "BTCZAR")
+	suite.Require().NoError(err)
+
+	spans := suite.recorder.Ended()
+	suite.Require().Len(spans, 1)
+
+	sc := spans[0].SpanContext()
+	suite.Require().Equal("00-"+sc.TraceID().String()+"-"+
+		sc.SpanID().String()+"-01", suite.headers.Get("traceparent"))
+}